@@ -4,6 +4,8 @@ go 1.16
 
 require (
 	github.com/blang/semver/v4 v4.0.0
+	github.com/deislabs/oras v0.8.1
+	github.com/docker/cli v0.0.0-20200130152716-5d0cf8839492
 	github.com/fatih/structtag v1.1.0
 	github.com/go-logr/logr v0.3.0
 	github.com/iancoleman/strcase v0.0.0-20191112232945-16388991a334
@@ -0,0 +1,128 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ClusterScope is the namespace path segment of cluster scoped CRs.
+const ClusterScope = "_"
+
+type handler struct {
+	store  *Store
+	prefix string
+}
+
+// NewHandler - returns a read-only HTTP API for the artifacts in store, served
+// under prefix:
+//
+//	GET <prefix><group>/<version>/<kind>/<namespace>/<name>/
+//	  lists the runs of a CR, newest first.
+//	GET <prefix><group>/<version>/<kind>/<namespace>/<name>/<ident>/stdout
+//	  returns the output of a run.
+//	GET <prefix><group>/<version>/<kind>/<namespace>/<name>/<ident>/job_events
+//	  returns the job events of a run as a JSON array.
+//
+// The ident "latest" is the newest run, and the namespace of cluster scoped
+// CRs is ClusterScope.
+func NewHandler(store *Store, prefix string) http.Handler {
+	return &handler{store: store, prefix: prefix}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, h.prefix), "/"), "/")
+	for _, s := range segments {
+		if s == "" || s == "." || s == ".." || strings.Contains(s, `\`) {
+			http.Error(w, "invalid path", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(segments) != 5 && len(segments) != 7 {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	o := Owner{Group: segments[0], Version: segments[1], Kind: segments[2], Namespace: segments[3], Name: segments[4]}
+	if o.Namespace == ClusterScope {
+		o.Namespace = ""
+	}
+
+	if len(segments) == 5 {
+		runs, err := h.store.Runs(o)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, runs)
+		return
+	}
+
+	ident := segments[5]
+	if ident == latest {
+		var err error
+		if ident, err = h.store.Latest(o); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	switch segments[6] {
+	case "stdout":
+		stdout, err := h.store.Stdout(o, ident)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write(stdout)
+	case "job_events":
+		events, err := h.store.JobEvents(o, ident)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		// The events are written as they are, since they are JSON already.
+		writeJSON(w, json.RawMessage(append(append([]byte("["), bytes.Join(events, []byte(","))...), ']')))
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	log.Error(err, "Failed to read artifacts")
+	http.Error(w, "failed to read artifacts", http.StatusInternalServerError)
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifacts
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+	inputDir := writeRun(t, s.Root, "42", "successful", time.Now())
+	if err := os.Symlink(filepath.Join(inputDir, "artifacts", "42"), filepath.Join(inputDir, "artifacts", latest)); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(s, "/artifacts/")
+
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "stdout",
+			path:         "/artifacts/app.example.com/v1alpha1/Database/default/example/42/stdout",
+			expectedCode: http.StatusOK,
+			expectedBody: "PLAY RECAP 42",
+		},
+		{
+			name:         "latest job events",
+			path:         "/artifacts/app.example.com/v1alpha1/Database/default/example/latest/job_events",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"counter":1},{"counter":2},{"counter":10}]`,
+		},
+		{
+			name:         "unknown run",
+			path:         "/artifacts/app.example.com/v1alpha1/Database/default/example/43/stdout",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unknown file",
+			path:         "/artifacts/app.example.com/v1alpha1/Database/default/example/42/env",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "path traversal",
+			path:         "/artifacts/app.example.com/v1alpha1/Database/default/../../../../../42/stdout",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "write",
+			method:       http.MethodDelete,
+			path:         "/artifacts/app.example.com/v1alpha1/Database/default/example/",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "http://localhost", nil)
+			// set the path directly, since the request would clean it
+			req.URL.Path = tc.path
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.expectedCode {
+				t.Fatalf("expected code %d, got %d: %s", tc.expectedCode, rec.Code, rec.Body.String())
+			}
+			if tc.expectedBody != "" && rec.Body.String() != tc.expectedBody {
+				t.Fatalf("expected body %q, got %q", tc.expectedBody, rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("runs", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+			"/artifacts/app.example.com/v1alpha1/Database/default/example/", nil))
+		var runs []Run
+		if err := json.Unmarshal(rec.Body.Bytes(), &runs); err != nil {
+			t.Fatalf("unexpected response %q: %v", rec.Body.String(), err)
+		}
+		if len(runs) != 1 || runs[0].Ident != "42" || runs[0].Status != "successful" || !runs[0].Local {
+			t.Fatalf("unexpected runs: %+v", runs)
+		}
+	})
+}
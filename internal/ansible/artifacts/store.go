@@ -0,0 +1,446 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifacts
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var log = logf.Log.WithName("artifacts")
+
+const (
+	// archiveExt is the extension of the archive of a run.
+	archiveExt = ".tar.gz"
+	// latest is the symlink ansible-operator points at the newest run of a CR.
+	latest = "latest"
+	// pruneInterval is how often artifacts older than the maximum age are removed.
+	pruneInterval = time.Minute
+)
+
+// Owner - identifies the CR that runs were for.
+type Owner struct {
+	Group     string
+	Version   string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// path returns the path of o's input directory relative to the Store root,
+// which is empty for cluster scoped CRs.
+func (o Owner) path() string {
+	return filepath.Join(o.Group, o.Version, o.Kind, o.Namespace, o.Name)
+}
+
+// Run - describes the artifacts of a run.
+type Run struct {
+	Ident string    `json:"ident"`
+	Time  time.Time `json:"time"`
+	// Status is the status ansible-runner reported, such as "successful" or "failed".
+	Status string `json:"status,omitempty"`
+	// RC is the return code of the playbook, if it completed.
+	RC *int `json:"rc,omitempty"`
+	// Local is true while the artifacts are in the input directory of the CR.
+	Local bool `json:"local"`
+	// Archived is true if the artifacts were archived.
+	Archived bool `json:"archived"`
+}
+
+// Store - the artifacts that ansible-runner writes to the input directories
+// of CRs under Root, and their archives under ArchiveDir.
+type Store struct {
+	// Root is the directory holding the input directory of every CR.
+	Root string
+	// ArchiveDir, if set, receives a gzipped tarball of the artifacts of
+	// every run passed to Archive.
+	ArchiveDir string
+	// MaxArchives is the number of archives kept per CR. Zero keeps all of them.
+	MaxArchives int
+	// MaxAge is the age after which the artifacts and archives of runs are
+	// removed. Zero keeps them until they are rotated.
+	MaxAge time.Duration
+}
+
+// Archive writes the artifacts of the run with ident of the CR whose input
+// directory is inputDir to ArchiveDir, if set, and removes the oldest archives
+// of the CR beyond MaxArchives.
+func (s *Store) Archive(inputDir, ident string) error {
+	if s.ArchiveDir == "" {
+		return nil
+	}
+	rel, err := filepath.Rel(s.Root, inputDir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("input directory %q is not under %q", inputDir, s.Root)
+	}
+	dir := filepath.Join(s.ArchiveDir, rel)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that a partial archive is never read.
+	dst := filepath.Join(dir, ident+archiveExt)
+	f, err := ioutil.TempFile(dir, "."+ident)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := writeArchive(f, filepath.Join(inputDir, "artifacts", ident)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), dst); err != nil {
+		return err
+	}
+	return s.rotateArchives(dir)
+}
+
+func writeArchive(w io.Writer, src string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		name, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func (s *Store) rotateArchives(dir string) error {
+	if s.MaxArchives <= 0 {
+		return nil
+	}
+	archives, err := listArchives(dir)
+	if err != nil {
+		return err
+	}
+	for i := s.MaxArchives; i < len(archives); i++ {
+		if err := os.Remove(filepath.Join(dir, archives[i].Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listArchives returns the archives in dir, newest first.
+func listArchives(dir string) ([]os.FileInfo, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	archives := infos[:0]
+	for _, info := range infos {
+		if info.Mode().IsRegular() && strings.HasSuffix(info.Name(), archiveExt) {
+			archives = append(archives, info)
+		}
+	}
+	sort.SliceStable(archives, func(i, j int) bool { return archives[i].ModTime().After(archives[j].ModTime()) })
+	return archives, nil
+}
+
+// Runs returns the runs of o with local or archived artifacts, newest first.
+func (s *Store) Runs(o Owner) ([]Run, error) {
+	runs := map[string]*Run{}
+	infos, err := ioutil.ReadDir(filepath.Join(s.Root, o.path(), "artifacts"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		run := &Run{Ident: info.Name(), Time: info.ModTime(), Local: true}
+		files, err := s.readLocal(o, run.Ident, isRunResult)
+		if err != nil {
+			return nil, err
+		}
+		run.setResult(files)
+		runs[run.Ident] = run
+	}
+	if s.ArchiveDir != "" {
+		archives, err := listArchives(filepath.Join(s.ArchiveDir, o.path()))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for _, info := range archives {
+			ident := strings.TrimSuffix(info.Name(), archiveExt)
+			if run, ok := runs[ident]; ok {
+				run.Archived = true
+				continue
+			}
+			run := &Run{Ident: ident, Time: info.ModTime(), Archived: true}
+			files, err := s.readArchive(o, ident, isRunResult)
+			if err != nil {
+				return nil, err
+			}
+			run.setResult(files)
+			runs[ident] = run
+		}
+	}
+
+	result := make([]Run, 0, len(runs))
+	for _, run := range runs {
+		result = append(result, *run)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Time.After(result[j].Time) })
+	return result, nil
+}
+
+func isRunResult(name string) bool {
+	return name == "status" || name == "rc"
+}
+
+func (r *Run) setResult(files map[string][]byte) {
+	r.Status = strings.TrimSpace(string(files["status"]))
+	if rc, err := strconv.Atoi(strings.TrimSpace(string(files["rc"]))); err == nil {
+		r.RC = &rc
+	}
+}
+
+// Latest returns the ident of the newest run of o.
+func (s *Store) Latest(o Owner) (string, error) {
+	target, err := os.Readlink(filepath.Join(s.Root, o.path(), "artifacts", latest))
+	if err == nil {
+		return filepath.Base(target), nil
+	}
+	runs, err := s.Runs(o)
+	if err != nil {
+		return "", err
+	}
+	if len(runs) == 0 {
+		return "", fmt.Errorf("no runs of %s: %w", o.path(), os.ErrNotExist)
+	}
+	return runs[0].Ident, nil
+}
+
+// Stdout returns the output of the run with ident of o.
+func (s *Store) Stdout(o Owner, ident string) ([]byte, error) {
+	files, err := s.read(o, ident, func(name string) bool { return name == "stdout" })
+	if err != nil {
+		return nil, err
+	}
+	return files["stdout"], nil
+}
+
+// JobEvents returns the job events of the run with ident of o, as the JSON
+// ansible-runner wrote them, in the order they occurred.
+func (s *Store) JobEvents(o Owner, ident string) ([][]byte, error) {
+	files, err := s.read(o, ident, func(name string) bool {
+		return strings.HasPrefix(name, "job_events/") && strings.HasSuffix(name, ".json")
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Event files are named after the counter of the event.
+	type event struct {
+		counter int
+		data    []byte
+	}
+	events := make([]event, 0, len(files))
+	for name, data := range files {
+		counter, _ := strconv.Atoi(strings.SplitN(strings.TrimPrefix(name, "job_events/"), "-", 2)[0])
+		events = append(events, event{counter: counter, data: data})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].counter < events[j].counter })
+	result := make([][]byte, len(events))
+	for i, e := range events {
+		result[i] = e.data
+	}
+	return result, nil
+}
+
+// read returns the files of the run with ident of o whose slash separated
+// names keep accepts, from its local artifacts if they still exist and from
+// its archive otherwise.
+func (s *Store) read(o Owner, ident string, keep func(string) bool) (map[string][]byte, error) {
+	if _, err := os.Stat(filepath.Join(s.Root, o.path(), "artifacts", ident)); err == nil {
+		return s.readLocal(o, ident, keep)
+	}
+	if s.ArchiveDir != "" {
+		files, err := s.readArchive(o, ident, keep)
+		if !errors.Is(err, os.ErrNotExist) {
+			return files, err
+		}
+	}
+	return nil, fmt.Errorf("no run %s of %s: %w", ident, o.path(), os.ErrNotExist)
+}
+
+func (s *Store) readLocal(o Owner, ident string, keep func(string) bool) (map[string][]byte, error) {
+	dir := filepath.Join(s.Root, o.path(), "artifacts", ident)
+	files := map[string][]byte{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil || !keep(filepath.ToSlash(name)) {
+			return err
+		}
+		files[filepath.ToSlash(name)], err = ioutil.ReadFile(path)
+		return err
+	})
+	return files, err
+}
+
+func (s *Store) readArchive(o Owner, ident string, keep func(string) bool) (map[string][]byte, error) {
+	f, err := os.Open(filepath.Join(s.ArchiveDir, o.path(), ident+archiveExt))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gr)
+	files := map[string][]byte{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !keep(hdr.Name) {
+			continue
+		}
+		if files[hdr.Name], err = ioutil.ReadAll(tr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Prune removes the local artifacts and archives of runs that are older than
+// MaxAge at now. Local artifacts of runs that have not completed are kept.
+func (s *Store) Prune(now time.Time) error {
+	if s.MaxAge <= 0 {
+		return nil
+	}
+	cutoff := now.Add(-s.MaxAge)
+	err := filepath.Walk(s.Root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() || info.Name() != "artifacts" {
+			return nil
+		}
+		return pruneRuns(path, cutoff)
+	})
+	if err != nil || s.ArchiveDir == "" {
+		return err
+	}
+	return filepath.Walk(s.ArchiveDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() && strings.HasSuffix(info.Name(), archiveExt) && info.ModTime().Before(cutoff) {
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// pruneRuns removes the completed runs in the artifacts directory dir that
+// were last modified before cutoff, along with the latest symlink if it
+// pointed at one of them.
+func pruneRuns(dir string, cutoff time.Time) error {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	latestTarget, _ := os.Readlink(filepath.Join(dir, latest))
+	for _, info := range infos {
+		if !info.IsDir() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		runDir := filepath.Join(dir, info.Name())
+		status, err := ioutil.ReadFile(filepath.Join(runDir, "status"))
+		if s := strings.TrimSpace(string(status)); err == nil && (s == "starting" || s == "running") {
+			continue
+		}
+		if err := os.RemoveAll(runDir); err != nil {
+			return err
+		}
+		if latestTarget != "" && filepath.Base(latestTarget) == info.Name() {
+			if err := os.Remove(filepath.Join(dir, latest)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Start prunes expired artifacts until ctx is done.
+func (s *Store) Start(ctx context.Context) error {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.Prune(time.Now()); err != nil {
+			log.Error(err, "Failed to prune artifacts")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
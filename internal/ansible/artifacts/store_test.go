@@ -0,0 +1,165 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifacts
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testOwner = Owner{Group: "app.example.com", Version: "v1alpha1", Kind: "Database", Namespace: "default", Name: "example"}
+
+// writeRun writes the artifacts of a completed run of testOwner under root,
+// as ansible-runner would, and returns the input directory of testOwner.
+func writeRun(t *testing.T, root, ident, status string, modTime time.Time) string {
+	inputDir := filepath.Join(root, testOwner.path())
+	runDir := filepath.Join(inputDir, "artifacts", ident)
+	files := map[string]string{
+		"status":                    status,
+		"rc":                        "0",
+		"stdout":                    "PLAY RECAP " + ident,
+		"job_events/2-b.json":       `{"counter":2}`,
+		"job_events/10-c.json":      `{"counter":10}`,
+		"job_events/1-a.json":       `{"counter":1}`,
+		"job_events/partial.json~":  "ignored",
+		"fact_cache/localhost/fact": "{}",
+	}
+	for name, content := range files {
+		path := filepath.Join(runDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(runDir, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	return inputDir
+}
+
+func newTestStore(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Store{Root: filepath.Join(dir, "runner"), ArchiveDir: filepath.Join(dir, "archive"), MaxArchives: 2}
+	return s, func() { os.RemoveAll(dir) }
+}
+
+func TestArchive(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	now := time.Now()
+	for i, ident := range []string{"1", "2", "3"} {
+		inputDir := writeRun(t, s.Root, ident, "successful", now.Add(time.Duration(i)*time.Minute))
+		if err := s.Archive(inputDir, ident); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		archive := filepath.Join(s.ArchiveDir, testOwner.path(), ident+archiveExt)
+		modTime := now.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(archive, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the oldest archive is rotated away when the third run is archived
+	archives, err := listArchives(filepath.Join(s.ArchiveDir, testOwner.path()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 {
+		t.Fatalf("expected 2 archives, got %d", len(archives))
+	}
+
+	// once the local artifacts are gone, runs are read from their archives
+	if err := os.RemoveAll(filepath.Join(s.Root, testOwner.path(), "artifacts", "3")); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := s.Runs(testOwner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != 3 || runs[0].Ident != "3" || runs[0].Local || !runs[0].Archived || runs[1].Ident != "2" ||
+		!runs[1].Local || !runs[1].Archived || runs[2].Ident != "1" || runs[2].Archived {
+		t.Fatalf("unexpected runs: %+v", runs)
+	}
+	if runs[0].Status != "successful" || runs[0].RC == nil || *runs[0].RC != 0 {
+		t.Fatalf("unexpected result of archived run: %+v", runs[0])
+	}
+
+	stdout, err := s.Stdout(testOwner, "3")
+	if err != nil || string(stdout) != "PLAY RECAP 3" {
+		t.Fatalf("unexpected stdout %q: %v", stdout, err)
+	}
+	events, err := s.JobEvents(testOwner, "3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, string(e))
+	}
+	if expected := `{"counter":1},{"counter":2},{"counter":10}`; strings.Join(got, ",") != expected {
+		t.Fatalf("expected events %s, got %s", expected, strings.Join(got, ","))
+	}
+
+	if _, err := s.Stdout(testOwner, "4"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a not exist error, got %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+	s.MaxAge = time.Hour
+
+	now := time.Now()
+	old := now.Add(-2 * time.Hour)
+	inputDir := writeRun(t, s.Root, "old", "failed", old)
+	if err := s.Archive(inputDir, "old"); err != nil {
+		t.Fatal(err)
+	}
+	oldArchive := filepath.Join(s.ArchiveDir, testOwner.path(), "old"+archiveExt)
+	if err := os.Chtimes(oldArchive, old, old); err != nil {
+		t.Fatal(err)
+	}
+	writeRun(t, s.Root, "running", "running", old)
+	writeRun(t, s.Root, "new", "successful", now)
+	if err := os.Symlink(filepath.Join(inputDir, "artifacts", "old"), filepath.Join(inputDir, "artifacts", latest)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Prune(now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for path, exists := range map[string]bool{
+		filepath.Join(inputDir, "artifacts", "old"):  false,
+		filepath.Join(inputDir, "artifacts", latest): false,
+		oldArchive: false,
+		filepath.Join(inputDir, "artifacts", "running"): true,
+		filepath.Join(inputDir, "artifacts", "new"):     true,
+	} {
+		if _, err := os.Lstat(path); (err == nil) != exists {
+			t.Errorf("expected %s to exist: %v, got error %v", path, exists, err)
+		}
+	}
+}
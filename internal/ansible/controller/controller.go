@@ -34,7 +34,9 @@ import (
 	"github.com/operator-framework/operator-sdk/internal/ansible/events"
 	"github.com/operator-framework/operator-sdk/internal/ansible/handler"
 	"github.com/operator-framework/operator-sdk/internal/ansible/predicate"
+	"github.com/operator-framework/operator-sdk/internal/ansible/proxy/kubeconfig"
 	"github.com/operator-framework/operator-sdk/internal/ansible/runner"
+	"github.com/operator-framework/operator-sdk/internal/ansible/watches"
 )
 
 var log = logf.Log.WithName("ansible-controller")
@@ -52,26 +54,33 @@ type Options struct {
 	WatchClusterScopedResources bool
 	MaxConcurrentReconciles     int
 	Selector                    metav1.LabelSelector
+	TaskSummary                 *watches.TaskSummary
+	SkipUnchangedRuns           bool
+	// Proxy describes how playbooks connect to the proxy.
+	Proxy kubeconfig.Proxy
 }
 
 // Add - Creates a new ansible operator controller and adds it to the manager
 func Add(mgr manager.Manager, options Options) *controller.Controller {
 	log.Info("Watching resource", "Options.Group", options.GVK.Group, "Options.Version",
 		options.GVK.Version, "Options.Kind", options.GVK.Kind)
-	if options.EventHandlers == nil {
-		options.EventHandlers = []events.EventHandler{}
-	}
-	eventHandlers := append(options.EventHandlers, events.NewLoggingEventHandler(options.LoggingLevel))
+	// Copy the handlers, since options.EventHandlers may be shared between controllers.
+	eventHandlers := make([]events.EventHandler, 0, len(options.EventHandlers)+1)
+	eventHandlers = append(eventHandlers, options.EventHandlers...)
+	eventHandlers = append(eventHandlers, events.NewLoggingEventHandler(options.LoggingLevel))
 
 	aor := &AnsibleOperatorReconciler{
-		Client:           mgr.GetClient(),
-		GVK:              options.GVK,
-		Runner:           options.Runner,
-		EventHandlers:    eventHandlers,
-		ReconcilePeriod:  options.ReconcilePeriod,
-		ManageStatus:     options.ManageStatus,
-		AnsibleDebugLogs: options.AnsibleDebugLogs,
-		APIReader:        mgr.GetAPIReader(),
+		Client:            mgr.GetClient(),
+		GVK:               options.GVK,
+		Runner:            options.Runner,
+		EventHandlers:     eventHandlers,
+		ReconcilePeriod:   options.ReconcilePeriod,
+		ManageStatus:      options.ManageStatus,
+		AnsibleDebugLogs:  options.AnsibleDebugLogs,
+		APIReader:         mgr.GetAPIReader(),
+		TaskSummary:       options.TaskSummary,
+		Proxy:             options.Proxy,
+		SkipUnchangedRuns: options.SkipUnchangedRuns,
 	}
 
 	scheme := mgr.GetScheme()
@@ -99,9 +108,12 @@ func Add(mgr manager.Manager, options Options) *controller.Controller {
 		os.Exit(1)
 	}
 
-	// Set up predicates.
+	// Set up predicates. Toggling a dry run or forcing a run must trigger a
+	// reconcile although it does not change the generation.
 	predicates := []ctrlpredicate.Predicate{
-		ctrlpredicate.Or(ctrlpredicate.GenerationChangedPredicate{}, libpredicate.NoGenerationPredicate{}),
+		ctrlpredicate.Or(ctrlpredicate.GenerationChangedPredicate{}, libpredicate.NoGenerationPredicate{},
+			predicate.NewAnnotationChangedPredicate(runner.DryRunAnnotation),
+			predicate.NewAnnotationChangedPredicate(runner.ForceRunAnnotation)),
 	}
 	filterPredicate, err := predicate.NewResourceFilterPredicate(options.Selector)
 	if err != nil {
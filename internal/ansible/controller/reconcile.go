@@ -42,6 +42,7 @@ import (
 	"github.com/operator-framework/operator-sdk/internal/ansible/proxy/kubeconfig"
 	"github.com/operator-framework/operator-sdk/internal/ansible/runner"
 	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+	"github.com/operator-framework/operator-sdk/internal/ansible/watches"
 )
 
 const (
@@ -61,6 +62,13 @@ type AnsibleOperatorReconciler struct {
 	ReconcilePeriod  time.Duration
 	ManageStatus     bool
 	AnsibleDebugLogs bool
+	// TaskSummary, if set, enables writing a summary of the tasks of each run to the status.
+	TaskSummary *watches.TaskSummary
+	// Proxy describes how playbooks connect to the proxy.
+	Proxy kubeconfig.Proxy
+	// SkipUnchangedRuns, if true, skips runs whose inputs and generation are
+	// those of the last successful run.
+	SkipUnchangedRuns bool
 }
 
 // Reconcile - handle the event.
@@ -83,6 +91,13 @@ func (r *AnsibleOperatorReconciler) Reconcile(ctx context.Context, request recon
 		"namespace", u.GetNamespace(),
 	)
 
+	// A dry run reports the changes ansible would make in the status, and
+	// leaves the conditions of the last real run as they are.
+	dryRun := runner.DryRun(u)
+	if dryRun {
+		logger.Info("Running in check mode")
+	}
+
 	reconcileResult := reconcile.Result{RequeueAfter: r.ReconcilePeriod}
 	if ds, ok := u.GetAnnotations()[ReconcilePeriodAnnotation]; ok {
 		duration, err := time.ParseDuration(ds)
@@ -100,21 +115,33 @@ func (r *AnsibleOperatorReconciler) Reconcile(ctx context.Context, request recon
 	}
 
 	deleted := u.GetDeletionTimestamp() != nil
-	finalizer, finalizerExists := r.Runner.GetFinalizer()
-	if !controllerutil.ContainsFinalizer(u, finalizer) {
-		if deleted {
-			// If the resource is being deleted we don't want to add the finalizer again
-			logger.Info("Resource is terminated, skipping reconciliation")
-			return reconcile.Result{}, nil
-		} else if finalizerExists {
-			logger.V(1).Info("Adding finalizer to resource", "Finalizer", finalizer)
-			controllerutil.AddFinalizer(u, finalizer)
-			err := r.Client.Update(ctx, u)
-			if err != nil {
-				logger.Error(err, "Unable to update cr with finalizer")
-				return reconcileResult, err
+	finalizers := r.Runner.GetFinalizers()
+	pending := pendingFinalizers(u, finalizers)
+	if deleted && len(pending) == 0 {
+		// If the resource is being deleted we don't want to add the finalizers again
+		logger.Info("Resource is terminated, skipping reconciliation")
+		return reconcile.Result{}, nil
+	}
+	if !deleted && len(pending) != len(finalizers) {
+		for _, finalizer := range finalizers {
+			if !controllerutil.ContainsFinalizer(u, finalizer) {
+				logger.V(1).Info("Adding finalizer to resource", "Finalizer", finalizer)
+				controllerutil.AddFinalizer(u, finalizer)
 			}
 		}
+		err := r.Client.Update(ctx, u)
+		if err != nil {
+			logger.Error(err, "Unable to update cr with finalizer")
+			return reconcileResult, err
+		}
+	}
+
+	// The finalizers run one per reconciliation, in the order of the watch.
+	var finalizer string
+	var progress string
+	if deleted {
+		finalizer = pending[0]
+		progress = fmt.Sprintf("%s (%d of %d)", finalizer, len(finalizers)-len(pending)+1, len(finalizers))
 	}
 
 	spec := u.Object["spec"]
@@ -128,13 +155,39 @@ func (r *AnsibleOperatorReconciler) Reconcile(ctx context.Context, request recon
 		u.Object["spec"] = map[string]interface{}{}
 	}
 
-	if r.ManageStatus {
+	// Finalizer runs and dry runs are never skipped, and do not count as the
+	// last successful run.
+	var inputHash string
+	generation := u.GetGeneration()
+	if r.SkipUnchangedRuns && !dryRun && !deleted {
+		inputHash, err = r.Runner.InputHash(u)
+		if err != nil {
+			logger.Error(err, "Unable to hash the inputs of the run")
+			return reconcileResult, err
+		}
+		last := getStatus(u).LastSuccessfulRun
+		if last != nil && last.InputHash == inputHash && last.ObservedGeneration == generation {
+			logger.V(1).Info("Inputs are unchanged since the last successful run, skipping", "lastJob", last.Job)
+			metrics.ReconcileSkipped(r.GVK.String())
+			return reconcileResult, nil
+		}
+	}
+
+	if r.ManageStatus && !dryRun {
 		errmark := r.markRunning(ctx, request.NamespacedName, u)
 		if errmark != nil {
 			logger.Error(errmark, "Unable to update the status to mark cr as running")
 			return reconcileResult, errmark
 		}
 	}
+	if r.ManageStatus && deleted {
+		errmark := r.markFinalizing(ctx, request.NamespacedName, u, ansiblestatus.FinalizingReason,
+			"Running finalizer "+progress)
+		if errmark != nil {
+			logger.Error(errmark, "Unable to update the status to mark cr as finalizing")
+			return reconcileResult, errmark
+		}
+	}
 
 	ownerRef := metav1.OwnerReference{
 		APIVersion: u.GetAPIVersion(),
@@ -143,7 +196,13 @@ func (r *AnsibleOperatorReconciler) Reconcile(ctx context.Context, request recon
 		UID:        u.GetUID(),
 	}
 
-	kc, err := kubeconfig.Create(ownerRef, "http://localhost:8888", u.GetNamespace())
+	nsOwnerRef := kubeconfig.NamespacedOwnerReference{
+		OwnerReference: ownerRef,
+		Namespace:      u.GetNamespace(),
+		Job:            ident,
+		DryRun:         dryRun,
+	}
+	kc, err := r.Proxy.Create(nsOwnerRef, r.Runner.RunTimeout(u))
 	if err != nil {
 		errmark := r.markError(ctx, request.NamespacedName, u, "Unable to run reconciliation")
 		if errmark != nil {
@@ -153,11 +212,13 @@ func (r *AnsibleOperatorReconciler) Reconcile(ctx context.Context, request recon
 		return reconcileResult, err
 	}
 	defer func() {
+		// The run has finished, so its kubeconfig must not be usable anymore.
+		r.Proxy.Revoke(nsOwnerRef)
 		if err := os.Remove(kc.Name()); err != nil {
 			logger.Error(err, "Failed to remove generated kubeconfig file")
 		}
 	}()
-	result, err := r.Runner.Run(ident, u, kc.Name())
+	result, err := r.Runner.Run(ctx, ident, u, kc.Name())
 	if err != nil {
 		errmark := r.markError(ctx, request.NamespacedName, u, "Unable to run reconciliation")
 		if errmark != nil {
@@ -167,12 +228,30 @@ func (r *AnsibleOperatorReconciler) Reconcile(ctx context.Context, request recon
 		return reconcileResult, err
 	}
 
+	var taskRecorder *ansiblestatus.TaskSummaryRecorder
+	var dryRunRecorder *ansiblestatus.DryRunRecorder
+	if dryRun {
+		dryRunRecorder = ansiblestatus.NewDryRunRecorder(ident, u.GetGeneration())
+	} else if r.ManageStatus && r.TaskSummary != nil {
+		taskRecorder = ansiblestatus.NewTaskSummaryRecorder(r.TaskSummary.MaxTasks, r.TaskSummary.MaxFailedTasks,
+			getStatus(u).TaskSummary)
+	}
+
 	// iterate events from ansible, looking for the final one
 	statusEvent := eventapi.StatusJobEvent{}
 	failureMessages := eventapi.FailureMessages{}
+	// Handlers may queue the CR with the event, so they are given a copy that
+	// is not modified when the status of u is updated.
+	eventCR := u.DeepCopy()
 	for event := range result.Events() {
 		for _, eHandler := range r.EventHandlers {
-			go eHandler.Handle(ident, u, event)
+			eHandler.Handle(ident, eventCR, event)
+		}
+		if taskRecorder != nil {
+			taskRecorder.Record(event)
+		}
+		if dryRunRecorder != nil {
+			dryRunRecorder.Record(event)
 		}
 		if event.Event == eventapi.EventPlaybookOnStats {
 			// convert to StatusJobEvent; would love a better way to do this
@@ -189,7 +268,7 @@ func (r *AnsibleOperatorReconciler) Reconcile(ctx context.Context, request recon
 		}
 
 		if module, found := event.EventData["task_action"]; found {
-			if module == "operator_sdk.util.requeue_after" && event.Event != eventapi.EventRunnerOnFailed {
+			if module == "operator_sdk.util.requeue_after" && event.Event != eventapi.EventRunnerOnFailed && !dryRun {
 				if data, exists := event.EventData["res"]; exists {
 					if fields, check := data.(map[string]interface{}); check {
 						requeueDuration, err := time.ParseDuration(fields["period"].(string))
@@ -215,6 +294,16 @@ func (r *AnsibleOperatorReconciler) Reconcile(ctx context.Context, request recon
 	// To print the full ansible result
 	r.printAnsibleResult(result, u)
 
+	if result.TimedOut() {
+		timeoutErr := errors.New("ansible-runner exceeded the run timeout")
+		errmark := r.markTimeout(ctx, request.NamespacedName, u)
+		if errmark != nil {
+			logger.Error(errmark, "Unable to mark timeout to run reconciliation")
+		}
+		logger.Error(timeoutErr, "Ansible-runner was stopped before it completed")
+		return reconcileResult, timeoutErr
+	}
+
 	if statusEvent.Event == "" {
 		eventErr := errors.New("did not receive playbook_on_stats event")
 		stdout, err := result.Stdout()
@@ -240,22 +329,50 @@ func (r *AnsibleOperatorReconciler) Reconcile(ctx context.Context, request recon
 		return reconcile.Result{}, err
 	}
 
+	if dryRun {
+		dr := dryRunRecorder.DryRun()
+		logger.Info("Dry run completed", "changes", dr.TotalChanges, "failures", len(dr.Failures))
+		errmark := r.markDryRun(ctx, request.NamespacedName, u, dr)
+		if errmark != nil {
+			logger.Error(errmark, "Failed to record dry run in status")
+		}
+		return reconcileResult, errmark
+	}
+
 	// We only want to update the CustomResource once, so we'll track changes
 	// and do it at the end
 	runSuccessful := len(failureMessages) == 0
 
-	// The finalizer has run successfully, time to remove it
-	deleted = u.GetDeletionTimestamp() != nil
-	if deleted && finalizerExists && runSuccessful {
+	// The finalizer has run successfully, time to remove it, and to run the
+	// next one if there is any
+	if deleted && runSuccessful {
 		controllerutil.RemoveFinalizer(u, finalizer)
 		err := r.Client.Update(ctx, u)
 		if err != nil {
 			logger.Error(err, "Failed to remove finalizer")
 			return reconcileResult, err
 		}
+		if len(pendingFinalizers(u, finalizers)) > 0 {
+			reconcileResult = reconcile.Result{Requeue: true}
+		}
+	}
+	if r.ManageStatus && deleted && !runSuccessful {
+		errmark := r.markFinalizing(ctx, request.NamespacedName, u, ansiblestatus.FinalizerFailedReason,
+			"Failed to run finalizer "+progress)
+		if errmark != nil {
+			logger.Error(errmark, "Failed to mark status finalizing")
+		}
 	}
 	if r.ManageStatus {
-		errmark := r.markDone(ctx, request.NamespacedName, u, statusEvent, failureMessages)
+		var taskSummary *ansiblestatus.TaskSummary
+		if taskRecorder != nil {
+			taskSummary = taskRecorder.Summary()
+		}
+		var lastRun *ansiblestatus.LastSuccessfulRun
+		if inputHash != "" {
+			lastRun = &ansiblestatus.LastSuccessfulRun{Job: ident, ObservedGeneration: generation, InputHash: inputHash}
+		}
+		errmark := r.markDone(ctx, request.NamespacedName, u, statusEvent, failureMessages, taskSummary, lastRun)
 		if errmark != nil {
 			logger.Error(errmark, "Failed to mark status done")
 		}
@@ -273,6 +390,18 @@ func (r *AnsibleOperatorReconciler) Reconcile(ctx context.Context, request recon
 	return reconcileResult, nil
 }
 
+// pendingFinalizers returns the finalizers that are still present on u, in
+// the order of finalizers.
+func pendingFinalizers(u *unstructured.Unstructured, finalizers []string) []string {
+	var pending []string
+	for _, finalizer := range finalizers {
+		if controllerutil.ContainsFinalizer(u, finalizer) {
+			pending = append(pending, finalizer)
+		}
+	}
+	return pending
+}
+
 func printEventStats(statusEvent eventapi.StatusJobEvent, u *unstructured.Unstructured) {
 	if len(statusEvent.StdOut) > 0 {
 		str := fmt.Sprintf("Ansible Task Status Event StdOut (%s, %s/%s)", u.GroupVersionKind(), u.GetName(), u.GetNamespace())
@@ -296,6 +425,8 @@ func (r *AnsibleOperatorReconciler) markRunning(ctx context.Context, nn types.Na
 		return err
 	}
 	crStatus := getStatus(u)
+	// Until this run succeeds, the next one must not be skipped.
+	crStatus.LastSuccessfulRun = nil
 
 	// If there is no current status add that we are working on this resource.
 	errCond := ansiblestatus.GetCondition(crStatus, ansiblestatus.FailureConditionType)
@@ -319,15 +450,66 @@ func (r *AnsibleOperatorReconciler) markRunning(ctx context.Context, nn types.Na
 	return r.Client.Status().Update(ctx, u)
 }
 
+// markFinalizing - used to report the progress of the finalizers of a deleted resource.
+func (r *AnsibleOperatorReconciler) markFinalizing(ctx context.Context, nn types.NamespacedName,
+	u *unstructured.Unstructured, reason, message string) error {
+
+	logger := logf.Log.WithName("markFinalizing")
+	// Get the latest resource to prevent updating a stale status.
+	if err := r.APIReader.Get(ctx, nn, u); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("Resource not found, assuming it was deleted")
+			return nil
+		}
+		return err
+	}
+	crStatus := getStatus(u)
+
+	c := ansiblestatus.NewCondition(
+		ansiblestatus.FinalizingConditionType,
+		v1.ConditionTrue,
+		nil,
+		reason,
+		message,
+	)
+	// SetCondition keeps a condition whose status and reason are unchanged,
+	// which would hide the progress, so the condition is replaced instead.
+	if cur := ansiblestatus.GetCondition(crStatus, ansiblestatus.FinalizingConditionType); cur != nil {
+		c.LastTransitionTime = cur.LastTransitionTime
+		ansiblestatus.RemoveCondition(&crStatus, ansiblestatus.FinalizingConditionType)
+	}
+	ansiblestatus.SetCondition(&crStatus, *c)
+	u.Object["status"] = crStatus.GetJSONMap()
+
+	return r.Client.Status().Update(ctx, u)
+}
+
 // markError - used to alert the user to the issues during the validation of a reconcile run.
 // i.e Annotations that could be incorrect
 func (r *AnsibleOperatorReconciler) markError(ctx context.Context, nn types.NamespacedName, u *unstructured.Unstructured,
 	failureMessage string) error {
 
-	logger := logf.Log.WithName("markError")
 	// Immediately update metrics with failed reconciliation, since Get()
 	// may fail.
 	metrics.ReconcileFailed(r.GVK.String())
+	return r.markFailure(ctx, nn, u, ansiblestatus.FailedReason, failureMessage)
+}
+
+// markTimeout - used to alert the user that ansible-runner was killed because it
+// exceeded its run timeout.
+func (r *AnsibleOperatorReconciler) markTimeout(ctx context.Context, nn types.NamespacedName,
+	u *unstructured.Unstructured) error {
+
+	metrics.ReconcileFailed(r.GVK.String())
+	metrics.ReconcileTimedOut(r.GVK.String())
+	return r.markFailure(ctx, nn, u, ansiblestatus.TimeoutReason, ansiblestatus.TimeoutMessage)
+}
+
+// markFailure - sets the failure condition with the given reason and message.
+func (r *AnsibleOperatorReconciler) markFailure(ctx context.Context, nn types.NamespacedName,
+	u *unstructured.Unstructured, reason, failureMessage string) error {
+
+	logger := logf.Log.WithName("markFailure")
 	// Get the latest resource to prevent updating a stale status.
 	if err := r.APIReader.Get(ctx, nn, u); err != nil {
 		if apierrors.IsNotFound(err) {
@@ -348,7 +530,7 @@ func (r *AnsibleOperatorReconciler) markError(ctx context.Context, nn types.Name
 		ansiblestatus.FailureConditionType,
 		v1.ConditionTrue,
 		nil,
-		ansiblestatus.FailedReason,
+		reason,
 		failureMessage,
 	)
 	ansiblestatus.SetCondition(&crStatus, *c)
@@ -359,7 +541,8 @@ func (r *AnsibleOperatorReconciler) markError(ctx context.Context, nn types.Name
 }
 
 func (r *AnsibleOperatorReconciler) markDone(ctx context.Context, nn types.NamespacedName, u *unstructured.Unstructured,
-	statusEvent eventapi.StatusJobEvent, failureMessages eventapi.FailureMessages) error {
+	statusEvent eventapi.StatusJobEvent, failureMessages eventapi.FailureMessages,
+	taskSummary *ansiblestatus.TaskSummary, lastRun *ansiblestatus.LastSuccessfulRun) error {
 
 	logger := logf.Log.WithName("markDone")
 	// Get the latest resource to prevent updating a stale status.
@@ -402,7 +585,33 @@ func (r *AnsibleOperatorReconciler) markDone(ctx context.Context, nn types.Names
 		// Remove the failure condition if set, because this completed successfully.
 		ansiblestatus.RemoveCondition(&crStatus, ansiblestatus.FailureConditionType)
 		ansiblestatus.SetCondition(&crStatus, *c)
+		crStatus.LastSuccessfulRun = lastRun
 	}
+	if taskSummary != nil {
+		crStatus.TaskSummary = taskSummary
+	}
+	// This needs the status subresource to be enabled by default.
+	u.Object["status"] = crStatus.GetJSONMap()
+
+	return r.Client.Status().Update(ctx, u)
+}
+
+// markDryRun - records the changes predicted by a run in check mode in the
+// status, leaving the conditions as they are.
+func (r *AnsibleOperatorReconciler) markDryRun(ctx context.Context, nn types.NamespacedName, u *unstructured.Unstructured,
+	dryRun *ansiblestatus.DryRun) error {
+
+	logger := logf.Log.WithName("markDryRun")
+	// Get the latest resource to prevent updating a stale status.
+	if err := r.APIReader.Get(ctx, nn, u); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("Resource not found, assuming it was deleted")
+			return nil
+		}
+		return err
+	}
+	crStatus := getStatus(u)
+	crStatus.DryRun = dryRun
 	// This needs the status subresource to be enabled by default.
 	u.Object["status"] = crStatus.GetJSONMap()
 
@@ -16,6 +16,7 @@ package controller_test
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -54,6 +55,7 @@ func TestReconcile(t *testing.T) {
 		Request         reconcile.Request
 		ShouldError     bool
 		ManageStatus    bool
+		SkipUnchanged   bool
 	}{
 		{
 			Name:            "cr not found",
@@ -246,6 +248,64 @@ func TestReconcile(t *testing.T) {
 			},
 			ShouldError: true,
 		},
+		{
+			Name:            "Run timeout with manageStatus == true",
+			GVK:             gvk,
+			ReconcilePeriod: 5 * time.Second,
+			ManageStatus:    true,
+			Runner: &fake.Runner{
+				JobEvents: []eventapi.JobEvent{},
+				TimedOut:  true,
+			},
+			Client: fakeclient.NewClientBuilder().WithObjects(&unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "reconcile",
+						"namespace": "default",
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+					"spec":       map[string]interface{}{},
+				},
+			}).Build(),
+			Result: reconcile.Result{
+				RequeueAfter: 5 * time.Second,
+			},
+			Request: reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      "reconcile",
+					Namespace: "default",
+				},
+			},
+			ExpectedObject: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "reconcile",
+						"namespace": "default",
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+					"spec":       map[string]interface{}{},
+					"status": map[string]interface{}{
+						"conditions": []interface{}{
+							map[string]interface{}{
+								"status":  "False",
+								"type":    "Running",
+								"message": "Running reconciliation",
+								"reason":  "Running",
+							},
+							map[string]interface{}{
+								"status":  "True",
+								"type":    "Failure",
+								"message": "Ansible-runner exceeded the run timeout and was stopped",
+								"reason":  "Timeout",
+							},
+						},
+					},
+				},
+			},
+			ShouldError: true,
+		},
 		{
 			Name:            "Finalizer successful reconcile",
 			GVK:             gvk,
@@ -433,11 +493,186 @@ func TestReconcile(t *testing.T) {
 								"message": "Awaiting next reconciliation",
 								"reason":  "Successful",
 							},
+							map[string]interface{}{
+								"status":  "True",
+								"type":    "Finalizing",
+								"message": "Running finalizer testing.io/finalizer (1 of 1)",
+								"reason":  "Finalizing",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:            "Finalizer phase successful deletion reconcile",
+			GVK:             gvk,
+			ReconcilePeriod: 5 * time.Second,
+			ManageStatus:    true,
+			Runner: &fake.Runner{
+				JobEvents: []eventapi.JobEvent{
+					eventapi.JobEvent{
+						Event:   eventapi.EventPlaybookOnStats,
+						Created: eventapi.EventTime{Time: eventTime},
+					},
+				},
+				Finalizers: []string{"testing.io/backup", "testing.io/cleanup"},
+			},
+			Client: fakeclient.NewClientBuilder().WithObjects(&unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "reconcile",
+						"namespace": "default",
+						"finalizers": []interface{}{
+							"testing.io/cleanup",
+							"testing.io/backup",
+						},
+						"deletionTimestamp": eventTime.Format(time.RFC3339),
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+					"spec":       map[string]interface{}{},
+				},
+			}).Build(),
+			Result: reconcile.Result{
+				Requeue: true,
+			},
+			Request: reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      "reconcile",
+					Namespace: "default",
+				},
+			},
+			ExpectedObject: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "reconcile",
+						"namespace": "default",
+						"finalizers": []interface{}{
+							"testing.io/cleanup",
+						},
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+					"spec":       map[string]interface{}{},
+					"status": map[string]interface{}{
+						"conditions": []interface{}{
+							map[string]interface{}{
+								"status": "True",
+								"type":   "Running",
+								"ansibleResult": map[string]interface{}{
+									"changed":    int64(0),
+									"failures":   int64(0),
+									"ok":         int64(0),
+									"skipped":    int64(0),
+									"completion": eventTime.Format("2006-01-02T15:04:05.99999999"),
+								},
+								"message": "Awaiting next reconciliation",
+								"reason":  "Successful",
+							},
+							map[string]interface{}{
+								"status":  "True",
+								"type":    "Finalizing",
+								"message": "Running finalizer testing.io/backup (1 of 2)",
+								"reason":  "Finalizing",
+							},
 						},
 					},
 				},
 			},
 		},
+		{
+			Name:            "Finalizer phase failed deletion reconcile",
+			GVK:             gvk,
+			ReconcilePeriod: 5 * time.Second,
+			ManageStatus:    true,
+			Runner: &fake.Runner{
+				JobEvents: []eventapi.JobEvent{
+					eventapi.JobEvent{
+						Event:   eventapi.EventRunnerOnFailed,
+						Created: eventapi.EventTime{Time: eventTime},
+						EventData: map[string]interface{}{
+							"res": map[string]interface{}{
+								"msg": "new failure message",
+							},
+						},
+					},
+					eventapi.JobEvent{
+						Event:   eventapi.EventPlaybookOnStats,
+						Created: eventapi.EventTime{Time: eventTime},
+					},
+				},
+				Finalizers: []string{"testing.io/backup", "testing.io/cleanup"},
+			},
+			Client: fakeclient.NewClientBuilder().WithObjects(&unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "reconcile",
+						"namespace": "default",
+						"finalizers": []interface{}{
+							"testing.io/cleanup",
+						},
+						"deletionTimestamp": eventTime.Format(time.RFC3339),
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+					"spec":       map[string]interface{}{},
+				},
+			}).Build(),
+			Result: reconcile.Result{
+				RequeueAfter: 5 * time.Second,
+			},
+			Request: reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      "reconcile",
+					Namespace: "default",
+				},
+			},
+			ExpectedObject: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "reconcile",
+						"namespace": "default",
+						"finalizers": []interface{}{
+							"testing.io/cleanup",
+						},
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+					"spec":       map[string]interface{}{},
+					"status": map[string]interface{}{
+						"conditions": []interface{}{
+							map[string]interface{}{
+								"status":  "False",
+								"type":    "Running",
+								"message": "Running reconciliation",
+								"reason":  "Running",
+							},
+							map[string]interface{}{
+								"status": "True",
+								"type":   "Failure",
+								"ansibleResult": map[string]interface{}{
+									"changed":    int64(0),
+									"failures":   int64(0),
+									"ok":         int64(0),
+									"skipped":    int64(0),
+									"completion": eventTime.Format("2006-01-02T15:04:05.99999999"),
+								},
+								"message": "new failure message",
+								"reason":  "Failed",
+							},
+							map[string]interface{}{
+								"status":  "True",
+								"type":    "Finalizing",
+								"message": "Failed to run finalizer testing.io/cleanup (2 of 2)",
+								"reason":  "FinalizerFailed",
+							},
+						},
+					},
+				},
+			},
+			ShouldError: true,
+		},
 		{
 			Name:            "No status event",
 			GVK:             gvk,
@@ -487,6 +722,211 @@ func TestReconcile(t *testing.T) {
 			},
 			ShouldError: true,
 		},
+		{
+			Name:            "dry run",
+			GVK:             gvk,
+			ReconcilePeriod: 5 * time.Second,
+			ManageStatus:    true,
+			Runner: &fake.Runner{
+				JobEvents: []eventapi.JobEvent{
+					eventapi.JobEvent{
+						Event: eventapi.EventRunnerOnOk,
+						EventData: map[string]interface{}{
+							"task": "Create configmap",
+							"res": map[string]interface{}{
+								"changed": true,
+								"diff":    map[string]interface{}{"after": "data: foo"},
+							},
+						},
+					},
+					eventapi.JobEvent{
+						Event:     eventapi.EventRunnerOnOk,
+						EventData: map[string]interface{}{"task": "Gather facts"},
+					},
+					eventapi.JobEvent{
+						Event:   eventapi.EventPlaybookOnStats,
+						Created: eventapi.EventTime{Time: eventTime},
+					},
+				},
+			},
+			Client: fakeclient.NewClientBuilder().WithObjects(&unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "reconcile",
+						"namespace": "default",
+						"annotations": map[string]interface{}{
+							runner.DryRunAnnotation: "true",
+						},
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+				},
+			}).Build(),
+			Result: reconcile.Result{
+				RequeueAfter: 5 * time.Second,
+			},
+			Request: reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      "reconcile",
+					Namespace: "default",
+				},
+			},
+			ExpectedObject: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "reconcile",
+						"namespace": "default",
+						"annotations": map[string]interface{}{
+							runner.DryRunAnnotation: "true",
+						},
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+					"spec":       map[string]interface{}{},
+					"status": map[string]interface{}{
+						"dryRun": map[string]interface{}{
+							"totalChanges": int64(1),
+							"changes": []interface{}{
+								map[string]interface{}{
+									"name": "Create configmap",
+									"diff": map[string]interface{}{"after": "data: foo"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:            "skip unchanged run",
+			GVK:             gvk,
+			ReconcilePeriod: 5 * time.Second,
+			ManageStatus:    true,
+			SkipUnchanged:   true,
+			Runner: &fake.Runner{
+				Hash:  "unchanged",
+				Error: errors.New("run must be skipped"),
+			},
+			Client: fakeclient.NewClientBuilder().WithObjects(&unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":       "reconcile",
+						"namespace":  "default",
+						"generation": int64(2),
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+					"status": map[string]interface{}{
+						"lastSuccessfulRun": map[string]interface{}{
+							"job":                "1",
+							"observedGeneration": int64(2),
+							"inputHash":          "unchanged",
+						},
+					},
+				},
+			}).Build(),
+			Result: reconcile.Result{
+				RequeueAfter: 5 * time.Second,
+			},
+			Request: reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      "reconcile",
+					Namespace: "default",
+				},
+			},
+			ExpectedObject: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "reconcile",
+						"namespace": "default",
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+					"status": map[string]interface{}{
+						"lastSuccessfulRun": map[string]interface{}{
+							"job":                "1",
+							"observedGeneration": int64(2),
+							"inputHash":          "unchanged",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:            "run changed inputs",
+			GVK:             gvk,
+			ReconcilePeriod: 5 * time.Second,
+			ManageStatus:    true,
+			SkipUnchanged:   true,
+			Runner: &fake.Runner{
+				Hash: "changed",
+				JobEvents: []eventapi.JobEvent{
+					eventapi.JobEvent{
+						Event:   eventapi.EventPlaybookOnStats,
+						Created: eventapi.EventTime{Time: eventTime},
+					},
+				},
+			},
+			Client: fakeclient.NewClientBuilder().WithObjects(&unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":       "reconcile",
+						"namespace":  "default",
+						"generation": int64(2),
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+					"status": map[string]interface{}{
+						"lastSuccessfulRun": map[string]interface{}{
+							"job":                "1",
+							"observedGeneration": int64(2),
+							"inputHash":          "unchanged",
+						},
+					},
+				},
+			}).Build(),
+			Result: reconcile.Result{
+				RequeueAfter: 5 * time.Second,
+			},
+			Request: reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      "reconcile",
+					Namespace: "default",
+				},
+			},
+			ExpectedObject: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "reconcile",
+						"namespace": "default",
+					},
+					"apiVersion": "operator-sdk/v1beta1",
+					"kind":       "Testing",
+					"spec":       map[string]interface{}{},
+					"status": map[string]interface{}{
+						"conditions": []interface{}{
+							map[string]interface{}{
+								"status": "True",
+								"type":   "Running",
+								"ansibleResult": map[string]interface{}{
+									"changed":    int64(0),
+									"failures":   int64(0),
+									"ok":         int64(0),
+									"skipped":    int64(0),
+									"completion": eventTime.Format("2006-01-02T15:04:05.99999999"),
+								},
+								"message": "Awaiting next reconciliation",
+								"reason":  "Successful",
+							},
+						},
+						"lastSuccessfulRun": map[string]interface{}{
+							"observedGeneration": int64(2),
+							"inputHash":          "changed",
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:            "no manage status",
 			GVK:             gvk,
@@ -537,13 +977,14 @@ func TestReconcile(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
 			var aor reconcile.Reconciler = &controller.AnsibleOperatorReconciler{
-				GVK:             tc.GVK,
-				Runner:          tc.Runner,
-				Client:          tc.Client,
-				APIReader:       tc.Client,
-				EventHandlers:   tc.EventHandlers,
-				ReconcilePeriod: tc.ReconcilePeriod,
-				ManageStatus:    tc.ManageStatus,
+				GVK:               tc.GVK,
+				Runner:            tc.Runner,
+				Client:            tc.Client,
+				APIReader:         tc.Client,
+				EventHandlers:     tc.EventHandlers,
+				ReconcilePeriod:   tc.ReconcilePeriod,
+				ManageStatus:      tc.ManageStatus,
+				SkipUnchangedRuns: tc.SkipUnchanged,
 			}
 			result, err := aor.Reconcile(context.TODO(), tc.Request)
 			if err != nil && !tc.ShouldError {
@@ -579,6 +1020,22 @@ func TestReconcile(t *testing.T) {
 					t.Fatalf("Status conditions not the same\nexpected: %v\nactual: %v", expectedStatus,
 						actualStatus)
 				}
+				if expectedStatus.DryRun != nil {
+					actual := actualStatus.DryRun
+					if actual == nil || actual.Job == "" ||
+						!reflect.DeepEqual(expectedStatus.DryRun.Changes, actual.Changes) ||
+						expectedStatus.DryRun.TotalChanges != actual.TotalChanges ||
+						!reflect.DeepEqual(expectedStatus.DryRun.Failures, actual.Failures) {
+						t.Fatalf("Dry run did not match\nexpected: %+v\nactual: %+v", expectedStatus.DryRun, actual)
+					}
+				}
+				if expected := expectedStatus.LastSuccessfulRun; expected != nil {
+					actual := actualStatus.LastSuccessfulRun
+					if actual == nil || actual.Job == "" || expected.InputHash != actual.InputHash ||
+						expected.ObservedGeneration != actual.ObservedGeneration {
+						t.Fatalf("Last successful run did not match\nexpected: %+v\nactual: %+v", expected, actual)
+					}
+				}
 				for _, c := range expectedStatus.Conditions {
 					actualCond := ansiblestatus.GetCondition(actualStatus, c.Type)
 					if c.Reason != actualCond.Reason || c.Message != actualCond.Message || c.Status !=
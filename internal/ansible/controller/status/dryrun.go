@@ -0,0 +1,208 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+// maxPredictedChanges is the maximum number of changes kept in a DryRun, so
+// that large diffs cannot grow the status without bound.
+const maxPredictedChanges = 50
+
+// maxDryRunSize is the maximum size in bytes of the changes and failures kept
+// in a DryRun, once serialized.
+const maxDryRunSize = 16 * 1024
+
+// ignoredFields are the paths of fields that change on every update of a
+// resource, so are not reported as changed.
+var ignoredFields = map[string]bool{
+	"metadata.generation":      true,
+	"metadata.managedFields":   true,
+	"metadata.resourceVersion": true,
+}
+
+// PredictedChange - a change that a task reported it would make in check mode.
+// Only the paths of the changed fields are kept, not their values, which may
+// hold the data of Secrets.
+type PredictedChange struct {
+	Name string `json:"name"`
+	Role string `json:"role,omitempty"`
+	// Kind and ResourceName identify the resource the task would change, if
+	// the task reported one.
+	Kind         string `json:"kind,omitempty"`
+	ResourceName string `json:"resourceName,omitempty"`
+	// Fields are the paths of the fields of the resource that would change,
+	// if the task reported the state of the resource before and after.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// DryRun - result of the last run of ansible in check mode.
+type DryRun struct {
+	Job string `json:"job"`
+	// ObservedGeneration is the generation of the CR the dry run was for.
+	ObservedGeneration int64              `json:"observedGeneration"`
+	Time               eventapi.EventTime `json:"time"`
+	// TotalChanges is the number of changes predicted, which may be larger
+	// than the number of Changes kept.
+	TotalChanges int               `json:"totalChanges"`
+	Changes      []PredictedChange `json:"changes,omitempty"`
+	// Failures holds the messages of the tasks that failed in check mode.
+	Failures []string `json:"failures,omitempty"`
+	// Truncated is true if changes or failures were not kept, because there
+	// were too many of them or they were too large.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// createDryRunFromMap - creates a DryRun from the status map of a CR.
+func createDryRunFromMap(dm map[string]interface{}) *DryRun {
+	b, err := json.Marshal(dm)
+	if err != nil {
+		log.Error(err, "Unable to marshal dry run")
+		return nil
+	}
+	dr := &DryRun{}
+	if err := json.Unmarshal(b, dr); err != nil {
+		log.Info("Unable to parse dry run, discarding it", "error", err.Error())
+		return nil
+	}
+	return dr
+}
+
+// DryRunRecorder - builds a DryRun from the job events of a run in check mode.
+type DryRunRecorder struct {
+	dryRun DryRun
+	// size is the serialized size of the changes and failures kept.
+	size int
+}
+
+// NewDryRunRecorder - creates a recorder for the dry run of job for a CR with
+// the given generation.
+func NewDryRunRecorder(job string, generation int64) *DryRunRecorder {
+	return &DryRunRecorder{dryRun: DryRun{Job: job, ObservedGeneration: generation}}
+}
+
+// Record - adds the changed and failed tasks of a job event to the dry run.
+func (r *DryRunRecorder) Record(je eventapi.JobEvent) {
+	switch je.Event {
+	case eventapi.EventRunnerOnOk:
+		if !je.Changed() {
+			return
+		}
+		r.dryRun.TotalChanges++
+		name, _ := je.EventData["task"].(string)
+		role, _ := je.EventData["role"].(string)
+		change := PredictedChange{Name: name, Role: role}
+		if res, ok := je.EventData["res"].(map[string]interface{}); ok {
+			change.Kind, change.ResourceName, change.Fields = changedResource(res)
+		}
+		if len(r.dryRun.Changes) < maxPredictedChanges && r.fits(change) {
+			r.dryRun.Changes = append(r.dryRun.Changes, change)
+		} else {
+			r.dryRun.Truncated = true
+		}
+	case eventapi.EventRunnerOnFailed:
+		if je.IgnoreError() || je.Rescued() {
+			return
+		}
+		if msg := je.GetFailedPlaybookMessage(); r.fits(msg) {
+			r.dryRun.Failures = append(r.dryRun.Failures, msg)
+		} else {
+			r.dryRun.Truncated = true
+		}
+	case eventapi.EventPlaybookOnStats:
+		r.dryRun.Time = je.Created
+	}
+}
+
+// fits adds the serialized size of v to the size of the dry run, and returns
+// whether it is within maxDryRunSize.
+func (r *DryRunRecorder) fits(v interface{}) bool {
+	b, err := json.Marshal(v)
+	if err != nil || r.size+len(b) > maxDryRunSize {
+		return false
+	}
+	r.size += len(b)
+	return true
+}
+
+// DryRun - returns the dry run of all events recorded so far.
+func (r *DryRunRecorder) DryRun() *DryRun {
+	dr := r.dryRun
+	return &dr
+}
+
+// changedResource returns the kind and name of the resource a task result
+// is for, and the paths of the fields that differ between the states of the
+// resource before and after in its diff, as reported by the k8s module.
+func changedResource(res map[string]interface{}) (kind, name string, fields []string) {
+	diff, _ := res["diff"].(map[string]interface{})
+	before, _ := diff["before"].(map[string]interface{})
+	after, _ := diff["after"].(map[string]interface{})
+	for _, obj := range []interface{}{res["result"], after, before} {
+		u, ok := obj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		kind, _ = u["kind"].(string)
+		if metadata, ok := u["metadata"].(map[string]interface{}); ok {
+			name, _ = metadata["name"].(string)
+		}
+		if kind != "" || name != "" {
+			break
+		}
+	}
+	if before != nil && after != nil {
+		collectChangedFields(before, after, "", &fields)
+		sort.Strings(fields)
+	}
+	return kind, name, fields
+}
+
+// collectChangedFields appends the paths of the fields that differ between
+// before and after to fields. Fields holding objects in both are compared
+// field by field, and all other fields as a whole.
+func collectChangedFields(before, after map[string]interface{}, path string, fields *[]string) {
+	visit := func(k string) {
+		fieldPath := k
+		if path != "" {
+			fieldPath = path + "." + k
+		}
+		if ignoredFields[fieldPath] {
+			return
+		}
+		b, bOK := before[k].(map[string]interface{})
+		a, aOK := after[k].(map[string]interface{})
+		if bOK && aOK {
+			collectChangedFields(b, a, fieldPath, fields)
+			return
+		}
+		if !reflect.DeepEqual(before[k], after[k]) {
+			*fields = append(*fields, fieldPath)
+		}
+	}
+	for k := range before {
+		visit(k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			visit(k)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+func TestDryRunRecorderSize(t *testing.T) {
+	r := NewDryRunRecorder("42", 1)
+	msg := strings.Repeat("x", maxDryRunSize/4)
+	for i := 0; i < 5; i++ {
+		r.Record(taskEvent(eventapi.EventRunnerOnFailed, "fail", time.Time{}, map[string]interface{}{
+			"res": map[string]interface{}{"msg": msg},
+		}))
+	}
+	r.Record(taskEvent(eventapi.EventRunnerOnOk, "change", time.Time{}, map[string]interface{}{
+		"res": map[string]interface{}{"changed": true},
+	}))
+
+	dr := r.DryRun()
+	if len(dr.Failures) != 3 || len(dr.Changes) != 1 || !dr.Truncated {
+		t.Errorf("Expected 3 failures and 1 change to be kept, got %d and %d", len(dr.Failures), len(dr.Changes))
+	}
+	b, err := json.Marshal(dr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) > maxDryRunSize+1024 {
+		t.Errorf("Expected the dry run to be at most about %d bytes, got %d", maxDryRunSize, len(b))
+	}
+}
+
+func TestDryRunRecorder(t *testing.T) {
+	end := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	diff := map[string]interface{}{
+		"before": map[string]interface{}{
+			"kind":     "Secret",
+			"metadata": map[string]interface{}{"name": "creds", "resourceVersion": "1"},
+			"data":     map[string]interface{}{"password": "b2xk", "user": "YWRtaW4="},
+		},
+		"after": map[string]interface{}{
+			"kind":     "Secret",
+			"metadata": map[string]interface{}{"name": "creds", "resourceVersion": "2", "labels": map[string]interface{}{"a": "b"}},
+			"data":     map[string]interface{}{"password": "bmV3", "user": "YWRtaW4="},
+		},
+	}
+
+	r := NewDryRunRecorder("42", 3)
+	r.Record(taskEvent(eventapi.EventPlaybookOnTaskStart, "change", end, nil))
+	r.Record(taskEvent(eventapi.EventRunnerOnOk, "change", end, map[string]interface{}{
+		"res": map[string]interface{}{"changed": true, "diff": diff},
+	}))
+	r.Record(taskEvent(eventapi.EventRunnerOnOk, "unchanged", end, map[string]interface{}{
+		"res": map[string]interface{}{"changed": false},
+	}))
+	r.Record(taskEvent(eventapi.EventRunnerOnFailed, "ignored", end, map[string]interface{}{
+		"ignore_errors": true,
+	}))
+	r.Record(taskEvent(eventapi.EventRunnerOnFailed, "fail", end, map[string]interface{}{
+		"res": map[string]interface{}{"msg": "boom"},
+	}))
+	for i := 0; i < maxPredictedChanges; i++ {
+		r.Record(taskEvent(eventapi.EventRunnerOnOk, "more", end, map[string]interface{}{
+			"res": map[string]interface{}{"changed": true},
+		}))
+	}
+	r.Record(eventapi.JobEvent{Event: eventapi.EventPlaybookOnStats, Created: eventapi.EventTime{Time: end}})
+
+	dr := r.DryRun()
+	if dr.Job != "42" || dr.ObservedGeneration != 3 || !dr.Time.Equal(end) {
+		t.Errorf("Unexpected dry run metadata: %+v", dr)
+	}
+	if dr.TotalChanges != maxPredictedChanges+1 || len(dr.Changes) != maxPredictedChanges {
+		t.Errorf("Expected %d of %d changes to be kept, got %d of %d", maxPredictedChanges,
+			maxPredictedChanges+1, len(dr.Changes), dr.TotalChanges)
+	}
+	expected := PredictedChange{Name: "change", Role: "myrole", Kind: "Secret", ResourceName: "creds",
+		Fields: []string{"data.password", "metadata.labels"}}
+	if !reflect.DeepEqual(dr.Changes[0], expected) {
+		t.Errorf("Expected first change %+v, got %+v", expected, dr.Changes[0])
+	}
+	if !dr.Truncated {
+		t.Error("Expected the dry run to be truncated")
+	}
+	b, err := json.Marshal(dr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "bmV3") {
+		t.Errorf("Expected no field values in the dry run, got %s", b)
+	}
+	if len(dr.Failures) != 1 {
+		t.Errorf("Expected one failure, got %v", dr.Failures)
+	}
+
+	// The dry run must survive a round trip through the status map.
+	s := Status{DryRun: dr}
+	got := CreateFromMap(s.GetJSONMap())
+	if got.DryRun == nil || got.DryRun.TotalChanges != dr.TotalChanges || got.DryRun.Job != dr.Job {
+		t.Errorf("Expected dry run %+v after round trip, got %+v", dr, got.DryRun)
+	}
+	if _, ok := got.CustomStatus["dryRun"]; ok {
+		t.Error("Expected dryRun not to be part of the custom status")
+	}
+}
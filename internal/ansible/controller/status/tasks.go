@@ -0,0 +1,175 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+// Task results reported in a TaskResult.
+const (
+	TaskResultOk          = "ok"
+	TaskResultFailed      = "failed"
+	TaskResultSkipped     = "skipped"
+	TaskResultUnreachable = "unreachable"
+)
+
+// TaskResult - summary of a single task from the last ansible run.
+type TaskResult struct {
+	Name     string `json:"name"`
+	Role     string `json:"role,omitempty"`
+	Result   string `json:"result"`
+	Changed  bool   `json:"changed"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// FailedTask - a task that failed, along with the message it failed with.
+type FailedTask struct {
+	Name    string             `json:"name"`
+	Role    string             `json:"role,omitempty"`
+	Message string             `json:"message"`
+	Time    eventapi.EventTime `json:"time"`
+}
+
+// TaskSummary - bounded summary of the tasks run by ansible. Tasks only holds
+// the most recent tasks of the last run, while FailedTasks holds the most
+// recent failures across runs.
+type TaskSummary struct {
+	TotalTasks  int          `json:"totalTasks"`
+	Tasks       []TaskResult `json:"tasks,omitempty"`
+	FailedTasks []FailedTask `json:"failedTasks,omitempty"`
+}
+
+// createTaskSummaryFromMap - creates a TaskSummary from the status map of a CR.
+func createTaskSummaryFromMap(tm map[string]interface{}) *TaskSummary {
+	b, err := json.Marshal(tm)
+	if err != nil {
+		log.Error(err, "Unable to marshal task summary")
+		return nil
+	}
+	ts := &TaskSummary{}
+	if err := json.Unmarshal(b, ts); err != nil {
+		log.Info("Unable to parse task summary, discarding it", "error", err.Error())
+		return nil
+	}
+	return ts
+}
+
+// TaskSummaryRecorder - builds a TaskSummary from the job events of a run.
+type TaskSummaryRecorder struct {
+	maxTasks       int
+	maxFailedTasks int
+	summary        TaskSummary
+	// taskStarts maps task UUIDs to the time the task started, for events
+	// that do not report their own duration.
+	taskStarts map[string]time.Time
+}
+
+// NewTaskSummaryRecorder - creates a recorder that keeps at most maxTasks tasks and
+// maxFailedTasks failures. Failures from the previous summary are kept until
+// newer failures push them out.
+func NewTaskSummaryRecorder(maxTasks, maxFailedTasks int, previous *TaskSummary) *TaskSummaryRecorder {
+	r := &TaskSummaryRecorder{
+		maxTasks:       maxTasks,
+		maxFailedTasks: maxFailedTasks,
+		taskStarts:     map[string]time.Time{},
+	}
+	if previous != nil {
+		r.summary.FailedTasks = append(r.summary.FailedTasks, previous.FailedTasks...)
+		r.summary.FailedTasks = lastFailedTasks(r.summary.FailedTasks, maxFailedTasks)
+	}
+	return r
+}
+
+// Record - adds the result of a job event to the summary. Events that do not
+// mark the end of a task are only used to track task start times.
+func (r *TaskSummaryRecorder) Record(je eventapi.JobEvent) {
+	var result string
+	switch je.Event {
+	case eventapi.EventPlaybookOnTaskStart:
+		if id, ok := je.EventData["task_uuid"].(string); ok {
+			r.taskStarts[id] = je.Created.Time
+		}
+		return
+	case eventapi.EventRunnerOnOk:
+		result = TaskResultOk
+	case eventapi.EventRunnerOnFailed:
+		result = TaskResultFailed
+	case eventapi.EventRunnerOnSkipped:
+		result = TaskResultSkipped
+	case eventapi.EventRunnerOnUnreachable:
+		result = TaskResultUnreachable
+	default:
+		return
+	}
+
+	name, _ := je.EventData["task"].(string)
+	role, _ := je.EventData["role"].(string)
+	tr := TaskResult{
+		Name:     name,
+		Role:     role,
+		Result:   result,
+		Changed:  je.Changed(),
+		Duration: r.taskDuration(je),
+	}
+	r.summary.TotalTasks++
+	r.summary.Tasks = append(r.summary.Tasks, tr)
+	if len(r.summary.Tasks) > r.maxTasks {
+		r.summary.Tasks = r.summary.Tasks[len(r.summary.Tasks)-r.maxTasks:]
+	}
+
+	if result == TaskResultFailed && !je.IgnoreError() && !je.Rescued() {
+		r.summary.FailedTasks = append(r.summary.FailedTasks, FailedTask{
+			Name:    name,
+			Role:    role,
+			Message: je.GetFailedPlaybookMessage(),
+			Time:    je.Created,
+		})
+		r.summary.FailedTasks = lastFailedTasks(r.summary.FailedTasks, r.maxFailedTasks)
+	}
+}
+
+// Summary - returns the summary of all events recorded so far.
+func (r *TaskSummaryRecorder) Summary() *TaskSummary {
+	s := r.summary
+	return &s
+}
+
+// taskDuration returns the duration reported by ansible-runner, or falls back to
+// the time elapsed since the task start event.
+func (r *TaskSummaryRecorder) taskDuration(je eventapi.JobEvent) string {
+	if d, ok := je.EventData["duration"].(float64); ok {
+		return time.Duration(d * float64(time.Second)).Round(time.Millisecond).String()
+	}
+	id, ok := je.EventData["task_uuid"].(string)
+	if !ok {
+		return ""
+	}
+	start, ok := r.taskStarts[id]
+	if !ok || je.Created.Before(start) {
+		return ""
+	}
+	return je.Created.Sub(start).Round(time.Millisecond).String()
+}
+
+func lastFailedTasks(tasks []FailedTask, max int) []FailedTask {
+	if len(tasks) > max {
+		return tasks[len(tasks)-max:]
+	}
+	return tasks
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+func taskEvent(event, task string, created time.Time, data map[string]interface{}) eventapi.JobEvent {
+	eventData := map[string]interface{}{"task": task, "task_uuid": task + "-uuid", "role": "myrole"}
+	for k, v := range data {
+		eventData[k] = v
+	}
+	return eventapi.JobEvent{Event: event, Created: eventapi.EventTime{Time: created}, EventData: eventData}
+}
+
+func TestTaskSummaryRecorder(t *testing.T) {
+	start := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	previousFailure := FailedTask{Name: "old task", Message: "old failure"}
+
+	testCases := []struct {
+		name           string
+		maxTasks       int
+		maxFailedTasks int
+		previous       *TaskSummary
+		events         []eventapi.JobEvent
+		expected       *TaskSummary
+	}{
+		{
+			name:           "records task results",
+			maxTasks:       10,
+			maxFailedTasks: 5,
+			events: []eventapi.JobEvent{
+				taskEvent(eventapi.EventPlaybookOnTaskStart, "create", start, nil),
+				taskEvent(eventapi.EventRunnerOnOk, "create", start.Add(1500*time.Millisecond),
+					map[string]interface{}{"res": map[string]interface{}{"changed": true}}),
+				taskEvent(eventapi.EventRunnerOnSkipped, "skip", start, map[string]interface{}{"duration": 0.25}),
+				taskEvent(eventapi.EventPlaybookOnStats, "", start, nil),
+			},
+			expected: &TaskSummary{
+				TotalTasks: 2,
+				Tasks: []TaskResult{
+					{Name: "create", Role: "myrole", Result: TaskResultOk, Changed: true, Duration: "1.5s"},
+					{Name: "skip", Role: "myrole", Result: TaskResultSkipped, Duration: "250ms"},
+				},
+			},
+		},
+		{
+			name:           "keeps only the most recent tasks",
+			maxTasks:       1,
+			maxFailedTasks: 5,
+			events: []eventapi.JobEvent{
+				taskEvent(eventapi.EventRunnerOnOk, "first", start, nil),
+				taskEvent(eventapi.EventRunnerOnOk, "second", start, nil),
+			},
+			expected: &TaskSummary{
+				TotalTasks: 2,
+				Tasks:      []TaskResult{{Name: "second", Role: "myrole", Result: TaskResultOk}},
+			},
+		},
+		{
+			name:           "records failures after previous failures",
+			maxTasks:       10,
+			maxFailedTasks: 2,
+			previous:       &TaskSummary{FailedTasks: []FailedTask{previousFailure, previousFailure}},
+			events: []eventapi.JobEvent{
+				taskEvent(eventapi.EventRunnerOnFailed, "ignored", start,
+					map[string]interface{}{"ignore_errors": true, "res": map[string]interface{}{"msg": "ignored"}}),
+				taskEvent(eventapi.EventRunnerOnFailed, "broken", start,
+					map[string]interface{}{"res": map[string]interface{}{"msg": "boom"}}),
+			},
+			expected: &TaskSummary{
+				TotalTasks: 2,
+				Tasks: []TaskResult{
+					{Name: "ignored", Role: "myrole", Result: TaskResultFailed},
+					{Name: "broken", Role: "myrole", Result: TaskResultFailed},
+				},
+				FailedTasks: []FailedTask{
+					previousFailure,
+					{Name: "broken", Role: "myrole", Message: "boom", Time: eventapi.EventTime{Time: start}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewTaskSummaryRecorder(tc.maxTasks, tc.maxFailedTasks, tc.previous)
+			for _, e := range tc.events {
+				r.Record(e)
+			}
+			if summary := r.Summary(); !reflect.DeepEqual(summary, tc.expected) {
+				t.Fatalf("Unexpected task summary\nexpected: %#v\nactual: %#v", tc.expected, summary)
+			}
+		})
+	}
+}
+
+func TestCreateFromMapTaskSummary(t *testing.T) {
+	statusMap := map[string]interface{}{
+		"custom": "value",
+		"taskSummary": map[string]interface{}{
+			"totalTasks": int64(1),
+			"tasks": []interface{}{
+				map[string]interface{}{"name": "create", "result": "ok", "changed": true},
+			},
+		},
+	}
+	s := CreateFromMap(statusMap)
+	expected := &TaskSummary{
+		TotalTasks: 1,
+		Tasks:      []TaskResult{{Name: "create", Result: TaskResultOk, Changed: true}},
+	}
+	if !reflect.DeepEqual(s.TaskSummary, expected) {
+		t.Fatalf("Unexpected task summary\nexpected: %#v\nactual: %#v", expected, s.TaskSummary)
+	}
+	if _, ok := s.CustomStatus["taskSummary"]; ok {
+		t.Fatalf("Task summary should not be part of the custom status: %v", s.CustomStatus)
+	}
+	if _, ok := s.GetJSONMap()["taskSummary"]; !ok {
+		t.Fatalf("Task summary should be written back to the status map")
+	}
+}
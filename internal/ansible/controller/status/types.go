@@ -93,6 +93,8 @@ const (
 	RunningConditionType ConditionType = "Running"
 	// FailureConditionType - condition type of failure.
 	FailureConditionType ConditionType = "Failure"
+	// FinalizingConditionType - condition type of the progress of the finalizers of a deleted resource.
+	FinalizingConditionType ConditionType = "Finalizing"
 )
 
 // Condition - the condition for the ansible operator.
@@ -151,21 +153,60 @@ func createConditionFromMap(cm map[string]interface{}) Condition {
 
 // Status - The status for custom resources managed by the operator-sdk.
 type Status struct {
-	Conditions   []Condition            `json:"conditions"`
-	CustomStatus map[string]interface{} `json:"-"`
+	Conditions        []Condition            `json:"conditions"`
+	TaskSummary       *TaskSummary           `json:"taskSummary,omitempty"`
+	DryRun            *DryRun                `json:"dryRun,omitempty"`
+	LastSuccessfulRun *LastSuccessfulRun     `json:"lastSuccessfulRun,omitempty"`
+	CustomStatus      map[string]interface{} `json:"-"`
+}
+
+// LastSuccessfulRun - identifies the inputs of the last successful run for a CR.
+type LastSuccessfulRun struct {
+	Job string `json:"job"`
+	// ObservedGeneration is the generation of the CR the run was for.
+	ObservedGeneration int64 `json:"observedGeneration"`
+	// InputHash is the hash of the inputs ansible-runner received.
+	InputHash string `json:"inputHash"`
+}
+
+func createLastSuccessfulRunFromMap(lm map[string]interface{}) *LastSuccessfulRun {
+	b, err := json.Marshal(lm)
+	if err != nil {
+		log.Error(err, "Unable to marshal last successful run")
+		return nil
+	}
+	lr := &LastSuccessfulRun{}
+	if err := json.Unmarshal(b, lr); err != nil {
+		log.Info("Unable to parse last successful run, discarding it", "error", err.Error())
+		return nil
+	}
+	return lr
 }
 
 // CreateFromMap - create a status from the map
 func CreateFromMap(statusMap map[string]interface{}) Status {
 	customStatus := make(map[string]interface{})
 	for key, value := range statusMap {
-		if key != "conditions" {
+		if key != "conditions" && key != "taskSummary" && key != "dryRun" && key != "lastSuccessfulRun" {
 			customStatus[key] = value
 		}
 	}
+	var taskSummary *TaskSummary
+	if tm, ok := statusMap["taskSummary"].(map[string]interface{}); ok {
+		taskSummary = createTaskSummaryFromMap(tm)
+	}
+	var dryRun *DryRun
+	if dm, ok := statusMap["dryRun"].(map[string]interface{}); ok {
+		dryRun = createDryRunFromMap(dm)
+	}
+	var lastSuccessfulRun *LastSuccessfulRun
+	if lm, ok := statusMap["lastSuccessfulRun"].(map[string]interface{}); ok {
+		lastSuccessfulRun = createLastSuccessfulRunFromMap(lm)
+	}
 	conditionsInterface, ok := statusMap["conditions"].([]interface{})
 	if !ok {
-		return Status{Conditions: []Condition{}, CustomStatus: customStatus}
+		return Status{Conditions: []Condition{}, TaskSummary: taskSummary, DryRun: dryRun,
+			LastSuccessfulRun: lastSuccessfulRun, CustomStatus: customStatus}
 	}
 	conditions := []Condition{}
 	for _, ci := range conditionsInterface {
@@ -176,7 +217,8 @@ func CreateFromMap(statusMap map[string]interface{}) Status {
 		}
 		conditions = append(conditions, createConditionFromMap(cm))
 	}
-	return Status{Conditions: conditions, CustomStatus: customStatus}
+	return Status{Conditions: conditions, TaskSummary: taskSummary, DryRun: dryRun,
+		LastSuccessfulRun: lastSuccessfulRun, CustomStatus: customStatus}
 }
 
 // GetJSONMap - gets the map value for the status object.
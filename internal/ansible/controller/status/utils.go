@@ -26,8 +26,14 @@ const (
 	SuccessfulReason = "Successful"
 	// FailedReason - Condition is failed due to ansible failure
 	FailedReason = "Failed"
+	// TimeoutReason - Condition is failed due to ansible-runner exceeding its run timeout
+	TimeoutReason = "Timeout"
 	// UnknownFailedReason - Condition is unknown
 	UnknownFailedReason = "Unknown"
+	// FinalizingReason - Condition is finalizing due to a finalizer running
+	FinalizingReason = "Finalizing"
+	// FinalizerFailedReason - Condition is finalizing, but the last finalizer run failed
+	FinalizerFailedReason = "FinalizerFailed"
 )
 
 const (
@@ -35,6 +41,8 @@ const (
 	RunningMessage = "Running reconciliation"
 	// SuccessfulMessage - message for successful reason.
 	SuccessfulMessage = "Awaiting next reconciliation"
+	// TimeoutMessage - message for timeout reason.
+	TimeoutMessage = "Ansible-runner exceeded the run timeout and was stopped"
 )
 
 // NewCondition -  condition
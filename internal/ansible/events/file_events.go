@@ -0,0 +1,97 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+// fileQueueSize is the number of events that can be queued before new events
+// are dropped.
+const fileQueueSize = 1000
+
+// FileEventHandler - an EventHandler that appends every job event to a file as
+// a line of JSON. It must be started with Start to write events.
+type FileEventHandler struct {
+	file    *os.File
+	records chan eventRecord
+	logger  logr.Logger
+}
+
+// NewFileEventHandler - Creates a FileEventHandler that appends events to opts.FilePath.
+func NewFileEventHandler(opts HandlerOptions) (EventHandler, error) {
+	if opts.FilePath == "" {
+		return nil, errors.New("a file path must be set")
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.FilePath), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(opts.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileEventHandler{
+		file:    f,
+		records: make(chan eventRecord, fileQueueSize),
+		logger:  logf.Log.WithName("file_event_handler"),
+	}, nil
+}
+
+// Handle queues the event to be written. If the queue is full the event is
+// dropped rather than blocking the reconcile.
+func (h *FileEventHandler) Handle(ident string, u *unstructured.Unstructured, e eventapi.JobEvent) {
+	select {
+	case h.records <- newEventRecord(ident, u, e):
+	default:
+		h.logger.Info("Event queue is full, dropping event", "job", ident, "event_type", e.Event)
+	}
+}
+
+// Start writes queued events, in order, until ctx is done, at which point any
+// events still queued are written and the file is closed. It implements
+// manager.Runnable.
+func (h *FileEventHandler) Start(ctx context.Context) error {
+	encoder := json.NewEncoder(h.file)
+	write := func(r eventRecord) {
+		if err := encoder.Encode(r); err != nil {
+			h.logger.Error(err, "Failed to write event", "job", r.Job)
+		}
+	}
+	for {
+		select {
+		case r := <-h.records:
+			write(r)
+		case <-ctx.Done():
+			for {
+				select {
+				case r := <-h.records:
+					write(r)
+				default:
+					return h.file.Close()
+				}
+			}
+		}
+	}
+}
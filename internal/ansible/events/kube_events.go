@@ -0,0 +1,56 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+// Reasons of the Kubernetes Events created by the kubernetes-events EventHandler.
+const (
+	TaskFailedReason  = "TaskFailed"
+	TaskChangedReason = "TaskChanged"
+)
+
+type kubeEventHandler struct {
+	recorder record.EventRecorder
+}
+
+// NewKubeEventHandler - Creates an EventHandler that records failed and changed
+// tasks as Kubernetes Events on the CR being reconciled.
+func NewKubeEventHandler(opts HandlerOptions) (EventHandler, error) {
+	if opts.Recorder == nil {
+		return nil, errors.New("an event recorder must be set")
+	}
+	return kubeEventHandler{recorder: opts.Recorder}, nil
+}
+
+func (h kubeEventHandler) Handle(_ string, u *unstructured.Unstructured, e eventapi.JobEvent) {
+	task, _ := e.EventData["task"].(string)
+	switch {
+	case e.Event == eventapi.EventRunnerOnFailed && !e.IgnoreError() && !e.Rescued():
+		h.recorder.Event(u, corev1.EventTypeWarning, TaskFailedReason,
+			fmt.Sprintf("Task %q failed: %s", task, e.GetFailedPlaybookMessage()))
+	case e.Event == eventapi.EventRunnerOnOk && e.Changed():
+		h.recorder.Event(u, corev1.EventTypeNormal, TaskChangedReason, fmt.Sprintf("Task %q changed", task))
+	}
+}
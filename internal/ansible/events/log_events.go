@@ -41,7 +41,9 @@ const (
 	Nothing
 )
 
-// EventHandler - knows how to handle job events.
+// EventHandler - knows how to handle job events. Handle is called for every
+// event of a job, in order, from the reconcile, so it must not block and must
+// not modify the CR.
 type EventHandler interface {
 	Handle(string, *unstructured.Unstructured, eventapi.JobEvent)
 }
@@ -0,0 +1,131 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+// Names of the EventHandlers that are registered by default.
+const (
+	FileHandlerName       = "file"
+	WebhookHandlerName    = "webhook"
+	KubeEventsHandlerName = "kubernetes-events"
+)
+
+// HandlerOptions - configuration shared by all EventHandler factories. Each
+// factory only reads the options relevant to it.
+type HandlerOptions struct {
+	// FilePath is the file that JSON-lines records are appended to.
+	FilePath string
+	// WebhookURL is the URL that batches of records are POSTed to.
+	WebhookURL string
+	// WebhookBatchSize is the maximum number of records sent in one request.
+	WebhookBatchSize int
+	// WebhookFlushInterval is the maximum time a record is buffered before it is sent.
+	WebhookFlushInterval time.Duration
+	// WebhookMaxRetries is the number of times a failed request is retried.
+	WebhookMaxRetries int
+	// Recorder is used to create Kubernetes Events.
+	Recorder record.EventRecorder
+}
+
+// HandlerFactory - creates an EventHandler from the HandlerOptions.
+type HandlerFactory func(HandlerOptions) (EventHandler, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]HandlerFactory{
+		FileHandlerName:       NewFileEventHandler,
+		WebhookHandlerName:    NewWebhookEventHandler,
+		KubeEventsHandlerName: NewKubeEventHandler,
+	}
+)
+
+// Register - makes an EventHandler factory available under name. It returns an
+// error if a factory is already registered with that name.
+func Register(name string, factory HandlerFactory) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("event handler %q is already registered", name)
+	}
+	registry[name] = factory
+	return nil
+}
+
+// RegisteredHandlers - returns the sorted names of all registered EventHandlers.
+func RegisteredHandlers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registeredHandlers()
+}
+
+// registeredHandlers must be called with registryMu held.
+func registeredHandlers() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewEventHandlers - creates the EventHandlers registered with the given names.
+func NewEventHandlers(names []string, opts HandlerOptions) ([]EventHandler, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	handlers := []EventHandler{}
+	for _, name := range names {
+		factory, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown event handler %q, must be one of %v", name, registeredHandlers())
+		}
+		h, err := factory(opts)
+		if err != nil {
+			return nil, fmt.Errorf("error creating event handler %q: %w", name, err)
+		}
+		handlers = append(handlers, h)
+	}
+	return handlers, nil
+}
+
+// eventRecord - a job event along with the CR and job it belongs to, as written
+// by the file and webhook EventHandlers.
+type eventRecord struct {
+	Job       string            `json:"job"`
+	GVK       string            `json:"gvk"`
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Event     eventapi.JobEvent `json:"event"`
+}
+
+func newEventRecord(ident string, u *unstructured.Unstructured, e eventapi.JobEvent) eventRecord {
+	return eventRecord{
+		Job:       ident,
+		GVK:       u.GroupVersionKind().String(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+		Event:     e,
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/record"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+func TestNewEventHandlers(t *testing.T) {
+	if _, err := NewEventHandlers([]string{"unknown"}, HandlerOptions{}); err == nil {
+		t.Fatal("Expected an error for an unknown handler")
+	}
+	if _, err := NewEventHandlers([]string{WebhookHandlerName}, HandlerOptions{}); err == nil {
+		t.Fatal("Expected an error for a webhook handler without a URL")
+	}
+	if err := Register(FileHandlerName, NewFileEventHandler); err == nil {
+		t.Fatal("Expected an error registering a handler twice")
+	}
+	handlers, err := NewEventHandlers([]string{KubeEventsHandlerName},
+		HandlerOptions{Recorder: record.NewFakeRecorder(1)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(handlers) != 1 {
+		t.Fatalf("Expected 1 handler, got %d", len(handlers))
+	}
+}
+
+func TestFileEventHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events", "events.jsonl")
+	h, err := NewFileEventHandler(HandlerOptions{FilePath: path})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	u := testCR()
+	for _, uuid := range []string{"1", "2", "3"} {
+		h.Handle("job", u, eventapi.JobEvent{UUID: uuid, Event: eventapi.EventRunnerOnOk})
+	}
+	// Stopping the handler writes the queued events and closes the file.
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if err := h.(*FileEventHandler).Start(ctx); err != nil {
+		t.Fatalf("Unexpected error from Start: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open events file: %v", err)
+	}
+	defer f.Close()
+	uuids := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		r := eventRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("Line is not a JSON record: %v", err)
+		}
+		uuids = append(uuids, r.Event.UUID)
+	}
+	if strings.Join(uuids, ",") != "1,2,3" {
+		t.Fatalf("Unexpected events in file: %v", uuids)
+	}
+}
+
+func TestKubeEventHandler(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	h, err := NewKubeEventHandler(HandlerOptions{Recorder: recorder})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	u := testCR()
+	h.Handle("job", u, eventapi.JobEvent{Event: eventapi.EventRunnerOnOk, EventData: map[string]interface{}{
+		"task": "unchanged", "res": map[string]interface{}{"changed": false},
+	}})
+	h.Handle("job", u, eventapi.JobEvent{Event: eventapi.EventRunnerOnOk, EventData: map[string]interface{}{
+		"task": "create", "res": map[string]interface{}{"changed": true},
+	}})
+	h.Handle("job", u, eventapi.JobEvent{Event: eventapi.EventRunnerOnFailed, EventData: map[string]interface{}{
+		"task": "ignored", "ignore_errors": true,
+	}})
+	h.Handle("job", u, eventapi.JobEvent{Event: eventapi.EventRunnerOnFailed, EventData: map[string]interface{}{
+		"task": "delete", "res": map[string]interface{}{"msg": "boom"},
+	}})
+	close(recorder.Events)
+
+	got := []string{}
+	for e := range recorder.Events {
+		got = append(got, e)
+	}
+	expected := []string{
+		`Normal TaskChanged Task "create" changed`,
+		`Warning TaskFailed Task "delete" failed: boom`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Unexpected events\nexpected: %v\nactual: %v", expected, got)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+const (
+	defaultWebhookBatchSize     = 50
+	defaultWebhookFlushInterval = 5 * time.Second
+	// webhookQueueBatches is the number of batches that can be queued before
+	// new events are dropped.
+	webhookQueueBatches = 10
+	webhookRetryBackoff = time.Second
+	webhookTimeout      = 10 * time.Second
+	// webhookDrainTimeout bounds the time spent sending queued events on shutdown.
+	webhookDrainTimeout = 30 * time.Second
+)
+
+// WebhookEventHandler - an EventHandler that POSTs job events, in batches, to a
+// webhook as a JSON array. It must be started with Start to send events.
+type WebhookEventHandler struct {
+	url           string
+	client        *http.Client
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	retryBackoff  time.Duration
+	records       chan eventRecord
+	logger        logr.Logger
+}
+
+// NewWebhookEventHandler - Creates a WebhookEventHandler that sends events to opts.WebhookURL.
+func NewWebhookEventHandler(opts HandlerOptions) (EventHandler, error) {
+	if opts.WebhookURL == "" {
+		return nil, errors.New("a webhook URL must be set")
+	}
+	if opts.WebhookBatchSize <= 0 {
+		opts.WebhookBatchSize = defaultWebhookBatchSize
+	}
+	if opts.WebhookFlushInterval <= 0 {
+		opts.WebhookFlushInterval = defaultWebhookFlushInterval
+	}
+	if opts.WebhookMaxRetries < 0 {
+		return nil, errors.New("webhook max retries must not be negative")
+	}
+	return &WebhookEventHandler{
+		url:           opts.WebhookURL,
+		client:        &http.Client{Timeout: webhookTimeout},
+		batchSize:     opts.WebhookBatchSize,
+		flushInterval: opts.WebhookFlushInterval,
+		maxRetries:    opts.WebhookMaxRetries,
+		retryBackoff:  webhookRetryBackoff,
+		records:       make(chan eventRecord, opts.WebhookBatchSize*webhookQueueBatches),
+		logger:        logf.Log.WithName("webhook_event_handler"),
+	}, nil
+}
+
+// Handle queues the event to be sent with the next batch. If the queue is full
+// the event is dropped rather than blocking the reconcile.
+func (h *WebhookEventHandler) Handle(ident string, u *unstructured.Unstructured, e eventapi.JobEvent) {
+	select {
+	case h.records <- newEventRecord(ident, u, e):
+	default:
+		h.logger.Info("Event queue is full, dropping event", "job", ident, "event_type", e.Event)
+	}
+}
+
+// Start sends queued events until ctx is done, at which point any events still
+// queued are sent before returning. It implements manager.Runnable.
+func (h *WebhookEventHandler) Start(ctx context.Context) error {
+	ticker := time.NewTicker(h.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]eventRecord, 0, h.batchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := h.send(ctx, batch); err != nil {
+			h.logger.Error(err, "Failed to send events, dropping them", "count", len(batch))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case r := <-h.records:
+			batch = append(batch, r)
+			if len(batch) >= h.batchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		case <-ctx.Done():
+			// ctx is already done, so queued events are sent with a context of
+			// their own that bounds how long shutdown can take.
+			drainCtx, cancel := context.WithTimeout(context.Background(), webhookDrainTimeout)
+			defer cancel()
+			for {
+				select {
+				case r := <-h.records:
+					batch = append(batch, r)
+					if len(batch) >= h.batchSize {
+						flush(drainCtx)
+					}
+				default:
+					flush(drainCtx)
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// send POSTs batch to the webhook, retrying with exponential backoff on
+// connection errors and on 429 and 5xx responses until ctx is done.
+func (h *WebhookEventHandler) send(ctx context.Context, batch []eventRecord) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	backoff := h.retryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := h.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= h.maxRetries {
+			return err
+		}
+		h.logger.V(1).Info("Retrying webhook request", "attempt", attempt+1, "error", err.Error())
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// post makes a single request, returning whether a failed request may be retried.
+func (h *WebhookEventHandler) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
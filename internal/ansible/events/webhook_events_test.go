@@ -0,0 +1,166 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+func testCR() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("app.example.com/v1alpha1")
+	u.SetKind("Example")
+	u.SetName("example")
+	u.SetNamespace("default")
+	return u
+}
+
+func TestWebhookEventHandler(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		received []eventRecord
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		// Fail the first request to exercise retries.
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Unexpected content type %q", ct)
+		}
+		batch := []eventRecord{}
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		received = append(received, batch...)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	eh, err := NewWebhookEventHandler(HandlerOptions{
+		WebhookURL:           srv.URL,
+		WebhookBatchSize:     2,
+		WebhookFlushInterval: time.Hour,
+		WebhookMaxRetries:    1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	h := eh.(*WebhookEventHandler)
+	h.retryBackoff = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan error)
+	go func() { done <- h.Start(ctx) }()
+
+	u := testCR()
+	for _, uuid := range []string{"1", "2", "3"} {
+		h.Handle("job", u, eventapi.JobEvent{UUID: uuid, Event: eventapi.EventRunnerOnOk})
+	}
+	// The first two events fill a batch, which is retried once; the third is
+	// only sent on shutdown.
+	g := NewWithT(t)
+	g.Eventually(func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received)
+	}).Should(Equal(2))
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error from Start: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 3 {
+		t.Fatalf("Expected 3 requests, got %d", requests)
+	}
+	if len(received) != 3 {
+		t.Fatalf("Expected 3 events, got %d: %#v", len(received), received)
+	}
+	for i, r := range received {
+		if r.Job != "job" || r.Name != "example" || r.Namespace != "default" ||
+			r.GVK != u.GroupVersionKind().String() {
+			t.Fatalf("Unexpected record %#v", r)
+		}
+		if r.Event.UUID != []string{"1", "2", "3"}[i] {
+			t.Fatalf("Unexpected event order: %#v", received)
+		}
+	}
+}
+
+func TestWebhookEventHandlerGivesUp(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	eh, err := NewWebhookEventHandler(HandlerOptions{WebhookURL: srv.URL, WebhookMaxRetries: 3})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	h := eh.(*WebhookEventHandler)
+	if err := h.send(context.TODO(), []eventRecord{{Job: "job"}}); err == nil {
+		t.Fatal("Expected an error for a 400 response")
+	}
+	if requests != 1 {
+		t.Fatalf("Client errors should not be retried, got %d requests", requests)
+	}
+}
+
+func TestWebhookEventHandlerStopsRetrying(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	eh, err := NewWebhookEventHandler(HandlerOptions{WebhookURL: srv.URL, WebhookMaxRetries: 10})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	h := eh.(*WebhookEventHandler)
+	h.retryBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan error)
+	go func() { done <- h.send(ctx, []eventRecord{{Job: "job"}}) }()
+	cancel()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Expected an error when the context is done")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Retries were not stopped when the context was done")
+	}
+}
@@ -39,6 +39,35 @@ type Flags struct {
 	LeaderElectionNamespace string
 	GracefulShutdownTimeout time.Duration
 	AnsibleArgs             string
+	ProxyTransport          string
+
+	// Backend that runs ansible-runner, and the options of the "job" backend.
+	RunnerBackend           string
+	RunnerJobImage          string
+	RunnerJobNamespace      string
+	RunnerJobServiceAccount string
+	RunnerJobHost           string
+
+	// Browsing, archiving and retention of runner artifacts.
+	ArtifactsAPI         bool
+	ArtifactsArchiveDir  string
+	ArtifactsMaxArchives int
+	ArtifactsMaxAge      time.Duration
+
+	// Sinks for audit records of mutating requests made through the proxy.
+	ProxyAuditSinks          []string
+	ProxyAuditFile           string
+	ProxyAuditFileMaxSize    int
+	ProxyAuditFileMaxBackups int
+	ProxyAuditRequestBodies  bool
+
+	// Additional sinks for Ansible job events.
+	EventHandlers             []string
+	EventFile                 string
+	EventWebhookURL           string
+	EventWebhookBatchSize     int
+	EventWebhookFlushInterval time.Duration
+	EventWebhookMaxRetries    int
 
 	// Path to a controller-runtime componentconfig file.
 	// If this is empty, use default values.
@@ -91,6 +120,128 @@ func (f *Flags) AddTo(flagSet *pflag.FlagSet) {
 		"Ansible args. Allows user to specify arbitrary arguments for ansible-based operators.",
 	)
 
+	flagSet.StringVar(&f.ProxyTransport,
+		"proxy-transport",
+		"http",
+		"How playbooks connect to the operator's Kubernetes API proxy. One of \"http\" (localhost:8888) or "+
+			"\"tls\" (localhost:8888 with a certificate signed by a CA generated at startup).",
+	)
+
+	// Runner backend flags.
+	flagSet.StringVar(&f.RunnerBackend,
+		"runner-backend",
+		"local",
+		"Where ansible-runner runs. One of \"local\" (a child process of the operator) or "+
+			"\"job\" (a Kubernetes Job per reconciliation, which requires --proxy-transport=tls).",
+	)
+	flagSet.StringVar(&f.RunnerJobImage,
+		"runner-job-image",
+		"",
+		"Execution environment image of the \"job\" runner backend. It must contain ansible-runner "+
+			"and the playbooks and roles of the watches at the same paths as the operator image.",
+	)
+	flagSet.StringVar(&f.RunnerJobNamespace,
+		"runner-job-namespace",
+		"",
+		"Namespace the \"job\" runner backend creates Jobs in. Defaults to the namespace of the operator's service account.",
+	)
+	flagSet.StringVar(&f.RunnerJobServiceAccount,
+		"runner-job-service-account",
+		"default",
+		"Service account of the Jobs created by the \"job\" runner backend",
+	)
+	flagSet.StringVar(&f.RunnerJobHost,
+		"runner-job-host",
+		"",
+		"Address of the operator pod that Jobs created by the \"job\" runner backend connect to "+
+			"for the proxy and events. Defaults to the value of the POD_IP environment variable.",
+	)
+
+	// Artifacts flags.
+	flagSet.BoolVar(&f.ArtifactsAPI,
+		"artifacts-api",
+		false,
+		"Serve a read-only HTTP API for the runner artifacts of each CR under /artifacts/ on the metrics endpoint. "+
+			"The metrics endpoint must be bound to a loopback address behind an authenticating proxy",
+	)
+	flagSet.StringVar(&f.ArtifactsArchiveDir,
+		"artifacts-archive-dir",
+		"",
+		"Directory, such as the mount path of a persistent volume, that a gzipped tarball of the artifacts "+
+			"of every run is written to. Requires the \"local\" runner backend",
+	)
+	flagSet.IntVar(&f.ArtifactsMaxArchives,
+		"artifacts-max-archives",
+		20,
+		"Number of archives kept per CR in --artifacts-archive-dir. 0 keeps all archives",
+	)
+	flagSet.DurationVar(&f.ArtifactsMaxAge,
+		"artifacts-max-age",
+		0,
+		"Age after which the artifacts and archives of runs are removed. 0 keeps them until they are rotated",
+	)
+
+	// Proxy audit flags.
+	flagSet.StringSliceVar(&f.ProxyAuditSinks,
+		"proxy-audit-sinks",
+		nil,
+		"Comma-separated list of sinks for audit records of the mutating requests playbooks and roles "+
+			"make through the proxy. Valid values are \"file\" and \"kubernetes-events\".",
+	)
+	flagSet.StringVar(&f.ProxyAuditFile,
+		"proxy-audit-file",
+		"/tmp/ansible-operator/audit.jsonl",
+		"Path of the file the \"file\" audit sink appends JSON-lines records to",
+	)
+	flagSet.IntVar(&f.ProxyAuditFileMaxSize,
+		"proxy-audit-file-max-size",
+		100,
+		"Size in megabytes above which the \"file\" audit sink rotates its file. 0 disables rotation",
+	)
+	flagSet.IntVar(&f.ProxyAuditFileMaxBackups,
+		"proxy-audit-file-max-backups",
+		3,
+		"Number of rotated files kept by the \"file\" audit sink",
+	)
+	flagSet.BoolVar(&f.ProxyAuditRequestBodies,
+		"proxy-audit-request-bodies",
+		false,
+		"Add the bodies of create, update and patch requests to audit records",
+	)
+
+	// Event handler flags.
+	flagSet.StringSliceVar(&f.EventHandlers,
+		"event-handlers",
+		nil,
+		"Comma-separated list of additional handlers for Ansible job events. "+
+			"Valid values are \"file\", \"webhook\" and \"kubernetes-events\".",
+	)
+	flagSet.StringVar(&f.EventFile,
+		"event-file",
+		"/tmp/ansible-operator/events.jsonl",
+		"Path of the file the \"file\" event handler appends JSON-lines records to",
+	)
+	flagSet.StringVar(&f.EventWebhookURL,
+		"event-webhook-url",
+		"",
+		"URL the \"webhook\" event handler POSTs batches of events to",
+	)
+	flagSet.IntVar(&f.EventWebhookBatchSize,
+		"event-webhook-batch-size",
+		50,
+		"Maximum number of events sent in a single request by the \"webhook\" event handler",
+	)
+	flagSet.DurationVar(&f.EventWebhookFlushInterval,
+		"event-webhook-flush-interval",
+		5*time.Second,
+		"Maximum time the \"webhook\" event handler buffers an event before sending it",
+	)
+	flagSet.IntVar(&f.EventWebhookMaxRetries,
+		"event-webhook-max-retries",
+		3,
+		"Number of times the \"webhook\" event handler retries a failed request",
+	)
+
 	// Controller flags.
 	flagSet.DurationVar(&f.ReconcilePeriod,
 		"reconcile-period",
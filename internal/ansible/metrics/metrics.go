@@ -51,6 +51,16 @@ var (
 			"result",
 		})
 
+	reconcileTimeouts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "reconcile_timeouts_total",
+			Help:      "Total number of reconciles whose ansible-runner job exceeded its run timeout.",
+		},
+		[]string{
+			"GVK",
+		})
+
 	reconciles = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Subsystem: subsystem,
@@ -64,6 +74,7 @@ var (
 
 func init() {
 	metrics.Registry.MustRegister(reconcileResults)
+	metrics.Registry.MustRegister(reconcileTimeouts)
 	metrics.Registry.MustRegister(reconciles)
 }
 
@@ -93,6 +104,16 @@ func ReconcileFailed(gvk string) {
 	reconcileResults.WithLabelValues(gvk, "failed").Inc()
 }
 
+func ReconcileSkipped(gvk string) {
+	defer recoverMetricPanic()
+	reconcileResults.WithLabelValues(gvk, "skipped").Inc()
+}
+
+func ReconcileTimedOut(gvk string) {
+	defer recoverMetricPanic()
+	reconcileTimeouts.WithLabelValues(gvk).Inc()
+}
+
 func ReconcileTimer(gvk string) *prometheus.Timer {
 	defer recoverMetricPanic()
 	return prometheus.NewTimer(prometheus.ObserverFunc(func(duration float64) {
@@ -0,0 +1,124 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paramconv
+
+import (
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Converter - converts the camelCase keys of parameters to snake_case
+// following the openAPIV3Schema of the values. The keys of properties are
+// converted, while the keys of maps, which the schema declares with
+// additionalProperties or x-kubernetes-preserve-unknown-fields, are kept as
+// they are. Keys the schema does not describe, or all keys if there is no
+// schema, are converted with ToSnake. Values are only converted on their way
+// to Ansible: playbooks and roles write status with the keys they choose.
+type Converter struct {
+	schema    *apiextv1.JSONSchemaProps
+	overrides map[string]string
+}
+
+// NewConverter - returns a Converter for values described by schema, which may
+// be nil. overrides maps the dot separated path of a field, such as
+// "network.IPv6Address", to its snake_case name, and takes precedence over the
+// conversion of ToSnake.
+func NewConverter(schema *apiextv1.JSONSchemaProps, overrides map[string]string) *Converter {
+	return &Converter{schema: schema, overrides: overrides}
+}
+
+// MapToSnake - converts the camelCase keys of in to snake_case.
+func (c *Converter) MapToSnake(in map[string]interface{}) map[string]interface{} {
+	return c.convertMap(in, c.schema, "")
+}
+
+func (c *Converter) convertMap(in map[string]interface{}, node *apiextv1.JSONSchemaProps,
+	path string) map[string]interface{} {
+
+	out := make(map[string]interface{}, len(in))
+	for key, val := range in {
+		fieldPath := joinPath(path, key)
+
+		if prop, ok := property(node, key); ok {
+			out[c.convertKey(key, fieldPath)] = c.convertValue(val, prop, fieldPath)
+			continue
+		}
+		if keepsKeys(node, path) {
+			if node.AdditionalProperties != nil && node.AdditionalProperties.Schema != nil {
+				out[key] = c.convertValue(val, node.AdditionalProperties.Schema, fieldPath)
+			} else {
+				out[key] = val
+			}
+			continue
+		}
+		out[c.convertKey(key, fieldPath)] = c.convertValue(val, nil, fieldPath)
+	}
+	return out
+}
+
+func (c *Converter) convertValue(v interface{}, node *apiextv1.JSONSchemaProps, path string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return c.convertMap(v, node, path)
+	case []interface{}:
+		var items *apiextv1.JSONSchemaProps
+		if node != nil && node.Items != nil {
+			items = node.Items.Schema
+		}
+		res := make([]interface{}, len(v))
+		for i, e := range v {
+			res[i] = c.convertValue(e, items, path)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+// convertKey returns the snake_case key of the camelCase field at fieldPath.
+func (c *Converter) convertKey(field, fieldPath string) string {
+	if name, ok := c.overrides[fieldPath]; ok {
+		return name
+	}
+	return ToSnake(field)
+}
+
+func property(node *apiextv1.JSONSchemaProps, field string) (*apiextv1.JSONSchemaProps, bool) {
+	if node == nil {
+		return nil, false
+	}
+	prop, ok := node.Properties[field]
+	return &prop, ok
+}
+
+// keepsKeys returns true if node is a map, whose keys are data rather than
+// fields. The keys of the top level are always fields, since the spec of
+// scaffolded CRDs preserves unknown fields.
+func keepsKeys(node *apiextv1.JSONSchemaProps, path string) bool {
+	if node == nil || path == "" {
+		return false
+	}
+	if node.XPreserveUnknownFields != nil && *node.XPreserveUnknownFields {
+		return true
+	}
+	ap := node.AdditionalProperties
+	return ap != nil && (ap.Allows || ap.Schema != nil)
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paramconv
+
+import (
+	"reflect"
+	"testing"
+
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestConverter(t *testing.T) {
+	preserve := true
+	str := apiextv1.JSONSchemaProps{Type: "string"}
+	schema := &apiextv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextv1.JSONSchemaProps{
+			"IPv6Address": str,
+			"labels": {
+				Type:                 "object",
+				AdditionalProperties: &apiextv1.JSONSchemaPropsOrBool{Schema: &str},
+			},
+			"extra": {Type: "object", XPreserveUnknownFields: &preserve},
+			"servers": {
+				Type: "array",
+				Items: &apiextv1.JSONSchemaPropsOrArray{Schema: &apiextv1.JSONSchemaProps{
+					Type:       "object",
+					Properties: map[string]apiextv1.JSONSchemaProps{"hostName": str},
+				}},
+			},
+			"network": {
+				Type:       "object",
+				Properties: map[string]apiextv1.JSONSchemaProps{"IPv6Address": str},
+			},
+			"volumes": {
+				Type: "object",
+				AdditionalProperties: &apiextv1.JSONSchemaPropsOrBool{Schema: &apiextv1.JSONSchemaProps{
+					Type:       "object",
+					Properties: map[string]apiextv1.JSONSchemaProps{"maxSize": str},
+				}},
+			},
+		},
+	}
+	overrides := map[string]string{
+		"IPv6Address":         "ipv6_address",
+		"network.IPv6Address": "ipv6_address",
+	}
+	camel := map[string]interface{}{
+		"IPv6Address":  "::1",
+		"labels":       map[string]interface{}{"app.kubernetes.io/name": "db"},
+		"extra":        map[string]interface{}{"someKey": map[string]interface{}{"innerKey": "value"}},
+		"servers":      []interface{}{map[string]interface{}{"hostName": "db-0"}},
+		"network":      map[string]interface{}{"IPv6Address": "::1"},
+		"volumes":      map[string]interface{}{"fastDisk": map[string]interface{}{"maxSize": "1Gi"}},
+		"unknownField": map[string]interface{}{"nestedKey": "value"},
+	}
+	snake := map[string]interface{}{
+		"ipv6_address":  "::1",
+		"labels":        map[string]interface{}{"app.kubernetes.io/name": "db"},
+		"extra":         map[string]interface{}{"someKey": map[string]interface{}{"innerKey": "value"}},
+		"servers":       []interface{}{map[string]interface{}{"host_name": "db-0"}},
+		"network":       map[string]interface{}{"ipv6_address": "::1"},
+		"volumes":       map[string]interface{}{"fastDisk": map[string]interface{}{"max_size": "1Gi"}},
+		"unknown_field": map[string]interface{}{"nested_key": "value"},
+	}
+
+	c := NewConverter(schema, overrides)
+	if got := c.MapToSnake(camel); !reflect.DeepEqual(got, snake) {
+		t.Errorf("MapToSnake() = %v, want %v", got, snake)
+	}
+}
+
+func TestConverterPreservedSpec(t *testing.T) {
+	// The spec of scaffolded CRDs preserves unknown fields, whose keys are
+	// still converted.
+	preserve := true
+	c := NewConverter(&apiextv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: &preserve}, nil)
+	in := map[string]interface{}{"appService": map[string]interface{}{"hostName": "db-0"}}
+	want := map[string]interface{}{"app_service": map[string]interface{}{"host_name": "db-0"}}
+	if got := c.MapToSnake(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("MapToSnake() = %v, want %v", got, want)
+	}
+}
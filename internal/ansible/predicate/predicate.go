@@ -54,3 +54,28 @@ func (r resourceFilterPredicate) Delete(e event.DeleteEvent) bool {
 func (r resourceFilterPredicate) Generic(e event.GenericEvent) bool {
 	return r.eventFilter(e.Object.GetLabels())
 }
+
+type annotationChangedPredicate struct {
+	predicate.Funcs
+	key string
+}
+
+// NewAnnotationChangedPredicate - returns a predicate that only passes updates
+// changing the value of the annotation with the given key.
+func NewAnnotationChangedPredicate(key string) predicate.Predicate {
+	return annotationChangedPredicate{
+		Funcs: predicate.Funcs{
+			CreateFunc:  func(event.CreateEvent) bool { return false },
+			DeleteFunc:  func(event.DeleteEvent) bool { return false },
+			GenericFunc: func(event.GenericEvent) bool { return false },
+		},
+		key: key,
+	}
+}
+
+func (p annotationChangedPredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		return false
+	}
+	return e.ObjectOld.GetAnnotations()[p.key] != e.ObjectNew.GetAnnotations()[p.key]
+}
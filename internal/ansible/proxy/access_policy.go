@@ -0,0 +1,111 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/proxy/controllermap"
+	k8sRequest "github.com/operator-framework/operator-sdk/internal/ansible/proxy/requestfactory"
+)
+
+// accessPolicyHandler rejects requests that are not allowed by the access
+// policy of the watch whose playbook or role made them. The watch is found
+// from the owner in the authorization header. Requests for watches without a
+// policy, or that are not for a resource, are not checked. Requests without an
+// owner, or whose owner is not watched, are rejected if any watch has a
+// policy, since the policy that applies to them cannot be determined.
+type accessPolicyHandler struct {
+	next       http.Handler
+	cMap       *controllermap.ControllerMap
+	restMapper meta.RESTMapper
+}
+
+func (a *accessPolicyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rf := k8sRequest.RequestInfoFactory{APIPrefixes: sets.NewString("api", "apis"),
+		GrouplessAPIPrefixes: sets.NewString("api")}
+	r, err := rf.NewRequestInfo(req)
+	if err != nil {
+		m := "Could not convert request"
+		log.Error(err, m)
+		http.Error(w, m, http.StatusBadRequest)
+		return
+	}
+	if !r.IsResourceRequest {
+		a.next.ServeHTTP(w, req)
+		return
+	}
+
+	owner, err := getRequestOwnerRef(req)
+	if err != nil {
+		m := "Could not get owner reference"
+		log.Error(err, m)
+		http.Error(w, m, http.StatusInternalServerError)
+		return
+	}
+	if owner == nil {
+		if a.cMap.HasAccessPolicy() {
+			log.Info("Request without owner denied by access policies", "verb", r.Verb, "resource", r.Resource)
+			a.forbid(w, r, fmt.Errorf("requests without an owner are not allowed by access policies"))
+			return
+		}
+		a.next.ServeHTTP(w, req)
+		return
+	}
+	ownerGV, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		m := fmt.Sprintf("could not get group version for: %v", owner)
+		log.Error(err, m)
+		http.Error(w, m, http.StatusBadRequest)
+		return
+	}
+	ownerGVK := ownerGV.WithKind(owner.Kind)
+	contents, ok := a.cMap.Get(ownerGVK)
+	if !ok && a.cMap.HasAccessPolicy() {
+		log.Info("Request from unwatched owner denied by access policies", "ownerKind", ownerGVK, "verb", r.Verb)
+		a.forbid(w, r, fmt.Errorf("requests from %s are not allowed by access policies", ownerGVK))
+		return
+	}
+	if !ok || contents.AccessPolicy == nil {
+		a.next.ServeHTTP(w, req)
+		return
+	}
+
+	k, err := getGVKFromRequestInfo(r, a.restMapper)
+	if err != nil {
+		log.Error(err, "Could not determine kind of request", "ownerKind", ownerGVK)
+		a.forbid(w, r, fmt.Errorf("access policy of %s could not be checked: %v", ownerGVK, err))
+		return
+	}
+	// The playbook or role may always manage the resources it reconciles.
+	if k.GroupKind() == ownerGVK.GroupKind() || contents.AccessPolicy.Allows(r.Verb, k) {
+		a.next.ServeHTTP(w, req)
+		return
+	}
+	log.Info("Request denied by access policy", "ownerKind", ownerGVK, "verb", r.Verb, "kind", k)
+	a.forbid(w, r, fmt.Errorf("access policy of %s does not allow %q on %s", ownerGVK, r.Verb, k))
+}
+
+// forbid responds with a Forbidden status, so that clients report err as the
+// reason the request failed.
+func (a *accessPolicyHandler) forbid(w http.ResponseWriter, r *k8sRequest.RequestInfo, err error) {
+	writeStatus(w, apierrors.NewForbidden(schema.GroupResource{Group: r.APIGroup, Resource: r.Resource}, r.Name, err))
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/proxy/controllermap"
+	"github.com/operator-framework/operator-sdk/internal/ansible/proxy/kubeconfig"
+	"github.com/operator-framework/operator-sdk/internal/ansible/watches"
+)
+
+func TestAccessPolicyHandler(t *testing.T) {
+	restricted := schema.GroupVersionKind{Group: "app.example.com", Version: "v1alpha1", Kind: "Restricted"}
+	open := schema.GroupVersionKind{Group: "app.example.com", Version: "v1alpha1", Kind: "Open"}
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	unwatched := schema.GroupVersionKind{Group: "app.example.com", Version: "v1alpha1", Kind: "Unwatched"}
+
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range []schema.GroupVersionKind{restricted, open, deployment, configMap} {
+		restMapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+
+	cMap := controllermap.NewControllerMap()
+	cMap.Store(restricted, &controllermap.Contents{AccessPolicy: &watches.AccessPolicy{Rules: []watches.AccessRule{
+		{Group: "apps", Kind: "Deployment", Verbs: []string{"get", "list", "watch"}},
+	}}}, nil)
+	cMap.Store(open, &controllermap.Contents{}, nil)
+
+	h := &accessPolicyHandler{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+		cMap:       cMap,
+		restMapper: restMapper,
+	}
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		owner  *schema.GroupVersionKind
+		status int
+	}{
+		{"allowed verb", http.MethodGet, "/apis/apps/v1/namespaces/default/deployments/foo", &restricted, http.StatusOK},
+		{"watch", http.MethodGet, "/apis/apps/v1/namespaces/default/deployments?watch=true", &restricted, http.StatusOK},
+		{"denied verb", http.MethodDelete, "/apis/apps/v1/namespaces/default/deployments/foo", &restricted, http.StatusForbidden},
+		{"denied kind", http.MethodGet, "/api/v1/namespaces/default/configmaps/foo", &restricted, http.StatusForbidden},
+		{"unknown kind", http.MethodGet, "/apis/batch/v1/namespaces/default/jobs/foo", &restricted, http.StatusForbidden},
+		{"own kind", http.MethodPut, "/apis/app.example.com/v1alpha1/namespaces/default/restricteds/foo/status", &restricted, http.StatusOK},
+		{"other watch kind", http.MethodDelete, "/apis/app.example.com/v1alpha1/namespaces/default/opens/foo", &restricted, http.StatusForbidden},
+		{"non-resource request", http.MethodGet, "/apis/apps/v1", &restricted, http.StatusOK},
+		{"watch without policy", http.MethodDelete, "/api/v1/namespaces/default/configmaps/foo", &open, http.StatusOK},
+		{"no owner", http.MethodDelete, "/api/v1/namespaces/default/configmaps/foo", nil, http.StatusForbidden},
+		{"unwatched owner", http.MethodDelete, "/api/v1/namespaces/default/configmaps/foo", &unwatched, http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.owner != nil {
+				setOwner(t, req, *tc.owner)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("Expected status %d, got %d: %s", tc.status, rec.Code, rec.Body)
+			}
+			if rec.Code != http.StatusForbidden {
+				return
+			}
+			status := metav1.Status{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+				t.Fatalf("Expected a Status response: %v", err)
+			}
+			message := "requests without an owner"
+			if tc.owner != nil {
+				message = "access policy of " + tc.owner.String()
+				if *tc.owner == unwatched {
+					message = "requests from " + tc.owner.String()
+				}
+			}
+			if status.Reason != metav1.StatusReasonForbidden || !strings.Contains(status.Message, message) {
+				t.Errorf("Unexpected status: %+v", status)
+			}
+		})
+	}
+}
+
+func TestAccessPolicyHandlerWithoutPolicies(t *testing.T) {
+	open := schema.GroupVersionKind{Group: "app.example.com", Version: "v1alpha1", Kind: "Open"}
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	restMapper.Add(configMap, meta.RESTScopeNamespace)
+	cMap := controllermap.NewControllerMap()
+	cMap.Store(open, &controllermap.Contents{}, nil)
+
+	h := &accessPolicyHandler{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+		cMap:       cMap,
+		restMapper: restMapper,
+	}
+
+	// Requests without an owner are allowed if no watch has a policy.
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/namespaces/default/configmaps/foo", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+}
+
+// setOwner sets the authorization header that a playbook run by the operator
+// for a CR of kind gvk would send.
+func setOwner(t *testing.T, req *http.Request, gvk schema.GroupVersionKind) {
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	owner := kubeconfig.NamespacedOwnerReference{
+		OwnerReference: metav1.OwnerReference{APIVersion: apiVersion, Kind: kind, Name: "foo"},
+		Namespace:      "default",
+	}
+	b, err := json.Marshal(owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth(base64.URLEncoding.EncodeToString(b), "unused")
+}
@@ -0,0 +1,374 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+
+	k8sRequest "github.com/operator-framework/operator-sdk/internal/ansible/proxy/requestfactory"
+)
+
+// Names of the audit sinks.
+const (
+	FileAuditSinkName       = "file"
+	KubeEventsAuditSinkName = "kubernetes-events"
+)
+
+// AuditReason is the reason of the Kubernetes Events created by the
+// kubernetes-events audit sink.
+const AuditReason = "ProxyRequest"
+
+// mutatingVerbs are the verbs of the requests that are audited.
+var mutatingVerbs = sets.NewString("create", "update", "patch", "delete", "deletecollection")
+
+// AuditRecord describes a mutating request made through the proxy by a
+// playbook or role.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Job is the ident of the runner job that made the request.
+	Job   string      `json:"job,omitempty"`
+	Owner AuditObject `json:"owner"`
+	Verb  string      `json:"verb"`
+	// Object is the object the request was made for. Its name is empty for
+	// requests on a collection.
+	Object AuditObject `json:"object"`
+	// Code is the HTTP status code of the response.
+	Code int `json:"code"`
+	// Body is the body of create, update and patch requests, which is the
+	// patch applied for the latter, if request bodies are audited.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// AuditObject identifies an object in an AuditRecord.
+type AuditObject struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Resource   string `json:"resource,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	UID        string `json:"uid,omitempty"`
+}
+
+// AuditSink receives an AuditRecord for every audited request.
+type AuditSink interface {
+	Audit(AuditRecord)
+}
+
+// AuditSinkOptions - configuration shared by all audit sinks. Each sink only
+// reads the options relevant to it.
+type AuditSinkOptions struct {
+	// FilePath is the file that JSON-lines records are appended to.
+	FilePath string
+	// FileMaxSize is the size in bytes above which the file is rotated.
+	// Zero disables rotation.
+	FileMaxSize int64
+	// FileMaxBackups is the number of rotated files that are kept.
+	FileMaxBackups int
+	// Recorder is used to create Kubernetes Events.
+	Recorder record.EventRecorder
+}
+
+// NewAuditSinks - creates the audit sinks with the given names.
+func NewAuditSinks(names []string, opts AuditSinkOptions) ([]AuditSink, error) {
+	sinks := []AuditSink{}
+	for _, name := range names {
+		var sink AuditSink
+		var err error
+		switch name {
+		case FileAuditSinkName:
+			sink, err = NewFileAuditSink(opts)
+		case KubeEventsAuditSinkName:
+			sink, err = NewKubeEventsAuditSink(opts)
+		default:
+			return nil, fmt.Errorf("unknown audit sink %q, must be one of %v", name,
+				[]string{FileAuditSinkName, KubeEventsAuditSinkName})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error creating audit sink %q: %w", name, err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+type fileAuditSink struct {
+	mux     *sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewFileAuditSink - Creates an AuditSink that appends every record to
+// opts.FilePath as a line of JSON, rotating the file when it grows larger
+// than opts.FileMaxSize.
+func NewFileAuditSink(opts AuditSinkOptions) (AuditSink, error) {
+	if opts.FilePath == "" {
+		return nil, errors.New("a file path must be set")
+	}
+	f, err := newRotatingFile(opts.FilePath, opts.FileMaxSize, opts.FileMaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	return fileAuditSink{
+		mux:     &sync.Mutex{},
+		encoder: json.NewEncoder(f),
+	}, nil
+}
+
+func (s fileAuditSink) Audit(r AuditRecord) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if err := s.encoder.Encode(r); err != nil {
+		log.Error(err, "Failed to write audit record", "job", r.Job)
+	}
+}
+
+// rotatingFile appends to a file, which is renamed to <path>.1 once it would
+// grow larger than maxSize. Older files are renamed to <path>.2 and so on,
+// up to maxBackups files.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+func newRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	backup := func(i int) string { return fmt.Sprintf("%s.%d", r.path, i) }
+	if err := os.Remove(backup(r.maxBackups)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := r.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(backup(i), backup(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if r.maxBackups > 0 {
+		if err := os.Rename(r.path, backup(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+type kubeEventsAuditSink struct {
+	recorder record.EventRecorder
+}
+
+// NewKubeEventsAuditSink - Creates an AuditSink that records every request as
+// a Kubernetes Event on the CR whose reconciliation made it.
+func NewKubeEventsAuditSink(opts AuditSinkOptions) (AuditSink, error) {
+	if opts.Recorder == nil {
+		return nil, errors.New("an event recorder must be set")
+	}
+	return kubeEventsAuditSink{recorder: opts.Recorder}, nil
+}
+
+func (s kubeEventsAuditSink) Audit(r AuditRecord) {
+	owner := &unstructured.Unstructured{}
+	owner.SetAPIVersion(r.Owner.APIVersion)
+	owner.SetKind(r.Owner.Kind)
+	owner.SetNamespace(r.Owner.Namespace)
+	owner.SetName(r.Owner.Name)
+	owner.SetUID(types.UID(r.Owner.UID))
+
+	eventType := corev1.EventTypeNormal
+	if r.Code >= http.StatusBadRequest {
+		eventType = corev1.EventTypeWarning
+	}
+	object := r.Object.Resource
+	if r.Object.Kind != "" {
+		object = fmt.Sprintf("%s %s", r.Object.APIVersion, r.Object.Kind)
+	}
+	if r.Object.Name != "" {
+		object = fmt.Sprintf("%s %q", object, r.Object.Name)
+	}
+	if r.Object.Namespace != "" {
+		object = fmt.Sprintf("%s in namespace %q", object, r.Object.Namespace)
+	}
+	s.recorder.Eventf(owner, eventType, AuditReason, "Job %s: %s %s: %d %s",
+		r.Job, r.Verb, object, r.Code, http.StatusText(r.Code))
+}
+
+// auditHandler sends an AuditRecord to every sink for each mutating request
+// made for an owner.
+type auditHandler struct {
+	next          http.Handler
+	sinks         []AuditSink
+	restMapper    meta.RESTMapper
+	requestBodies bool
+}
+
+func (a *auditHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rf := k8sRequest.RequestInfoFactory{APIPrefixes: sets.NewString("api", "apis"),
+		GrouplessAPIPrefixes: sets.NewString("api")}
+	r, err := rf.NewRequestInfo(req)
+	if err != nil || !r.IsResourceRequest || !mutatingVerbs.Has(r.Verb) {
+		a.next.ServeHTTP(w, req)
+		return
+	}
+	owner, err := getRequestOwnerRef(req)
+	if err != nil || owner == nil {
+		a.next.ServeHTTP(w, req)
+		return
+	}
+
+	record := AuditRecord{
+		Time: time.Now().UTC(),
+		Job:  owner.Job,
+		Owner: AuditObject{
+			APIVersion: owner.APIVersion,
+			Kind:       owner.Kind,
+			Namespace:  owner.Namespace,
+			Name:       owner.Name,
+			UID:        string(owner.UID),
+		},
+		Verb: r.Verb,
+		Object: AuditObject{
+			Resource:  r.Resource,
+			Namespace: r.Namespace,
+			Name:      r.Name,
+		},
+	}
+	if k, err := getGVKFromRequestInfo(r, a.restMapper); err == nil {
+		record.Object.APIVersion, record.Object.Kind = k.ToAPIVersionAndKind()
+	}
+	if r.Verb == "create" || r.Verb == "update" || r.Verb == "patch" {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			log.Error(err, "Could not read request body")
+		}
+		req.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+		if r.Verb == "create" && record.Object.Name == "" {
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON(body); err == nil {
+				record.Object.Name = obj.GetName()
+			}
+		}
+		if a.requestBodies && json.Valid(body) {
+			record.Body = body
+		}
+	}
+
+	sw := &statusResponseWriter{ResponseWriter: w}
+	a.next.ServeHTTP(sw, req)
+	record.Code = sw.status()
+	for _, sink := range a.sinks {
+		sink.Audit(record)
+	}
+}
+
+// statusResponseWriter records the status code of a response.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack supports upgraded connections, such as those of pod exec requests.
+func (w *statusResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	if w.code == 0 {
+		w.code = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+func (w *statusResponseWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
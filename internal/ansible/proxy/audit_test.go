@@ -0,0 +1,232 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/proxy/kubeconfig"
+)
+
+type fakeAuditSink struct {
+	records []AuditRecord
+}
+
+func (s *fakeAuditSink) Audit(r AuditRecord) {
+	s.records = append(s.records, r)
+}
+
+func TestAuditHandler(t *testing.T) {
+	secret := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	restMapper := meta.NewDefaultRESTMapper(nil)
+	restMapper.Add(secret, meta.RESTScopeNamespace)
+
+	owner := kubeconfig.NamespacedOwnerReference{
+		OwnerReference: metav1.OwnerReference{APIVersion: "app.example.com/v1alpha1", Kind: "Memcached", Name: "foo", UID: "123"},
+		Namespace:      "default",
+		Job:            "42",
+	}
+	body := `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"bar"}}`
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		body          string
+		owner         bool
+		requestBodies bool
+		status        int
+		expected      *AuditRecord
+	}{
+		{
+			name: "delete", method: http.MethodDelete, path: "/api/v1/namespaces/default/secrets/bar",
+			owner: true, status: http.StatusForbidden,
+			expected: &AuditRecord{Verb: "delete", Code: http.StatusForbidden, Object: AuditObject{
+				APIVersion: "v1", Kind: "Secret", Resource: "secrets", Namespace: "default", Name: "bar"}},
+		},
+		{
+			name: "create", method: http.MethodPost, path: "/api/v1/namespaces/default/secrets", body: body,
+			owner: true, status: http.StatusCreated,
+			expected: &AuditRecord{Verb: "create", Code: http.StatusCreated, Object: AuditObject{
+				APIVersion: "v1", Kind: "Secret", Resource: "secrets", Namespace: "default", Name: "bar"}},
+		},
+		{
+			name: "create with body", method: http.MethodPost, path: "/api/v1/namespaces/default/secrets", body: body,
+			owner: true, requestBodies: true, status: http.StatusCreated,
+			expected: &AuditRecord{Verb: "create", Code: http.StatusCreated, Body: json.RawMessage(body), Object: AuditObject{
+				APIVersion: "v1", Kind: "Secret", Resource: "secrets", Namespace: "default", Name: "bar"}},
+		},
+		{
+			name: "unknown kind", method: http.MethodDelete, path: "/apis/batch/v1/namespaces/default/jobs",
+			owner: true, status: http.StatusOK,
+			expected: &AuditRecord{Verb: "deletecollection", Code: http.StatusOK, Object: AuditObject{
+				Resource: "jobs", Namespace: "default"}},
+		},
+		{name: "get", method: http.MethodGet, path: "/api/v1/namespaces/default/secrets/bar", owner: true, status: http.StatusOK},
+		{name: "no owner", method: http.MethodDelete, path: "/api/v1/namespaces/default/secrets/bar", status: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sink := &fakeAuditSink{}
+			h := &auditHandler{
+				next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					// The body must still be readable by the next handler.
+					if b, _ := ioutil.ReadAll(req.Body); string(b) != tc.body {
+						t.Errorf("Expected body %q, got %q", tc.body, b)
+					}
+					w.WriteHeader(tc.status)
+				}),
+				sinks:         []AuditSink{sink},
+				restMapper:    restMapper,
+				requestBodies: tc.requestBodies,
+			}
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.owner {
+				req = withOwner(req, owner)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("Expected status %d, got %d", tc.status, rec.Code)
+			}
+
+			if tc.expected == nil {
+				if len(sink.records) != 0 {
+					t.Errorf("Expected no audit records, got %+v", sink.records)
+				}
+				return
+			}
+			if len(sink.records) != 1 {
+				t.Fatalf("Expected one audit record, got %+v", sink.records)
+			}
+			got := sink.records[0]
+			if got.Time.IsZero() {
+				t.Error("Expected the record time to be set")
+			}
+			expected := *tc.expected
+			expected.Time = got.Time
+			expected.Job = "42"
+			expected.Owner = AuditObject{APIVersion: "app.example.com/v1alpha1", Kind: "Memcached",
+				Namespace: "default", Name: "foo", UID: "123"}
+			if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", expected) {
+				t.Errorf("Expected record\n%+v\ngot\n%+v", expected, got)
+			}
+		})
+	}
+}
+
+func TestFileAuditSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit", "audit.jsonl")
+
+	sink, err := NewFileAuditSink(AuditSinkOptions{FilePath: path, FileMaxSize: 300, FileMaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		sink.Audit(AuditRecord{Job: fmt.Sprint(i), Verb: "delete", Code: http.StatusOK})
+	}
+
+	var jobs []string
+	for _, name := range []string{path + ".2", path + ".1", path} {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) > 300 {
+			t.Errorf("Expected %s to be rotated, it is %d bytes", name, len(b))
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+			r := AuditRecord{}
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatal(err)
+			}
+			jobs = append(jobs, r.Job)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected only 2 backups to be kept, got %v", err)
+	}
+	// The newest records must be kept in order.
+	if len(jobs) == 0 || jobs[len(jobs)-1] != "9" {
+		t.Fatalf("Expected the last record to be kept, got %v", jobs)
+	}
+	for i := 1; i < len(jobs); i++ {
+		if jobs[i-1] >= jobs[i] {
+			t.Errorf("Expected records in order, got %v", jobs)
+		}
+	}
+}
+
+func TestKubeEventsAuditSink(t *testing.T) {
+	recorder := record.NewFakeRecorder(2)
+	sink, err := NewKubeEventsAuditSink(AuditSinkOptions{Recorder: recorder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := AuditObject{APIVersion: "app.example.com/v1alpha1", Kind: "Memcached", Namespace: "default", Name: "foo"}
+	sink.Audit(AuditRecord{Job: "42", Owner: owner, Verb: "delete", Code: http.StatusOK,
+		Object: AuditObject{APIVersion: "v1", Kind: "Secret", Resource: "secrets", Namespace: "default", Name: "bar"}})
+	sink.Audit(AuditRecord{Job: "42", Owner: owner, Verb: "create", Code: http.StatusForbidden,
+		Object: AuditObject{Resource: "jobs", Namespace: "default"}})
+
+	for _, expected := range []string{
+		`Normal ProxyRequest Job 42: delete v1 Secret "bar" in namespace "default": 200 OK`,
+		`Warning ProxyRequest Job 42: create jobs in namespace "default": 403 Forbidden`,
+	} {
+		if got := <-recorder.Events; got != expected {
+			t.Errorf("Expected event %q, got %q", expected, got)
+		}
+	}
+
+	if _, err := NewAuditSinks([]string{"unknown"}, AuditSinkOptions{}); err == nil {
+		t.Error("Expected an error for an unknown sink")
+	}
+}
+
+// withOwner returns req with owner authenticated, as authenticateOwner would.
+func withOwner(req *http.Request, owner kubeconfig.NamespacedOwnerReference) *http.Request {
+	signer, err := kubeconfig.NewTokenSigner()
+	if err != nil {
+		panic(err)
+	}
+	token, err := signer.Sign(owner, 0)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	var authenticated *http.Request
+	authenticateOwner(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		authenticated = r
+	}), signer).ServeHTTP(httptest.NewRecorder(), req)
+	return authenticated
+}
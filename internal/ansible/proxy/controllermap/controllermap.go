@@ -19,6 +19,8 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/watches"
 )
 
 // ControllerMap - map of GVK to ControllerMapContents
@@ -41,6 +43,9 @@ type Contents struct {
 	OwnerWatchMap               *WatchMap
 	AnnotationWatchMap          *WatchMap
 	Blacklist                   map[schema.GroupVersionKind]bool
+	// AccessPolicy, if set, restricts the requests the controller's playbook
+	// or role may make through the proxy.
+	AccessPolicy *watches.AccessPolicy
 }
 
 // NewControllerMap returns a new object that contains a mapping between GVK
@@ -68,6 +73,19 @@ func (cm *ControllerMap) Get(key schema.GroupVersionKind) (value *Contents, ok b
 	return value, ok
 }
 
+// HasAccessPolicy - Returns whether any controller in the ControllerMap has an
+// access policy
+func (cm *ControllerMap) HasAccessPolicy() bool {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+	for _, contents := range cm.internal {
+		if contents.AccessPolicy != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // Delete - Deletes associated GVK to controller mapping from the ControllerMap
 func (cm *ControllerMap) Delete(key schema.GroupVersionKind) {
 	cm.mutex.Lock()
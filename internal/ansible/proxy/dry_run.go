@@ -0,0 +1,65 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	k8sRequest "github.com/operator-framework/operator-sdk/internal/ansible/proxy/requestfactory"
+)
+
+// dryRunHandler rejects mutating requests made by jobs running in check mode,
+// unless they are server-side dry runs.
+type dryRunHandler struct {
+	next http.Handler
+}
+
+func (d *dryRunHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	owner, err := getRequestOwnerRef(req)
+	if err != nil || owner == nil || !owner.DryRun {
+		d.next.ServeHTTP(w, req)
+		return
+	}
+	rf := k8sRequest.RequestInfoFactory{APIPrefixes: sets.NewString("api", "apis"),
+		GrouplessAPIPrefixes: sets.NewString("api")}
+	r, err := rf.NewRequestInfo(req)
+	if err != nil {
+		m := "Could not convert request"
+		log.Error(err, m)
+		http.Error(w, m, http.StatusBadRequest)
+		return
+	}
+	if !r.IsResourceRequest || !mutatingVerbs.Has(r.Verb) || isServerDryRun(req) {
+		d.next.ServeHTTP(w, req)
+		return
+	}
+
+	log.Info("Request denied for dry run", "job", owner.Job, "verb", r.Verb, "resource", r.Resource, "name", r.Name)
+	err = fmt.Errorf("job %s is a dry run and may not %s %s", owner.Job, r.Verb, r.Resource)
+	writeStatus(w, apierrors.NewForbidden(schema.GroupResource{Group: r.APIGroup, Resource: r.Resource}, r.Name, err))
+}
+
+// isServerDryRun returns true if req asks the API server not to persist any
+// changes.
+func isServerDryRun(req *http.Request) bool {
+	dryRun := req.URL.Query()["dryRun"]
+	return len(dryRun) > 0 && sets.NewString(dryRun...).Has(metav1.DryRunAll)
+}
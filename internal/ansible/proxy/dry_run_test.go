@@ -0,0 +1,68 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/proxy/kubeconfig"
+)
+
+func TestDryRunHandler(t *testing.T) {
+	h := &dryRunHandler{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	}
+	owner := kubeconfig.NamespacedOwnerReference{
+		OwnerReference: metav1.OwnerReference{APIVersion: "app.example.com/v1alpha1", Kind: "Memcached", Name: "foo"},
+		Namespace:      "default",
+		Job:            "42",
+	}
+	dryRunOwner := owner
+	dryRunOwner.DryRun = true
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		owner  *kubeconfig.NamespacedOwnerReference
+		status int
+	}{
+		{"get", http.MethodGet, "/api/v1/namespaces/default/secrets/bar", &dryRunOwner, http.StatusOK},
+		{"delete", http.MethodDelete, "/api/v1/namespaces/default/secrets/bar", &dryRunOwner, http.StatusForbidden},
+		{"create", http.MethodPost, "/api/v1/namespaces/default/secrets", &dryRunOwner, http.StatusForbidden},
+		{"server dry run", http.MethodPost, "/api/v1/namespaces/default/secrets?dryRun=All", &dryRunOwner, http.StatusOK},
+		{"not a dry run", http.MethodDelete, "/api/v1/namespaces/default/secrets/bar", &owner, http.StatusOK},
+		{"no owner", http.MethodDelete, "/api/v1/namespaces/default/secrets/bar", nil, http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.owner != nil {
+				req = withOwner(req, *tc.owner)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("Expected status %d, got %d: %s", tc.status, rec.Code, rec.Body)
+			}
+		})
+	}
+}
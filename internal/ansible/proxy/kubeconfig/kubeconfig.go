@@ -19,10 +19,11 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
-	"html/template"
 	"io/ioutil"
 	"net/url"
 	"os"
+	"text/template"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -39,7 +40,11 @@ apiVersion: v1
 kind: Config
 clusters:
 - cluster:
+{{- if .CAData }}
+    certificate-authority-data: {{.CAData}}
+{{- else }}
     insecure-skip-tls-verify: true
+{{- end }}
     server: {{.ProxyURL}}
   name: proxy-server
 contexts:
@@ -52,40 +57,89 @@ preferences: {}
 users:
 - name: admin/proxy-server
   user:
+{{- if .Token }}
+    token: {{.Token}}
+{{- else }}
     username: {{.Username}}
     password: unused
+{{- end }}
 `
 
 // values holds the data used to render the template
 type values struct {
 	Username  string
+	Token     string
 	ProxyURL  string
+	CAData    string
 	Namespace string
 }
 
+// DefaultProxyURL is the URL of the proxy when it serves plain HTTP.
+const DefaultProxyURL = "http://localhost:8888"
+
+// TokenExpiryMargin is how long after the run timeout the token of a run
+// expires, to allow for the time taken to start the run.
+const TokenExpiryMargin = 5 * time.Minute
+
+// Proxy - Describes how playbooks connect to the proxy and identify the owner
+// they run for.
+type Proxy struct {
+	// URL of the proxy, DefaultProxyURL if empty.
+	URL string
+	// CAData is the PEM encoded CA certificate of the proxy, for https URLs.
+	CAData []byte
+	// Signer, if set, signs a token identifying the owner. Otherwise the owner
+	// is sent unsigned as the basic auth username.
+	Signer *TokenSigner
+}
+
 type NamespacedOwnerReference struct {
 	metav1.OwnerReference
 	Namespace string
+	// Job is the ident of the runner job the kubeconfig was created for.
+	Job string `json:",omitempty"`
+	// DryRun is true if the job runs in check mode and must not make changes.
+	DryRun bool `json:",omitempty"`
 }
 
 // Create renders a kubeconfig template and writes it to disk
 func Create(ownerRef metav1.OwnerReference, proxyURL string, namespace string) (*os.File, error) {
-	nsOwnerRef := NamespacedOwnerReference{OwnerReference: ownerRef, Namespace: namespace}
-	parsedURL, err := url.Parse(proxyURL)
-	if err != nil {
-		return nil, err
+	return Proxy{URL: proxyURL}.Create(NamespacedOwnerReference{OwnerReference: ownerRef, Namespace: namespace}, 0)
+}
+
+// Create renders a kubeconfig for a playbook run for owner and writes it to
+// disk. If the owner is identified by a signed token, the token expires
+// TokenExpiryMargin after runTimeout, unless runTimeout is zero, and must be
+// revoked with Revoke once the run has finished.
+func (p Proxy) Create(nsOwnerRef NamespacedOwnerReference, runTimeout time.Duration) (*os.File, error) {
+	if p.URL == "" {
+		p.URL = DefaultProxyURL
 	}
-	ownerRefJSON, err := json.Marshal(nsOwnerRef)
+	parsedURL, err := url.Parse(p.URL)
 	if err != nil {
 		return nil, err
 	}
-	username := base64.URLEncoding.EncodeToString(ownerRefJSON)
-	parsedURL.User = url.User(username)
 	v := values{
-		Username:  username,
-		ProxyURL:  parsedURL.String(),
-		Namespace: namespace,
+		Namespace: nsOwnerRef.Namespace,
+		CAData:    base64.StdEncoding.EncodeToString(p.CAData),
 	}
+	if p.Signer != nil {
+		ttl := time.Duration(0)
+		if runTimeout > 0 {
+			ttl = runTimeout + TokenExpiryMargin
+		}
+		if v.Token, err = p.Signer.Sign(nsOwnerRef, ttl); err != nil {
+			return nil, err
+		}
+	} else {
+		ownerRefJSON, err := json.Marshal(nsOwnerRef)
+		if err != nil {
+			return nil, err
+		}
+		v.Username = base64.URLEncoding.EncodeToString(ownerRefJSON)
+		parsedURL.User = url.User(v.Username)
+	}
+	v.ProxyURL = parsedURL.String()
 
 	var parsed bytes.Buffer
 
@@ -115,3 +169,11 @@ func Create(ownerRef metav1.OwnerReference, proxyURL string, namespace string) (
 	}
 	return file, nil
 }
+
+// Revoke rejects the token of the run for owner from now on, if owners are
+// identified by signed tokens.
+func (p Proxy) Revoke(nsOwnerRef NamespacedOwnerReference) {
+	if p.Signer != nil {
+		p.Signer.Revoke(nsOwnerRef.Job)
+	}
+}
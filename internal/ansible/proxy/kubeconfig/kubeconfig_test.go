@@ -0,0 +1,185 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfig
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var testOwner = metav1.OwnerReference{APIVersion: "app.example.com/v1alpha1", Kind: "Memcached", Name: "foo", UID: "123"}
+
+func TestTokenSigner(t *testing.T) {
+	signer, err := NewTokenSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := NamespacedOwnerReference{OwnerReference: testOwner, Namespace: "default"}
+	token, err := signer.Sign(owner, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := signer.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != owner {
+		t.Errorf("Expected owner %+v, got %+v", owner, *got)
+	}
+
+	other, err := signer.Sign(NamespacedOwnerReference{OwnerReference: testOwner, Namespace: "other"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSigner, err := NewTokenSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherToken, err := otherSigner.Sign(owner, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, sig := strings.Split(token, ".")[0], strings.Split(token, ".")[1]
+	otherPayload := strings.Split(other, ".")[0]
+	for name, invalid := range map[string]string{
+		"empty":             "",
+		"unsigned":          payload,
+		"changed payload":   otherPayload + "." + sig,
+		"other signer":      otherToken,
+		"basic auth string": "dXNlcg==",
+	} {
+		if _, err := signer.Verify(invalid); err != ErrInvalidToken {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestTokenSignerExpiry(t *testing.T) {
+	signer, err := NewTokenSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	signer.now = func() time.Time { return now }
+	token, err := signer.Sign(NamespacedOwnerReference{OwnerReference: testOwner, Job: "1"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.Verify(token); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := signer.Verify(token); err != ErrExpiredToken {
+		t.Errorf("Expected ErrExpiredToken, got %v", err)
+	}
+
+	// Runs whose tokens have expired are forgotten once another is signed.
+	if _, err := signer.Sign(NamespacedOwnerReference{OwnerReference: testOwner, Job: "2"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := signer.runs["1"]; ok {
+		t.Error("Expected the expired run to be forgotten")
+	}
+}
+
+func TestTokenSignerRevoke(t *testing.T) {
+	signer, err := NewTokenSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := signer.Sign(NamespacedOwnerReference{OwnerReference: testOwner, Job: "1"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := signer.Sign(NamespacedOwnerReference{OwnerReference: testOwner, Job: "2"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer.Revoke("1")
+	if _, err := signer.Verify(token); err != ErrRevokedToken {
+		t.Errorf("Expected ErrRevokedToken, got %v", err)
+	}
+	if _, err := signer.Verify(other); err != nil {
+		t.Errorf("Expected the token of another run to be valid, got %v", err)
+	}
+}
+
+func TestProxyCreate(t *testing.T) {
+	signer, err := NewTokenSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name     string
+		proxy    Proxy
+		server   string
+		insecure bool
+	}{
+		{"default", Proxy{}, "http://", true},
+		{"signed", Proxy{Signer: signer}, DefaultProxyURL, true},
+		{"tls", Proxy{URL: "https://localhost:8888", CAData: []byte("ca+/="), Signer: signer}, "https://localhost:8888", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nsOwnerRef := NamespacedOwnerReference{OwnerReference: testOwner, Namespace: "default", Job: "42"}
+			f, err := tc.proxy.Create(nsOwnerRef, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+
+			cfg, err := clientcmd.LoadFromFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			cluster := cfg.Clusters["proxy-server"]
+			user := cfg.AuthInfos["admin/proxy-server"]
+			if !strings.HasPrefix(cluster.Server, tc.server) || cluster.InsecureSkipTLSVerify != tc.insecure ||
+				string(cluster.CertificateAuthorityData) != string(tc.proxy.CAData) {
+				t.Errorf("Unexpected cluster: %+v", cluster)
+			}
+
+			if tc.proxy.Signer == nil {
+				if user.Token != "" || user.Username == "" {
+					t.Errorf("Expected a basic auth user, got %+v", user)
+				}
+				return
+			}
+			if user.Username != "" {
+				t.Errorf("Expected no basic auth username, got %q", user.Username)
+			}
+			owner, err := signer.Verify(user.Token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owner.OwnerReference != testOwner || owner.Namespace != "default" || owner.Job != "42" {
+				t.Errorf("Unexpected owner: %+v", owner)
+			}
+
+			tc.proxy.Revoke(nsOwnerRef)
+			if _, err := signer.Verify(user.Token); err != ErrRevokedToken {
+				t.Errorf("Expected the token to be revoked, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfig
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned when a token was not signed by a TokenSigner.
+	ErrInvalidToken = errors.New("invalid owner token")
+	// ErrExpiredToken is returned when a token has expired.
+	ErrExpiredToken = errors.New("owner token has expired")
+	// ErrRevokedToken is returned when a token is for a run that has finished.
+	ErrRevokedToken = errors.New("owner token is for a run that has finished")
+)
+
+// TokenSigner - Signs and verifies tokens that identify the owner a playbook
+// runs for, so that the owner cannot be changed by whoever holds a token.
+// Tokens are bound to the run they were signed for, identified by the Job of
+// the owner, and are rejected once it is revoked or they expire.
+type TokenSigner struct {
+	key []byte
+	now func() time.Time
+
+	mu sync.Mutex
+	// runs holds the expiry of the tokens of each run that has not been
+	// revoked, which is zero if they do not expire.
+	runs map[string]time.Time
+}
+
+// tokenClaims is the payload of a token.
+type tokenClaims struct {
+	NamespacedOwnerReference
+	// Expires is the Unix time the token expires at, if not zero.
+	Expires int64 `json:",omitempty"`
+}
+
+// NewTokenSigner returns a TokenSigner with a random key.
+func NewTokenSigner() (*TokenSigner, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &TokenSigner{key: key, now: time.Now, runs: map[string]time.Time{}}, nil
+}
+
+// Sign returns a token of the form <payload>.<signature>, where payload is
+// the base64 encoded JSON of owner and signature its HMAC-SHA256. The token is
+// valid for the run of owner.Job until it is revoked, and for at most ttl if
+// ttl is not zero.
+func (s *TokenSigner) Sign(owner NamespacedOwnerReference, ttl time.Duration) (string, error) {
+	now := s.now()
+	claims := tokenClaims{NamespacedOwnerReference: owner}
+	var expires time.Time
+	if ttl > 0 {
+		expires = now.Add(ttl)
+		claims.Expires = expires.Unix()
+	}
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Runs whose tokens have expired may not have been revoked, e.g. if
+	// reconciliation panicked, so they are forgotten here.
+	for run, exp := range s.runs {
+		if !exp.IsZero() && !now.Before(exp) {
+			delete(s.runs, run)
+		}
+	}
+	s.runs[owner.Job] = expires
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + s.signature(payload), nil
+}
+
+// Revoke rejects the tokens of the run identified by job from now on.
+func (s *TokenSigner) Revoke(job string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.runs, job)
+}
+
+// Verify returns the owner identified by token. It returns ErrInvalidToken if
+// token was not signed by s, ErrExpiredToken if token has expired, and
+// ErrRevokedToken if the run token was signed for has been revoked.
+func (s *TokenSigner) Verify(token string) (*NamespacedOwnerReference, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(s.signature(parts[0]))) {
+		return nil, ErrInvalidToken
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	claims := &tokenClaims{}
+	if err := json.Unmarshal(b, claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Expires != 0 && s.now().Unix() >= claims.Expires {
+		return nil, ErrExpiredToken
+	}
+	s.mu.Lock()
+	_, active := s.runs[claims.Job]
+	s.mu.Unlock()
+	if !active {
+		return nil, ErrRevokedToken
+	}
+	return &claims.NamespacedOwnerReference, nil
+}
+
+func (s *TokenSigner) signature(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -17,6 +17,7 @@ package proxy
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -29,6 +30,7 @@ import (
 
 	libhandler "github.com/operator-framework/operator-lib/handler"
 	"github.com/operator-framework/operator-lib/predicate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -75,8 +77,19 @@ type HandlerChain func(http.Handler) http.Handler
 // Options will be used by the user to specify the desired details
 // for the proxy.
 type Options struct {
-	Address           string
-	Port              int
+	Address string
+	Port    int
+	// TLSConfig, if set, serves HTTPS instead of HTTP.
+	TLSConfig *tls.Config
+	// Signer, if set, verifies the token identifying the owner of every
+	// request. Requests without a valid token are rejected.
+	Signer *kubeconfig.TokenSigner
+	// AuditSinks receive a record of every mutating request made for an owner.
+	AuditSinks []AuditSink
+	// AuditRequestBodies adds the bodies of create, update and patch
+	// requests to audit records.
+	AuditRequestBodies bool
+
 	Handler           HandlerChain
 	KubeConfig        *rest.Config
 	Cache             cache.Cache
@@ -180,10 +193,36 @@ func Run(done chan error, o Options) error {
 		}
 	}
 
+	// Check access policies before requests can be served from the cache.
+	server.Handler = &accessPolicyHandler{
+		next:       server.Handler,
+		cMap:       o.ControllerMap,
+		restMapper: o.RESTMapper,
+	}
+
+	// Jobs running in check mode must not make changes.
+	server.Handler = &dryRunHandler{next: server.Handler}
+
+	if len(o.AuditSinks) > 0 {
+		server.Handler = &auditHandler{
+			next:          server.Handler,
+			sinks:         o.AuditSinks,
+			restMapper:    o.RESTMapper,
+			requestBodies: o.AuditRequestBodies,
+		}
+	}
+
+	if o.Signer != nil {
+		server.Handler = authenticateOwner(server.Handler, o.Signer)
+	}
+
 	l, err := server.Listen(o.Address, o.Port)
 	if err != nil {
 		return err
 	}
+	if o.TLSConfig != nil {
+		l = tls.NewListener(l, o.TLSConfig)
+	}
 	go func() {
 		log.Info("Starting to serve", "Address", l.Addr().String())
 		done <- server.ServeOnListener(l)
@@ -287,8 +326,41 @@ func removeAuthorizationHeader(h http.Handler) http.Handler {
 	})
 }
 
+// ownerContextKey is the request context key of an authenticated owner.
+type ownerContextKey struct{}
+
+// authenticateOwner rejects requests without a bearer token signed by signer,
+// and adds the owner identified by the token to the request's context.
+func authenticateOwner(h http.Handler, signer *kubeconfig.TokenSigner) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		owner, err := signer.Verify(token)
+		if err != nil {
+			log.Info("Rejecting request without a valid owner token", "method", req.Method, "uri", req.RequestURI)
+			writeStatus(w, apierrors.NewUnauthorized(err.Error()))
+			return
+		}
+		req.Header.Del("Authorization")
+		h.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), ownerContextKey{}, owner)))
+	})
+}
+
+// writeStatus responds with the Kubernetes Status of err, so that clients
+// report it as the reason the request failed.
+func writeStatus(w http.ResponseWriter, err *apierrors.StatusError) {
+	status := err.Status()
+	status.APIVersion = "v1"
+	status.Kind = "Status"
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(status.Code))
+	_ = json.NewEncoder(w).Encode(status)
+}
+
 // Helper function used by recovering dependent watches and owner ref injection.
 func getRequestOwnerRef(req *http.Request) (*kubeconfig.NamespacedOwnerReference, error) {
+	if owner, ok := req.Context().Value(ownerContextKey{}).(*kubeconfig.NamespacedOwnerReference); ok {
+		return owner, nil
+	}
 	owner := kubeconfig.NamespacedOwnerReference{}
 	user, _, ok := req.BasicAuth()
 	if !ok {
@@ -0,0 +1,123 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/proxy/kubeconfig"
+)
+
+func TestNewSelfSignedTLSConfig(t *testing.T) {
+	tlsConfig, caPEM, err := NewSelfSignedTLSConfig("localhost", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = tlsConfig
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		t.Fatal("Could not parse CA certificate")
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected the server certificate to be trusted: %v", err)
+	}
+	resp.Body.Close()
+
+	// A client that does not trust the CA must fail the handshake.
+	if resp, err := http.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Error("Expected an untrusted certificate error")
+	}
+}
+
+func TestAuthenticateOwner(t *testing.T) {
+	signer, err := kubeconfig.NewTokenSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := kubeconfig.NamespacedOwnerReference{
+		OwnerReference: metav1.OwnerReference{APIVersion: "app.example.com/v1alpha1", Kind: "Memcached", Name: "foo"},
+		Namespace:      "default",
+	}
+	token, err := signer.Sign(owner, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *kubeconfig.NamespacedOwnerReference
+	h := authenticateOwner(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "" {
+			t.Error("Expected the Authorization header to be removed")
+		}
+		if got, err = getRequestOwnerRef(req); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}), signer)
+
+	testCases := []struct {
+		name   string
+		setup  func(*http.Request)
+		status int
+	}{
+		{"signed token", func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }, http.StatusOK},
+		{"no token", func(*http.Request) {}, http.StatusUnauthorized},
+		{"invalid token", func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token+"x") }, http.StatusUnauthorized},
+		{"basic auth owner", func(req *http.Request) {
+			setOwner(t, req, schema.GroupVersionKind{Group: "app.example.com", Version: "v1alpha1", Kind: "Memcached"})
+		}, http.StatusUnauthorized},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/configmaps", nil)
+			tc.setup(req)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("Expected status %d, got %d: %s", tc.status, rec.Code, rec.Body)
+			}
+			if rec.Code == http.StatusOK {
+				if got == nil || *got != owner {
+					t.Errorf("Expected owner %+v, got %+v", owner, got)
+				}
+				return
+			}
+			status := metav1.Status{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+				t.Fatalf("Expected a Status response: %v", err)
+			}
+			if status.Reason != metav1.StatusReasonUnauthorized || got != nil {
+				t.Errorf("Unexpected status %+v or owner %+v", status, got)
+			}
+		})
+	}
+}
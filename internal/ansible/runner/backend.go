@@ -0,0 +1,88 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/artifacts"
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+// Job - a run of ansible-runner for a CR, as launched by a Backend.
+type Job struct {
+	Ident string
+	// Object is the CR being reconciled.
+	Object *unstructured.Unstructured
+	// InputDir is the path of the prepared ansible-runner input directory.
+	InputDir string
+	// Cmd runs ansible-runner with the input directory.
+	Cmd *exec.Cmd
+	// Kubeconfig is the path of the kubeconfig that ansible uses to reach
+	// the proxy.
+	Kubeconfig string
+}
+
+// Backend - launches ansible-runner for a Job, and receives the events it
+// posts. The Runner prepares the input directory of each Job.
+type Backend interface {
+	// NewEventReceiver starts the receiver ansible-runner posts the events
+	// of the job with ident to. The receiver's server error is sent on
+	// errChan once it stops serving.
+	NewEventReceiver(ident string, errChan chan<- error) (*eventapi.EventReceiver, error)
+	// Run runs job and waits for it to exit, returning the output of
+	// ansible-runner. If ctx is done first, the job is stopped and ctx's
+	// error is returned.
+	Run(ctx context.Context, job Job) ([]byte, error)
+}
+
+// LocalBackend - runs ansible-runner as a child process of the operator, and
+// receives its events on a unix socket.
+type LocalBackend struct {
+	// Artifacts, if set, archives the artifacts of every run.
+	Artifacts *artifacts.Store
+}
+
+var _ Backend = LocalBackend{}
+
+func (LocalBackend) NewEventReceiver(ident string, errChan chan<- error) (*eventapi.EventReceiver, error) {
+	// This is the first step of every run, so fail it early if ansible-runner
+	// is not installed.
+	if _, err := exec.LookPath(ansibleRunnerBin); err != nil {
+		return nil, err
+	}
+	return eventapi.New(ident, errChan)
+}
+
+func (b LocalBackend) Run(ctx context.Context, job Job) ([]byte, error) {
+	dc := job.Cmd
+	// Append current environment since setting dc.Env to anything other than nil overwrites current env
+	dc.Env = append(dc.Env, os.Environ()...)
+	dc.Env = append(dc.Env, fmt.Sprintf("K8S_AUTH_KUBECONFIG=%s", job.Kubeconfig),
+		fmt.Sprintf("KUBECONFIG=%s", job.Kubeconfig))
+	output, err := runCmd(ctx, dc)
+	if b.Artifacts != nil {
+		// Archive before the next run of the CR can rotate the artifacts away.
+		if aerr := b.Artifacts.Archive(job.InputDir, job.Ident); aerr != nil {
+			log.Error(aerr, "Failed to archive artifacts", "job", job.Ident)
+		}
+	}
+	return output, err
+}
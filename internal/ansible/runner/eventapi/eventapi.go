@@ -15,6 +15,7 @@
 package eventapi
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -37,13 +38,22 @@ type EventReceiver struct {
 	// back to the runner, or whatever code is using this receiver.
 	Events chan JobEvent
 
-	// SocketPath is the path on the filesystem to a unix streaming socket
+	// SocketPath is the path on the filesystem to a unix streaming socket.
+	// It is empty if the receiver listens on a TCP socket.
 	SocketPath string
 
+	// RunnerURL is the value of ansible-runner's runner_http_url setting,
+	// which is SocketPath or the http URL of a TCP socket.
+	RunnerURL string
+
 	// URLPath is the path portion of the url at which events should be
 	// received. For example, "/events/"
 	URLPath string
 
+	// Token, if set, must be sent as a bearer token in the Authorization
+	// header of every request, which is rejected otherwise.
+	Token string
+
 	// server is the http.Server instance that serves the event API. It must be
 	// closed.
 	server io.Closer
@@ -64,19 +74,32 @@ type EventReceiver struct {
 	logger logr.Logger
 }
 
+// New - creates an EventReceiver that serves on a unix socket.
 func New(ident string, errChan chan<- error) (*EventReceiver, error) {
 	sockPath := fmt.Sprintf("/tmp/ansibleoperator-%s", ident)
 	listener, err := net.Listen("unix", sockPath)
 	if err != nil {
 		return nil, err
 	}
+	return NewWithListener(ident, listener, "", errChan), nil
+}
 
+// NewWithListener - creates an EventReceiver that serves on listener, which
+// is a unix or TCP socket. If token is set, requests must carry it as a bearer
+// token.
+func NewWithListener(ident string, listener net.Listener, token string, errChan chan<- error) *EventReceiver {
 	rec := EventReceiver{
-		Events:     make(chan JobEvent, 1000),
-		SocketPath: sockPath,
-		URLPath:    "/events/",
-		ident:      ident,
-		logger:     logf.Log.WithName("eventapi").WithValues("job", ident),
+		Events:  make(chan JobEvent, 1000),
+		URLPath: "/events/",
+		Token:   token,
+		ident:   ident,
+		logger:  logf.Log.WithName("eventapi").WithValues("job", ident),
+	}
+	if listener.Addr().Network() == "unix" {
+		rec.SocketPath = listener.Addr().String()
+		rec.RunnerURL = rec.SocketPath
+	} else {
+		rec.RunnerURL = "http://" + listener.Addr().String()
 	}
 
 	mux := http.NewServeMux()
@@ -87,7 +110,7 @@ func New(ident string, errChan chan<- error) (*EventReceiver, error) {
 	go func() {
 		errChan <- srv.Serve(listener)
 	}()
-	return &rec, nil
+	return &rec
 }
 
 // Close ensures that appropriate resources are cleaned up, such as any unix
@@ -100,7 +123,9 @@ func (e *EventReceiver) Close() {
 	if err := e.server.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
 		e.logger.Error(err, "Failed to close event receiver")
 	}
-	os.Remove(e.SocketPath)
+	if e.SocketPath != "" {
+		os.Remove(e.SocketPath)
+	}
 	close(e.Events)
 }
 
@@ -117,6 +142,12 @@ func (e *EventReceiver) handleEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if e.Token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+e.Token)) != 1 {
+		e.logger.Info("Unauthorized", "code", "401")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
 	ct := r.Header.Get("content-type")
 	if strings.Split(ct, ";")[0] != "application/json" {
 		e.logger.Info("Wrong content type", "code", "415", "Request.Content-Type", ct)
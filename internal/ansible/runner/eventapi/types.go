@@ -29,6 +29,10 @@ const (
 	EventRunnerOnOk = "runner_on_ok"
 	// EventRunnerOnFailed - task finished with failed status.
 	EventRunnerOnFailed = "runner_on_failed"
+	// EventRunnerOnSkipped - task was skipped.
+	EventRunnerOnSkipped = "runner_on_skipped"
+	// EventRunnerOnUnreachable - task could not reach its host.
+	EventRunnerOnUnreachable = "runner_on_unreachable"
 	// EventPlaybookOnStats - playbook has finished running.
 	EventPlaybookOnStats = "playbook_on_stats"
 	// EventRunnerItemOnOk - item finished with ok status.
@@ -136,3 +140,13 @@ func (je JobEvent) Rescued() bool {
 	}
 	return false
 }
+
+// Changed - Does the task result of the job event report a change
+func (je JobEvent) Changed() bool {
+	res, ok := je.EventData["res"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	changed, _ := res["changed"].(bool)
+	return changed
+}
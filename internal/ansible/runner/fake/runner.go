@@ -15,6 +15,7 @@
 package fake
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -37,11 +38,20 @@ type Runner struct {
 	JobEvents []eventapi.JobEvent
 	//Stdout standard out to reply if failure occurs.
 	Stdout string
+	// TimedOut is reported by the run result once all JobEvents have been sent.
+	TimedOut bool
+	// Hash is returned as the input hash of every object.
+	Hash string
+	// Finalizers are returned instead of Finalizer when set.
+	Finalizers []string
+	// Timeout is returned as the run timeout of every object.
+	Timeout time.Duration
 }
 
 type runResult struct {
-	events <-chan eventapi.JobEvent
-	stdout string
+	events   <-chan eventapi.JobEvent
+	stdout   string
+	timedOut bool
 }
 
 func (r *runResult) Events() <-chan eventapi.JobEvent {
@@ -55,8 +65,12 @@ func (r *runResult) Stdout() (string, error) {
 	return r.stdout, fmt.Errorf("unable to find standard out")
 }
 
+func (r *runResult) TimedOut() bool {
+	return r.timedOut
+}
+
 // Run - runs the fake runner.
-func (r *Runner) Run(_ string, u *unstructured.Unstructured, _ string) (runner.RunResult, error) {
+func (r *Runner) Run(_ context.Context, _ string, u *unstructured.Unstructured, _ string) (runner.RunResult, error) {
 	if r.Error != nil {
 		return nil, r.Error
 	}
@@ -67,7 +81,7 @@ func (r *Runner) Run(_ string, u *unstructured.Unstructured, _ string) (runner.R
 		}
 		close(c)
 	}()
-	return &runResult{events: c, stdout: r.Stdout}, nil
+	return &runResult{events: c, stdout: r.Stdout, timedOut: r.TimedOut}, nil
 }
 
 // GetReconcilePeriod - new reconcile period.
@@ -90,7 +104,23 @@ func (r *Runner) GetWatchClusterScopedResources() bool {
 	return r.WatchClusterScopedResources
 }
 
-// GetFinalizer - gets the fake finalizer.
-func (r *Runner) GetFinalizer() (string, bool) {
-	return r.Finalizer, r.Finalizer != ""
+// GetFinalizers - gets the fake finalizers.
+func (r *Runner) GetFinalizers() []string {
+	if r.Finalizers != nil {
+		return r.Finalizers
+	}
+	if r.Finalizer != "" {
+		return []string{r.Finalizer}
+	}
+	return nil
+}
+
+// RunTimeout - returns the fake run timeout.
+func (r *Runner) RunTimeout(_ *unstructured.Unstructured) time.Duration {
+	return r.Timeout
+}
+
+// InputHash - returns the fake input hash.
+func (r *Runner) InputHash(_ *unstructured.Unstructured) (string, error) {
+	return r.Hash, nil
 }
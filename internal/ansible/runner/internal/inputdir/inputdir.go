@@ -35,8 +35,11 @@ type InputDir struct {
 	PlaybookPath string
 	Parameters   map[string]interface{}
 	EnvVars      map[string]string
-	Settings     map[string]string
+	Settings     map[string]interface{}
 	CmdLine      string
+	// CheckMode runs ansible with --check --diff, so that it reports the
+	// changes it would make instead of making them.
+	CheckMode bool
 }
 
 // makeDirs creates the required directory structure.
@@ -136,6 +139,9 @@ func (i *InputDir) Write() error {
 	if strings.HasPrefix(i.CmdLine, string("'")) && i.CmdLine[0] == i.CmdLine[len(i.CmdLine)-1] {
 		i.CmdLine = i.CmdLine[1 : len(i.CmdLine)-1]
 	}
+	if i.CheckMode {
+		i.CmdLine = strings.TrimSpace(i.CmdLine + " --check --diff")
+	}
 
 	cmdLineBytes := []byte(i.CmdLine)
 	if len(cmdLineBytes) > 0 {
@@ -0,0 +1,297 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+)
+
+const (
+	// JobIdentLabel - label set on the Kubernetes Jobs and Secrets created by
+	// the KubeJobBackend to the ident of the run.
+	JobIdentLabel = "ansible.sdk.operatorframework.io/job"
+	// JobOwnerAnnotation - annotation set on the Kubernetes Jobs created by
+	// the KubeJobBackend to the kind, namespace and name of the CR of the run.
+	JobOwnerAnnotation = "ansible.sdk.operatorframework.io/owner"
+
+	// jobInputDir is where the input directory is mounted in Job pods before
+	// it is copied to a writable directory at the same path as in the operator.
+	jobInputDir = "/runner-input"
+	// kubeconfigKey is the key of the kubeconfig in the input Secret.
+	kubeconfigKey = "kubeconfig"
+	// defaultJobPollInterval is how often the status of a Job is checked.
+	defaultJobPollInterval = 2 * time.Second
+)
+
+// KubeJobBackend - runs ansible-runner in a Kubernetes Job, which posts its
+// events to a TCP socket of the operator pod. The input directory and the
+// kubeconfig are passed to the Job in a Secret owned by it, and the Job is
+// deleted once it finishes.
+type KubeJobBackend struct {
+	Client client.Client
+	// Namespace is the namespace Jobs are created in.
+	Namespace string
+	// Image is the execution environment image of Jobs. It must contain
+	// ansible-runner, its http event plugin, and the playbooks and roles of
+	// the watches at the same paths as the operator image.
+	Image string
+	// ServiceAccount is the service account Jobs run as. Jobs only need to
+	// reach the operator's proxy, not the API server.
+	ServiceAccount string
+	// Host is the address of the operator pod, reachable by Jobs, that the
+	// event receivers listen on.
+	Host string
+	// PollInterval is how often the status of a Job is checked.
+	PollInterval time.Duration
+}
+
+var _ Backend = KubeJobBackend{}
+
+// NewEventReceiver listens on a TCP socket reachable by other pods, so the
+// receiver only accepts events carrying a token generated for the run. The
+// token reaches the Job in the ansible-runner settings of its input Secret.
+func (b KubeJobBackend) NewEventReceiver(ident string, errChan chan<- error) (*eventapi.EventReceiver, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("error generating event token: %w", err)
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(b.Host, "0"))
+	if err != nil {
+		return nil, err
+	}
+	return eventapi.NewWithListener(ident, listener, base64.RawURLEncoding.EncodeToString(token), errChan), nil
+}
+
+func (b KubeJobBackend) Run(ctx context.Context, job Job) ([]byte, error) {
+	kubeconfig, err := ioutil.ReadFile(job.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	secret := &corev1.Secret{Data: map[string][]byte{kubeconfigKey: kubeconfig}}
+	items := []corev1.KeyToPath{{Key: kubeconfigKey, Path: kubeconfigKey}}
+	// ansible-runner reads its input from these directories. Others, such as
+	// the artifacts of previous runs, are not needed.
+	for _, dir := range []string{"env", "project", "inventory"} {
+		err := filepath.Walk(filepath.Join(job.InputDir, dir), func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(job.InputDir, path)
+			if err != nil {
+				return err
+			}
+			// Secret keys may not contain slashes, so files are mapped to
+			// their path by the volume.
+			key := fmt.Sprintf("file-%d", len(items))
+			if secret.Data[key], err = ioutil.ReadFile(path); err != nil {
+				return err
+			}
+			items = append(items, corev1.KeyToPath{Key: key, Path: filepath.ToSlash(rel)})
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	kjob := b.newJob(job, items)
+	if err := b.Client.Create(ctx, kjob); err != nil {
+		return nil, fmt.Errorf("error creating job: %w", err)
+	}
+	defer func() {
+		// ctx may be done, but the Job must still be stopped.
+		if err := b.Client.Delete(context.Background(), kjob,
+			client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "Failed to delete ansible-runner job", "job", job.Ident, "name", kjob.GetName())
+		}
+	}()
+
+	// The Secret is garbage collected along with the Job. Pods of the Job
+	// wait for it to be created before starting.
+	secret.ObjectMeta = metav1.ObjectMeta{
+		Name:      kjob.GetName(),
+		Namespace: kjob.GetNamespace(),
+		Labels:    kjob.GetLabels(),
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+			Kind:       "Job",
+			Name:       kjob.GetName(),
+			UID:        kjob.GetUID(),
+		}},
+	}
+	if err := b.Client.Create(ctx, secret); err != nil {
+		return nil, fmt.Errorf("error creating job input secret: %w", err)
+	}
+
+	return nil, b.wait(ctx, kjob)
+}
+
+func (b KubeJobBackend) newJob(job Job, items []corev1.KeyToPath) *batchv1.Job {
+	var backoffLimit int32
+	labels := map[string]string{JobIdentLabel: job.Ident}
+	name := "ansible-runner-" + job.Ident
+
+	// Copy the input directory to a writable directory, since ansible-runner
+	// writes its artifacts there, and run ansible-runner.
+	script := fmt.Sprintf(`cp -rL %s/. "$1" && shift && exec "$@"`, jobInputDir)
+	command := append([]string{"/bin/sh", "-c", script, "sh", job.InputDir}, job.Cmd.Args...)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: b.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				JobOwnerAnnotation: fmt.Sprintf("%s/%s/%s",
+					job.Object.GetKind(), job.Object.GetNamespace(), job.Object.GetName()),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: b.ServiceAccount,
+					Containers: []corev1.Container{{
+						Name:    "ansible-runner",
+						Image:   b.Image,
+						Command: command,
+						Env:     jobEnv(job),
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "input", MountPath: jobInputDir, ReadOnly: true},
+							{Name: "input", MountPath: job.Kubeconfig, SubPath: kubeconfigKey, ReadOnly: true},
+							{Name: "runner", MountPath: job.InputDir},
+						},
+					}},
+					Volumes: []corev1.Volume{
+						{Name: "input", VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{SecretName: name, Items: items},
+						}},
+						{Name: "runner", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// jobEnv returns the environment the LocalBackend would run ansible-runner
+// with, so settings such as ANSIBLE_ROLES_PATH or ANSIBLE_COLLECTIONS_PATH
+// and the environment overrides of the operator apply to Jobs as well.
+// Variables describing the operator pod, rather than configuring ansible,
+// are left for the Job's pod to set.
+func jobEnv(job Job) []corev1.EnvVar {
+	vars := map[string]string{}
+	env := append(append([]string{}, job.Cmd.Env...), os.Environ()...)
+	env = append(env, "K8S_AUTH_KUBECONFIG="+job.Kubeconfig, "KUBECONFIG="+job.Kubeconfig)
+	for _, kv := range env {
+		// As with exec.Cmd, the last value of a variable wins.
+		name, value := kv, ""
+		if i := strings.Index(kv, "="); i >= 0 {
+			name, value = kv[:i], kv[i+1:]
+		}
+		if name != "" && !isPodEnv(name, value) {
+			vars[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	envVars := make([]corev1.EnvVar, 0, len(names))
+	for _, name := range names {
+		envVars = append(envVars, corev1.EnvVar{Name: name, Value: vars[name]})
+	}
+	return envVars
+}
+
+// podEnvVars are set by the container runtime or the image of the operator
+// pod, and would be wrong in the Job's pod.
+var podEnvVars = map[string]bool{
+	"HOME": true, "HOSTNAME": true, "PATH": true, "PWD": true, "SHLVL": true, "_": true,
+	"POD_IP": true, "POD_NAME": true, "POD_NAMESPACE": true,
+}
+
+// serviceLinkEnv matches the variables Kubernetes sets in every container for
+// the services of its namespace, such as FOO_SERVICE_HOST or FOO_PORT_80_TCP.
+var serviceLinkEnv = regexp.MustCompile(`^(KUBERNETES_.*|.+_SERVICE_(HOST|PORT.*)|.+_PORT_[0-9]+_(TCP|UDP|SCTP)(_PROTO|_PORT|_ADDR)?)$`)
+
+// isPodEnv returns whether the variable describes the operator pod rather
+// than configuring ansible.
+func isPodEnv(name, value string) bool {
+	// FOO_PORT is only a service link if it holds the service's URL.
+	serviceURL := strings.HasSuffix(name, "_PORT") && strings.Contains(value, "://")
+	return podEnvVars[name] || serviceURL || serviceLinkEnv.MatchString(name)
+}
+
+// wait returns once kjob completed, or an error if it failed or ctx is done.
+func (b KubeJobBackend) wait(ctx context.Context, kjob *batchv1.Job) error {
+	interval := b.PollInterval
+	if interval == 0 {
+		interval = defaultJobPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	key := types.NamespacedName{Namespace: kjob.GetNamespace(), Name: kjob.GetName()}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := b.Client.Get(ctx, key, kjob); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Error(err, "Failed to get ansible-runner job", "name", key.Name)
+			continue
+		}
+		for _, c := range kjob.Status.Conditions {
+			if c.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch c.Type {
+			case batchv1.JobComplete:
+				return nil
+			case batchv1.JobFailed:
+				return fmt.Errorf("job %s failed: %s", key, c.Message)
+			}
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/runner/eventapi"
+	"github.com/operator-framework/operator-sdk/internal/ansible/watches"
+)
+
+func TestKubeJobBackend(t *testing.T) {
+	testCases := []struct {
+		name      string
+		condition batchv1.JobConditionType
+	}{
+		{name: "completed", condition: batchv1.JobComplete},
+		{name: "failed", condition: batchv1.JobFailed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fakeclient.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+			testKubeJobBackend(t, c, "operator", tc.condition)
+		})
+	}
+}
+
+// TestKubeJobBackendAPIServer runs the Job, Secret and receiver round trip
+// against a real API server, which validates the Job and Secret. It requires
+// the envtest binaries in KUBEBUILDER_ASSETS.
+func TestKubeJobBackendAPIServer(t *testing.T) {
+	if testing.Short() || os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("skipping test requiring the envtest binaries in KUBEBUILDER_ASSETS")
+	}
+	env := &envtest.Environment{}
+	cfg, err := env.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := env.Stop(); err != nil {
+			t.Error(err)
+		}
+	}()
+	c, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		condition batchv1.JobConditionType
+	}{
+		{name: "completed", condition: batchv1.JobComplete},
+		{name: "failed", condition: batchv1.JobFailed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The API server has no garbage collector to delete the Secret
+			// of the previous Job, so each case uses its own namespace.
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "operator-" + tc.name}}
+			if err := c.Create(context.TODO(), ns); err != nil {
+				t.Fatal(err)
+			}
+			testKubeJobBackend(t, c, ns.Name, tc.condition)
+		})
+	}
+}
+
+// testKubeJobBackend runs a CR with a KubeJobBackend creating Jobs in
+// namespace, and checks the events posted by the Job and its deletion.
+func testKubeJobBackend(t *testing.T, c client.Client, namespace string, condition batchv1.JobConditionType) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kubeconfig, err := ioutil.TempFile("", "kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(kubeconfig.Name())
+	if _, err := kubeconfig.WriteString("apiVersion: v1\nkind: Config\n"); err != nil {
+		t.Fatal(err)
+	}
+	kubeconfig.Close()
+
+	// The environment of the operator configures ansible in the Job as well,
+	// but variables describing the operator pod do not.
+	for name, value := range map[string]string{
+		"ANSIBLE_ROLES_PATH":       "/opt/ansible/roles",
+		"HOSTNAME":                 "operator-pod",
+		"MEMCACHED_SERVICE_HOST":   "10.0.0.1",
+		"MEMCACHED_PORT_11211_TCP": "tcp://10.0.0.1:11211",
+	} {
+		if err := os.Setenv(name, value); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Unsetenv(name)
+	}
+
+	backend := KubeJobBackend{
+		Client:         c,
+		Namespace:      namespace,
+		Image:          "quay.io/example/ee:latest",
+		ServiceAccount: "runner",
+		Host:           "127.0.0.1",
+		PollInterval:   10 * time.Millisecond,
+	}
+	watch := watches.New(schema.GroupVersionKind{Group: "app.example.com", Version: "v1alpha1", Kind: "Example"},
+		"", filepath.Join(cwd, "testdata", "playbook.yml"), nil, nil)
+	r, err := NewWithBackend(*watch, "", backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(watch.GroupVersionKind)
+	u.SetNamespace("default")
+	u.SetName("example")
+	u.Object["spec"] = map[string]interface{}{"size": int64(3)}
+
+	posted := make(chan error, 1)
+	go func() { posted <- fakeJob(c, namespace, kubeconfig.Name(), condition) }()
+
+	result, err := r.Run(context.TODO(), "42", u, kubeconfig.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []eventapi.JobEvent
+	for e := range result.Events() {
+		events = append(events, e)
+	}
+	if err := <-posted; err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Event != eventapi.EventPlaybookOnStats {
+		t.Errorf("Expected the posted event, got %+v", events)
+	}
+
+	// The Job must be deleted once it finished, whether it failed or not.
+	err = c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "ansible-runner-42"}, &batchv1.Job{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("Expected the job to be deleted, got %v", err)
+	}
+}
+
+func TestJobEnv(t *testing.T) {
+	job := Job{Kubeconfig: "/tmp/kubeconfig"}
+	job.Cmd = exec.Command("ansible-runner")
+	job.Cmd.Env = []string{"ANSIBLE_COLLECTIONS_PATH=/opt/collections", "KUBECONFIG=/ignored"}
+
+	env := map[string]string{}
+	for _, v := range jobEnv(job) {
+		env[v.Name] = v.Value
+	}
+	expected := map[string]string{
+		"ANSIBLE_COLLECTIONS_PATH": "/opt/collections",
+		"K8S_AUTH_KUBECONFIG":      "/tmp/kubeconfig",
+		"KUBECONFIG":               "/tmp/kubeconfig",
+	}
+	for name, value := range expected {
+		if env[name] != value {
+			t.Errorf("Expected %s=%s, got %q", name, value, env[name])
+		}
+	}
+
+	podEnv := map[string]string{
+		"HOSTNAME":                       "operator-pod",
+		"KUBERNETES_SERVICE_HOST":        "10.0.0.1",
+		"KUBERNETES_PORT_443_TCP_ADDR":   "10.0.0.1",
+		"MEMCACHED_SERVICE_PORT":         "11211",
+		"MEMCACHED_PORT":                 "tcp://10.0.0.1:11211",
+		"MEMCACHED_PORT_11211_TCP_PROTO": "tcp",
+		"POD_IP":                         "10.1.0.1",
+	}
+	for name, value := range podEnv {
+		if !isPodEnv(name, value) {
+			t.Errorf("Expected %s to describe the operator pod", name)
+		}
+	}
+	for name, value := range map[string]string{"ANSIBLE_ROLES_PATH": "/opt/roles", "HTTP_PORT": "8080"} {
+		if isPodEnv(name, value) {
+			t.Errorf("Expected %s to be forwarded to the job", name)
+		}
+	}
+}
+
+// fakeJob plays the part of the Job created by the KubeJobBackend: it waits
+// for the Job and its input Secret, checks them, posts an event to the
+// receiver, and sets the Job's condition.
+func fakeJob(c client.Client, namespace, kubeconfig string, condition batchv1.JobConditionType) error {
+	key := types.NamespacedName{Namespace: namespace, Name: "ansible-runner-42"}
+	job := &batchv1.Job{}
+	secret := &corev1.Secret{}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if c.Get(context.TODO(), key, job) == nil && c.Get(context.TODO(), key, secret) == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("job and secret were not created")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	pod := job.Spec.Template.Spec
+	container := pod.Containers[0]
+	if container.Image != "quay.io/example/ee:latest" || pod.ServiceAccountName != "runner" || job.Labels[JobIdentLabel] != "42" {
+		return fmt.Errorf("unexpected job: %+v", job)
+	}
+	command := strings.Join(container.Command, " ")
+	if !strings.Contains(command, "ansible-runner") || !strings.Contains(command, "-i 42") {
+		return fmt.Errorf("unexpected command: %s", command)
+	}
+	env := map[string]string{}
+	for _, v := range container.Env {
+		env[v.Name] = v.Value
+	}
+	if env["ANSIBLE_ROLES_PATH"] != "/opt/ansible/roles" || env["KUBECONFIG"] != kubeconfig || env["K8S_AUTH_KUBECONFIG"] != kubeconfig {
+		return fmt.Errorf("expected the operator environment in the job: %v", container.Env)
+	}
+	for _, name := range []string{"HOSTNAME", "MEMCACHED_SERVICE_HOST", "MEMCACHED_PORT_11211_TCP"} {
+		if _, ok := env[name]; ok {
+			return fmt.Errorf("unexpected operator pod variable %s in the job", name)
+		}
+	}
+	if len(secret.OwnerReferences) != 1 || secret.OwnerReferences[0].Name != job.Name {
+		return fmt.Errorf("expected the secret to be owned by the job: %+v", secret.OwnerReferences)
+	}
+
+	// ansible-runner reads its settings from the input directory.
+	files := map[string][]byte{}
+	for _, item := range pod.Volumes[0].Secret.Items {
+		files[item.Path] = secret.Data[item.Key]
+	}
+	if _, ok := files["project/playbook.yaml"]; !ok {
+		return fmt.Errorf("expected the playbook in the input secret, got %v", pod.Volumes[0].Secret.Items)
+	}
+	extravars := map[string]interface{}{}
+	if err := json.Unmarshal(files["env/extravars"], &extravars); err != nil || extravars["size"] != float64(3) {
+		return fmt.Errorf("unexpected extravars %s: %v", files["env/extravars"], err)
+	}
+	settings := struct {
+		URL     string            `json:"runner_http_url"`
+		Path    string            `json:"runner_http_path"`
+		Headers map[string]string `json:"runner_http_headers"`
+	}{}
+	if err := json.Unmarshal(files["env/settings"], &settings); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(settings.URL, "http://127.0.0.1:") || settings.Headers["Authorization"] == "" {
+		return fmt.Errorf("unexpected settings: %+v", settings)
+	}
+
+	body, err := json.Marshal(eventapi.JobEvent{UUID: "1", Event: eventapi.EventPlaybookOnStats})
+	if err != nil {
+		return err
+	}
+	// Events without the token of the run are rejected.
+	for _, authorization := range []string{"", "Bearer forged", settings.Headers["Authorization"]} {
+		req, err := http.NewRequest(http.MethodPost, settings.URL+settings.Path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		expected := http.StatusUnauthorized
+		if authorization == settings.Headers["Authorization"] {
+			expected = http.StatusNoContent
+		}
+		if resp.StatusCode != expected {
+			return fmt.Errorf("unexpected event response with authorization %q: %s", authorization, resp.Status)
+		}
+	}
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: condition, Status: corev1.ConditionTrue}}
+	return c.Status().Update(context.TODO(), job)
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/watches"
+)
+
+// groupLimiter is shared by all runners of the process, since a concurrency
+// group limits the runs of every watch that is a member of it.
+var groupLimiter = newLimiter()
+
+// limiter enforces the concurrency groups of watches. Its state is keyed by
+// group name, and additionally by namespace for namespace scoped groups.
+type limiter struct {
+	mu      sync.Mutex
+	slots   map[string]chan struct{}
+	buckets map[string]flowcontrol.RateLimiter
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		slots:   map[string]chan struct{}{},
+		buckets: map[string]flowcontrol.RateLimiter{},
+	}
+}
+
+// acquire blocks until a run in namespace is admitted by all of groups, or
+// ctx is done. The returned func must be called once the run has finished.
+func (l *limiter) acquire(ctx context.Context, groups []watches.ConcurrencyGroup, namespace string) (func(), error) {
+	// Acquire in name order so that runs of watches sharing several groups
+	// cannot deadlock.
+	sorted := make([]watches.ConcurrencyGroup, len(groups))
+	copy(sorted, groups)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var held []chan struct{}
+	release := func() {
+		for _, slot := range held {
+			<-slot
+		}
+	}
+	for _, g := range sorted {
+		if g.MaxConcurrentRuns == 0 {
+			continue
+		}
+		slot := l.slot(groupKey(g, namespace), g.MaxConcurrentRuns)
+		select {
+		case slot <- struct{}{}:
+		default:
+			log.V(1).Info("Waiting for a free slot of concurrency group", "group", g.Name, "namespace", namespace)
+			select {
+			case slot <- struct{}{}:
+			case <-ctx.Done():
+				release()
+				return nil, ctx.Err()
+			}
+		}
+		held = append(held, slot)
+	}
+
+	// Take rate tokens only once the slots are held, so that a run waiting
+	// for a slot does not use up the tokens of runs that could start.
+	for _, g := range sorted {
+		if g.RunsPerSecond == 0 {
+			continue
+		}
+		if err := l.bucket(groupKey(g, namespace), g).Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+func (l *limiter) slot(key string, size int) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slot, ok := l.slots[key]
+	if !ok {
+		slot = make(chan struct{}, size)
+		l.slots[key] = slot
+	}
+	return slot
+}
+
+func (l *limiter) bucket(key string, g watches.ConcurrencyGroup) flowcontrol.RateLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = flowcontrol.NewTokenBucketRateLimiter(float32(g.RunsPerSecond), g.Burst)
+		l.buckets[key] = bucket
+	}
+	return bucket
+}
+
+func groupKey(g watches.ConcurrencyGroup, namespace string) string {
+	if g.Scope == watches.ConcurrencyScopeNamespace {
+		return g.Name + "/" + namespace
+	}
+	return g.Name
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/internal/ansible/watches"
+)
+
+func TestLimiterConcurrency(t *testing.T) {
+	cluster := []watches.ConcurrencyGroup{
+		{Name: "cluster", MaxConcurrentRuns: 1, Scope: watches.ConcurrencyScopeCluster},
+	}
+	namespaced := []watches.ConcurrencyGroup{
+		{Name: "namespaced", MaxConcurrentRuns: 1, Scope: watches.ConcurrencyScopeNamespace},
+	}
+
+	testCases := []struct {
+		name           string
+		groups         []watches.ConcurrencyGroup
+		firstNamespace string
+		nextNamespace  string
+		admitted       bool
+	}{
+		{name: "cluster scope, same namespace", groups: cluster, firstNamespace: "a", nextNamespace: "a"},
+		{name: "cluster scope, other namespace", groups: cluster, firstNamespace: "a", nextNamespace: "b"},
+		{name: "namespace scope, same namespace", groups: namespaced, firstNamespace: "a", nextNamespace: "a"},
+		{name: "namespace scope, other namespace", groups: namespaced, firstNamespace: "a", nextNamespace: "b",
+			admitted: true},
+		{name: "no groups", firstNamespace: "a", nextNamespace: "a", admitted: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newLimiter()
+			release, err := l.acquire(context.TODO(), tc.groups, tc.firstNamespace)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+			defer cancel()
+			nextRelease, err := l.acquire(ctx, tc.groups, tc.nextNamespace)
+			if admitted := err == nil; admitted != tc.admitted {
+				t.Fatalf("expected admitted %v, got error %v", tc.admitted, err)
+			}
+			if err == nil {
+				nextRelease()
+			}
+
+			// once the first run has finished, the next one is admitted
+			release()
+			nextRelease, err = l.acquire(context.TODO(), tc.groups, tc.nextNamespace)
+			if err != nil {
+				t.Fatalf("unexpected error after release: %v", err)
+			}
+			nextRelease()
+		})
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	groups := []watches.ConcurrencyGroup{
+		{Name: "rate", MaxConcurrentRuns: 1, Scope: watches.ConcurrencyScopeCluster, RunsPerSecond: 0.001, Burst: 1},
+	}
+	l := newLimiter()
+
+	release, err := l.acquire(context.TODO(), groups, "a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	// the bucket is empty until long after the deadline
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	if _, err := l.acquire(ctx, groups, "a"); err == nil {
+		t.Fatalf("expected the run to be rate limited")
+	}
+	// the slot taken before waiting for a token is released
+	if n := len(l.slots["rate"]); n != 0 {
+		t.Fatalf("expected no held slots, got %d", n)
+	}
+}
@@ -15,6 +15,10 @@
 package runner
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -23,6 +27,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -49,14 +55,38 @@ const (
 	// Example usage "ansible.sdk.operatorframework.io/verbosity: 5"
 	AnsibleVerbosityAnnotation = "ansible.sdk.operatorframework.io/verbosity"
 
+	// RunTimeoutAnnotation - annotation used by a user to specify how long ansible-runner may run
+	// for a particular CR before it is killed. This will override the value provided by the watches
+	// file. Setting this to zero disables the timeout.
+	// Example usage "ansible.sdk.operatorframework.io/run-timeout: 10m"
+	RunTimeoutAnnotation = "ansible.sdk.operatorframework.io/run-timeout"
+
+	// DryRunAnnotation - annotation used by a user to run ansible in check mode for a particular
+	// CR, reporting the changes it would make instead of making them. It is ignored once the CR
+	// is being deleted, so that finalizers still run.
+	// Example usage "ansible.sdk.operatorframework.io/dry-run: true"
+	DryRunAnnotation = "ansible.sdk.operatorframework.io/dry-run"
+
+	// ForceRunAnnotation - annotation used by a user to force a run for a particular CR when
+	// the watch skips runs whose inputs are unchanged. Its value is part of the inputs, so
+	// setting it to a new value forces a run.
+	// Example usage "ansible.sdk.operatorframework.io/force-run: 2021-06-01T10:00:00Z"
+	ForceRunAnnotation = "ansible.sdk.operatorframework.io/force-run"
+
+	// InputDirRoot is the directory holding the ansible-runner input
+	// directory, including the artifacts, of every CR.
+	InputDirRoot = "/tmp/ansible-operator/runner"
+
 	ansibleRunnerBin = "ansible-runner"
 )
 
 // Runner - a runnable that should take the parameters and name and namespace
 // and run the correct code.
 type Runner interface {
-	Run(string, *unstructured.Unstructured, string) (RunResult, error)
-	GetFinalizer() (string, bool)
+	Run(context.Context, string, *unstructured.Unstructured, string) (RunResult, error)
+	GetFinalizers() []string
+	InputHash(*unstructured.Unstructured) (string, error)
+	RunTimeout(*unstructured.Unstructured) time.Duration
 }
 
 // ansibleVerbosityString will return the string with the -v* levels
@@ -119,10 +149,16 @@ func roleCmdFunc(path string) cmdFuncType {
 	}
 }
 
-// New - creates a Runner from a Watch struct
+// New - creates a Runner from a Watch struct, which runs ansible-runner locally.
 func New(watch watches.Watch, runnerArgs string) (Runner, error) {
+	return NewWithBackend(watch, runnerArgs, LocalBackend{})
+}
+
+// NewWithBackend - creates a Runner from a Watch struct, which runs
+// ansible-runner with backend.
+func NewWithBackend(watch watches.Watch, runnerArgs string, backend Backend) (Runner, error) {
 	var path string
-	var cmdFunc, finalizerCmdFunc cmdFuncType
+	var cmdFunc cmdFuncType
 
 	err := watch.Validate()
 	if err != nil {
@@ -139,30 +175,37 @@ func New(watch watches.Watch, runnerArgs string) (Runner, error) {
 		cmdFunc = roleCmdFunc(path)
 	}
 
-	// handle finalizer
-	switch {
-	case watch.Finalizer == nil:
-		finalizerCmdFunc = nil
-	case watch.Finalizer.Playbook != "":
-		finalizerCmdFunc = playbookCmdFunc(watch.Finalizer.Playbook)
-	case watch.Finalizer.Role != "":
-		finalizerCmdFunc = roleCmdFunc(watch.Finalizer.Role)
-	default:
-		finalizerCmdFunc = cmdFunc
+	// handle finalizers
+	var finalizers []finalizer
+	for _, f := range watch.OrderedFinalizers() {
+		f := f
+		fin := finalizer{Finalizer: &f}
+		switch {
+		case f.Playbook != "":
+			fin.cmdFunc = playbookCmdFunc(f.Playbook)
+		case f.Role != "":
+			fin.cmdFunc = roleCmdFunc(f.Role)
+		default:
+			fin.cmdFunc = cmdFunc
+		}
+		finalizers = append(finalizers, fin)
 	}
 
 	return &runner{
 		Path:                path,
 		cmdFunc:             cmdFunc,
 		Vars:                watch.Vars,
-		Finalizer:           watch.Finalizer,
-		finalizerCmdFunc:    finalizerCmdFunc,
+		finalizers:          finalizers,
 		GVK:                 watch.GroupVersionKind,
 		maxRunnerArtifacts:  watch.MaxRunnerArtifacts,
+		runTimeout:          watch.RunTimeout,
 		ansibleVerbosity:    watch.AnsibleVerbosity,
 		ansibleArgs:         runnerArgs,
 		snakeCaseParameters: watch.SnakeCaseParameters,
+		paramConverter:      paramconv.NewConverter(watch.SpecSchema, watch.SnakeCaseOverrides),
 		markUnsafe:          watch.MarkUnsafe,
+		concurrencyGroups:   watch.ConcurrencyGroups,
+		backend:             backend,
 	}, nil
 }
 
@@ -170,26 +213,32 @@ func New(watch watches.Watch, runnerArgs string) (Runner, error) {
 type runner struct {
 	Path                string                  // path on disk to a playbook or role depending on what cmdFunc expects
 	GVK                 schema.GroupVersionKind // GVK being watched that corresponds to the Path
-	Finalizer           *watches.Finalizer
 	Vars                map[string]interface{}
 	cmdFunc             cmdFuncType // returns a Cmd that runs ansible-runner
-	finalizerCmdFunc    cmdFuncType
+	finalizers          []finalizer // in the order they run
 	maxRunnerArtifacts  int
+	runTimeout          time.Duration
 	ansibleVerbosity    int
 	snakeCaseParameters bool
+	paramConverter      *paramconv.Converter
 	markUnsafe          bool
 	ansibleArgs         string
+	concurrencyGroups   []watches.ConcurrencyGroup
+	backend             Backend
 }
 
-func (r *runner) Run(ident string, u *unstructured.Unstructured, kubeconfig string) (RunResult, error) {
-	if _, err := exec.LookPath(ansibleRunnerBin); err != nil {
-		return nil, err
-	}
+// finalizer - a finalizer of the watch, and a func returning a Cmd that runs it.
+type finalizer struct {
+	*watches.Finalizer
+	cmdFunc cmdFuncType
+}
 
+func (r *runner) Run(ctx context.Context, ident string, u *unstructured.Unstructured, kubeconfig string) (RunResult, error) {
 	timer := metrics.ReconcileTimer(r.GVK.String())
 	defer timer.ObserveDuration()
 
-	if u.GetDeletionTimestamp() != nil && !r.isFinalizerRun(u) {
+	fin := r.currentFinalizer(u)
+	if u.GetDeletionTimestamp() != nil && fin == nil {
 		return nil, errors.New("resource has been deleted, but no finalizer was matched, skipping reconciliation")
 	}
 	logger := log.WithValues(
@@ -201,23 +250,29 @@ func (r *runner) Run(ident string, u *unstructured.Unstructured, kubeconfig stri
 	// start the event receiver. We'll check errChan for an error after
 	// ansible-runner exits.
 	errChan := make(chan error, 1)
-	receiver, err := eventapi.New(ident, errChan)
+	receiver, err := r.backend.NewEventReceiver(ident, errChan)
 	if err != nil {
 		return nil, err
 	}
 	inputDir := inputdir.InputDir{
-		Path: filepath.Join("/tmp/ansible-operator/runner/", r.GVK.Group, r.GVK.Version, r.GVK.Kind,
+		Path: filepath.Join(InputDirRoot, r.GVK.Group, r.GVK.Version, r.GVK.Kind,
 			u.GetNamespace(), u.GetName()),
 		Parameters: r.makeParameters(u),
 		EnvVars: map[string]string{
 			"K8S_AUTH_KUBECONFIG": kubeconfig,
 			"KUBECONFIG":          kubeconfig,
 		},
-		Settings: map[string]string{
-			"runner_http_url":  receiver.SocketPath,
+		Settings: map[string]interface{}{
+			"runner_http_url":  receiver.RunnerURL,
 			"runner_http_path": receiver.URLPath,
 		},
-		CmdLine: r.ansibleArgs,
+		CmdLine:   r.ansibleArgs,
+		CheckMode: DryRun(u),
+	}
+	if receiver.Token != "" {
+		inputDir.Settings["runner_http_headers"] = map[string]string{
+			"Authorization": "Bearer " + receiver.Token,
+		}
 	}
 	// If Path is a dir, assume it is a role path. Otherwise assume it's a
 	// playbook path
@@ -252,24 +307,57 @@ func (r *runner) Run(ident string, u *unstructured.Unstructured, kubeconfig stri
 		}
 	}
 
+	runTimeout := r.RunTimeout(u)
+
+	result := &runResult{
+		events:   receiver.Events,
+		inputDir: &inputDir,
+		ident:    ident,
+	}
+
+	// Wait for the concurrency groups of the watch to admit the run before
+	// starting the run timeout, so that time spent waiting does not count.
+	release, err := groupLimiter.acquire(ctx, r.concurrencyGroups, u.GetNamespace())
+	if err != nil {
+		receiver.Close()
+		return nil, err
+	}
+
+	var cancel context.CancelFunc
+	if runTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, runTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
 	go func() {
+		defer cancel()
+		defer release()
 		var dc *exec.Cmd
-		if r.isFinalizerRun(u) {
+		if fin != nil {
 			logger.V(1).Info("Resource is marked for deletion, running finalizer",
-				"Finalizer", r.Finalizer.Name)
-			dc = r.finalizerCmdFunc(ident, inputDir.Path, maxArtifacts, verbosity)
+				"Finalizer", fin.Name)
+			dc = fin.cmdFunc(ident, inputDir.Path, maxArtifacts, verbosity)
 		} else {
 			dc = r.cmdFunc(ident, inputDir.Path, maxArtifacts, verbosity)
 		}
-		// Append current environment since setting dc.Env to anything other than nil overwrites current env
-		dc.Env = append(dc.Env, os.Environ()...)
-		dc.Env = append(dc.Env, fmt.Sprintf("K8S_AUTH_KUBECONFIG=%s", kubeconfig),
-			fmt.Sprintf("KUBECONFIG=%s", kubeconfig))
-
-		output, err := dc.CombinedOutput()
-		if err != nil {
+		output, err := r.backend.Run(ctx, Job{
+			Ident:      ident,
+			Object:     u,
+			InputDir:   inputDir.Path,
+			Cmd:        dc,
+			Kubeconfig: kubeconfig,
+		})
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			// Set before the receiver is closed so that it is visible to
+			// whoever drains the events channel.
+			result.timedOut = true
+			logger.Error(err, "Ansible-runner exceeded the run timeout and was killed",
+				"timeout", runTimeout.String(), "output", string(output))
+		case err != nil:
 			logger.Error(err, string(output))
-		} else {
+		default:
 			logger.Info("Ansible-runner exited successfully")
 		}
 
@@ -299,24 +387,115 @@ func (r *runner) Run(ident string, u *unstructured.Unstructured, kubeconfig stri
 
 	}()
 
-	return &runResult{
-		events:   receiver.Events,
-		inputDir: &inputDir,
-		ident:    ident,
-	}, nil
+	return result, nil
 }
 
-func (r *runner) isFinalizerRun(u *unstructured.Unstructured) bool {
-	finalizersSet := r.Finalizer != nil && u.GetFinalizers() != nil
-	// The resource is deleted and our finalizer is present, we need to run the finalizer
-	if finalizersSet && u.GetDeletionTimestamp() != nil {
-		for _, f := range u.GetFinalizers() {
-			if f == r.Finalizer.Name {
-				return true
+// runCmd starts dc in its own process group and waits for it to exit,
+// returning its combined output. If ctx is done before dc exits, the whole
+// process group is killed so that ansible processes forked by ansible-runner
+// do not outlive the run, and ctx's error is returned.
+func runCmd(ctx context.Context, dc *exec.Cmd) ([]byte, error) {
+	var output bytes.Buffer
+	dc.Stdout = &output
+	dc.Stderr = &output
+	dc.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := dc.Start(); err != nil {
+		return nil, err
+	}
+
+	exited := make(chan struct{})
+	killed := make(chan struct{})
+	go func() {
+		defer close(killed)
+		select {
+		case <-ctx.Done():
+			// A negative pid signals every process in the group.
+			if err := syscall.Kill(-dc.Process.Pid, syscall.SIGKILL); err != nil {
+				log.Error(err, "Failed to kill ansible-runner process group", "pid", dc.Process.Pid)
 			}
+		case <-exited:
+		}
+	}()
+
+	err := dc.Wait()
+	close(exited)
+	<-killed
+	if err != nil && ctx.Err() != nil {
+		return output.Bytes(), ctx.Err()
+	}
+	return output.Bytes(), err
+}
+
+// DryRun returns true if u is annotated to be reconciled in check mode.
+func DryRun(u *unstructured.Unstructured) bool {
+	v, ok := u.GetAnnotations()[DryRunAnnotation]
+	if !ok || u.GetDeletionTimestamp() != nil {
+		return false
+	}
+	dryRun, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Info("Invalid dry run annotation", "err", err, "value", v)
+		return false
+	}
+	return dryRun
+}
+
+// InputHash returns a hash of the inputs that a run for u receives: the
+// parameters, including the spec and the vars of the watch, and the value of
+// the force run annotation. The copy of the whole object in the parameters is
+// left out, since its metadata and status change with every run.
+// RunTimeout returns how long a run for u may take, which is zero if it is
+// not limited.
+func (r *runner) RunTimeout(u *unstructured.Unstructured) time.Duration {
+	runTimeout := r.runTimeout
+	if fin := r.currentFinalizer(u); fin != nil && fin.RunTimeout.Duration > 0 {
+		runTimeout = fin.RunTimeout.Duration
+	}
+	if rt, ok := u.GetAnnotations()[RunTimeoutAnnotation]; ok {
+		d, err := time.ParseDuration(rt)
+		switch {
+		case err != nil:
+			log.Info("Invalid run timeout annotation", "err", err, "value", rt)
+		case d < 0:
+			log.Info("Invalid run timeout annotation, must not be negative", "value", rt)
+		default:
+			runTimeout = d
+		}
+	}
+	return runTimeout
+}
+
+func (r *runner) InputHash(u *unstructured.Unstructured) (string, error) {
+	parameters := r.makeParameters(u)
+	delete(parameters, r.objectKey())
+	// encoding/json sorts map keys, so equal inputs are encoded identically
+	b, err := json.Marshal(struct {
+		Parameters map[string]interface{}
+		ForceRun   string
+	}{parameters, u.GetAnnotations()[ForceRunAnnotation]})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// currentFinalizer returns the finalizer to run for u, which is the first
+// finalizer of the watch that is still present on u, or nil if u is not
+// deleted or none is present.
+func (r *runner) currentFinalizer(u *unstructured.Unstructured) *finalizer {
+	if u.GetDeletionTimestamp() == nil {
+		return nil
+	}
+	present := make(map[string]bool, len(u.GetFinalizers()))
+	for _, f := range u.GetFinalizers() {
+		present[f] = true
+	}
+	for i := range r.finalizers {
+		if present[r.finalizers[i].Name] {
+			return &r.finalizers[i]
 		}
 	}
-	return false
+	return nil
 }
 
 // makeParameters - creates the extravars parameters for ansible
@@ -347,7 +526,7 @@ func (r *runner) makeParameters(u *unstructured.Unstructured) map[string]interfa
 	parameters := map[string]interface{}{}
 
 	if r.snakeCaseParameters {
-		parameters = paramconv.MapToSnake(spec)
+		parameters = r.paramConverter.MapToSnake(spec)
 	} else {
 		for k, v := range spec {
 			parameters[k] = v
@@ -362,7 +541,7 @@ func (r *runner) makeParameters(u *unstructured.Unstructured) map[string]interfa
 
 	parameters["ansible_operator_meta"] = map[string]string{"namespace": u.GetNamespace(), "name": u.GetName()}
 
-	objKey := escapeAnsibleKey(fmt.Sprintf("_%v_%v", r.GVK.Group, strings.ToLower(r.GVK.Kind)))
+	objKey := r.objectKey()
 	parameters[objKey] = u.Object
 
 	specKey := fmt.Sprintf("%s_spec", objKey)
@@ -371,14 +550,19 @@ func (r *runner) makeParameters(u *unstructured.Unstructured) map[string]interfa
 	for k, v := range r.Vars {
 		parameters[k] = v
 	}
-	if r.isFinalizerRun(u) {
-		for k, v := range r.Finalizer.Vars {
+	if fin := r.currentFinalizer(u); fin != nil {
+		for k, v := range fin.Vars {
 			parameters[k] = v
 		}
 	}
 	return parameters
 }
 
+// objectKey returns the parameter holding the whole object.
+func (r *runner) objectKey() string {
+	return escapeAnsibleKey(fmt.Sprintf("_%v_%v", r.GVK.Group, strings.ToLower(r.GVK.Kind)))
+}
+
 // markUnsafe recursively checks for string values and marks them unsafe.
 // for eg:
 //		spec:
@@ -417,11 +601,14 @@ func escapeAnsibleKey(key string) string {
 	return key
 }
 
-func (r *runner) GetFinalizer() (string, bool) {
-	if r.Finalizer != nil {
-		return r.Finalizer.Name, true
+// GetFinalizers - returns the names of the finalizers of the watch, in the
+// order they run.
+func (r *runner) GetFinalizers() []string {
+	names := make([]string, 0, len(r.finalizers))
+	for _, f := range r.finalizers {
+		names = append(names, f.Name)
 	}
-	return "", false
+	return names
 }
 
 // RunResult - result of a ansible run
@@ -430,6 +617,9 @@ type RunResult interface {
 	Stdout() (string, error)
 	// Events returns the events from ansible-runner if it is available, else an error.
 	Events() <-chan eventapi.JobEvent
+	// TimedOut returns true if ansible-runner was killed because it exceeded its run timeout.
+	// It is only meaningful once the Events channel has been closed.
+	TimedOut() bool
 }
 
 // RunResult facilitates access to information about a run of ansible.
@@ -440,6 +630,7 @@ type runResult struct {
 
 	ident    string
 	inputDir *inputdir.InputDir
+	timedOut bool
 }
 
 // Stdout returns the stdout from ansible-runner if it is available, else an error.
@@ -451,3 +642,8 @@ func (r *runResult) Stdout() (string, error) {
 func (r *runResult) Events() <-chan eventapi.JobEvent {
 	return r.events
 }
+
+// TimedOut returns true if ansible-runner was killed because it exceeded its run timeout.
+func (r *runResult) TimedOut() bool {
+	return r.timedOut
+}
@@ -15,15 +15,20 @@
 package runner
 
 import (
+	"context"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	"github.com/operator-framework/operator-sdk/internal/ansible/paramconv"
 	"github.com/operator-framework/operator-sdk/internal/ansible/watches"
 )
 
@@ -201,30 +206,60 @@ func TestNew(t *testing.T) {
 			checkCmdFunc(t, testRunnerStruct.cmdFunc, testWatch.Playbook, testWatch.Role, testWatch.AnsibleVerbosity)
 
 			// Check finalizer
-			if testRunnerStruct.Finalizer != testWatch.Finalizer {
-				t.Fatalf("Unexpected finalizer %v expected finalizer %v", testRunnerStruct.Finalizer,
-					testWatch.Finalizer)
-			}
-
-			if testWatch.Finalizer != nil {
-				if testRunnerStruct.Finalizer.Name != testWatch.Finalizer.Name {
-					t.Fatalf("Unexpected finalizer name %v expected finalizer name %v",
-						testRunnerStruct.Finalizer.Name, testWatch.Finalizer.Name)
+			if testWatch.Finalizer == nil {
+				if len(testRunnerStruct.finalizers) != 0 {
+					t.Fatalf("Unexpected finalizers %v", testRunnerStruct.GetFinalizers())
 				}
+				return
+			}
+			if len(testRunnerStruct.finalizers) != 1 {
+				t.Fatalf("Unexpected finalizers %v expected finalizer %v", testRunnerStruct.GetFinalizers(),
+					testWatch.Finalizer.Name)
+			}
+			fin := testRunnerStruct.finalizers[0]
+			if fin.Name != testWatch.Finalizer.Name {
+				t.Fatalf("Unexpected finalizer name %v expected finalizer name %v",
+					fin.Name, testWatch.Finalizer.Name)
+			}
 
-				if len(testWatch.Finalizer.Vars) == 0 {
-					checkCmdFunc(t, testRunnerStruct.cmdFunc, testWatch.Finalizer.Playbook, testWatch.Finalizer.Role,
-						testWatch.AnsibleVerbosity)
-				} else {
-					// when finalizer vars is set the finalizerCmdFunc should be the same as the cmdFunc
-					checkCmdFunc(t, testRunnerStruct.finalizerCmdFunc, testWatch.Playbook, testWatch.Role,
-						testWatch.AnsibleVerbosity)
-				}
+			if len(testWatch.Finalizer.Vars) == 0 {
+				checkCmdFunc(t, fin.cmdFunc, testWatch.Finalizer.Playbook, testWatch.Finalizer.Role,
+					testWatch.AnsibleVerbosity)
+			} else {
+				// when finalizer vars is set the finalizer cmdFunc should be the same as the cmdFunc
+				checkCmdFunc(t, fin.cmdFunc, testWatch.Playbook, testWatch.Role,
+					testWatch.AnsibleVerbosity)
 			}
 		})
 	}
 }
 
+func TestRunCmd(t *testing.T) {
+	t.Run("command completes", func(t *testing.T) {
+		output, err := runCmd(context.TODO(), exec.Command("sh", "-c", "echo done"))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(output) != "done\n" {
+			t.Fatalf("Unexpected output %q", output)
+		}
+	})
+	t.Run("process group is killed on timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		// The backgrounded sleep keeps stdout open, so runCmd only returns once
+		// the whole process group has been killed.
+		_, err := runCmd(ctx, exec.Command("sh", "-c", "sleep 30 & wait"))
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected %v, got %v", context.DeadlineExceeded, err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Fatalf("runCmd returned after %v, process group was not killed", elapsed)
+		}
+	})
+}
+
 func TestAnsibleVerbosityString(t *testing.T) {
 	testCases := []struct {
 		verbosity      int
@@ -245,6 +280,139 @@ func TestAnsibleVerbosityString(t *testing.T) {
 	}
 }
 
+func TestDryRun(t *testing.T) {
+	testCases := []struct {
+		name       string
+		annotation *string
+		deleted    bool
+		expected   bool
+	}{
+		{name: "no annotation"},
+		{name: "true", annotation: stringPtr("true"), expected: true},
+		{name: "false", annotation: stringPtr("false")},
+		{name: "invalid", annotation: stringPtr("yes please")},
+		{name: "deleted", annotation: stringPtr("true"), deleted: true},
+	}
+
+	for _, tc := range testCases {
+		u := &unstructured.Unstructured{}
+		if tc.annotation != nil {
+			u.SetAnnotations(map[string]string{DryRunAnnotation: *tc.annotation})
+		}
+		if tc.deleted {
+			now := metav1.Now()
+			u.SetDeletionTimestamp(&now)
+		}
+		if got := DryRun(u); got != tc.expected {
+			t.Fatalf("%s: expected dry run %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestInputHash(t *testing.T) {
+	r := &runner{
+		GVK:                 schema.GroupVersionKind{Group: "app.example.com", Version: "v1alpha1", Kind: "Database"},
+		Vars:                map[string]interface{}{"replicas": 3},
+		snakeCaseParameters: true,
+		paramConverter:      paramconv.NewConverter(nil, nil),
+	}
+	newObject := func(size string, resourceVersion string, annotations map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec":   map[string]interface{}{"size": size},
+			"status": map[string]interface{}{"resourceVersion": resourceVersion},
+		}}
+		u.SetName("example")
+		u.SetNamespace("default")
+		u.SetResourceVersion(resourceVersion)
+		u.SetAnnotations(annotations)
+		return u
+	}
+	hash := func(u *unstructured.Unstructured) string {
+		h, err := r.InputHash(u)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return h
+	}
+
+	base := hash(newObject("small", "1", nil))
+	if h := hash(newObject("small", "2", nil)); h != base {
+		t.Fatalf("expected metadata and status not to change the hash")
+	}
+	if h := hash(newObject("large", "1", nil)); h == base {
+		t.Fatalf("expected the spec to change the hash")
+	}
+	forced := hash(newObject("small", "1", map[string]string{ForceRunAnnotation: "1"}))
+	if forced == base {
+		t.Fatalf("expected the force run annotation to change the hash")
+	}
+	if h := hash(newObject("small", "1", map[string]string{ForceRunAnnotation: "2"})); h == forced {
+		t.Fatalf("expected a new force run annotation value to change the hash")
+	}
+	r.Vars = map[string]interface{}{"replicas": 5}
+	if h := hash(newObject("small", "1", nil)); h == base {
+		t.Fatalf("expected the vars to change the hash")
+	}
+}
+
+func TestCurrentFinalizer(t *testing.T) {
+	w := watches.New(schema.GroupVersionKind{Group: "app.example.com", Version: "v1alpha1", Kind: "Database"},
+		"", "testdata/playbook.yml", nil, nil)
+	w.Finalizers = []watches.Finalizer{
+		{Name: "app.example.com/backup", Vars: map[string]interface{}{"backup": true}},
+		{Name: "app.example.com/cleanup", Vars: map[string]interface{}{"state": "absent"}},
+	}
+	testRunner, err := New(*w, "")
+	if err != nil {
+		t.Fatalf("Error occurred unexpectedly: %v", err)
+	}
+	r := testRunner.(*runner)
+	if names := r.GetFinalizers(); !reflect.DeepEqual(names, []string{"app.example.com/backup", "app.example.com/cleanup"}) {
+		t.Fatalf("Unexpected finalizers %v", names)
+	}
+
+	testCases := []struct {
+		name       string
+		deleted    bool
+		finalizers []string
+		expected   string
+	}{
+		{name: "not deleted", finalizers: []string{"app.example.com/backup"}},
+		{name: "first", deleted: true, finalizers: []string{"other", "app.example.com/cleanup", "app.example.com/backup"},
+			expected: "app.example.com/backup"},
+		{name: "second", deleted: true, finalizers: []string{"app.example.com/cleanup"},
+			expected: "app.example.com/cleanup"},
+		{name: "none left", deleted: true, finalizers: []string{"other"}},
+	}
+	for _, tc := range testCases {
+		u := &unstructured.Unstructured{}
+		u.SetFinalizers(tc.finalizers)
+		if tc.deleted {
+			now := metav1.Now()
+			u.SetDeletionTimestamp(&now)
+		}
+		fin := r.currentFinalizer(u)
+		switch {
+		case fin == nil && tc.expected != "":
+			t.Fatalf("%s: expected finalizer %s, got none", tc.name, tc.expected)
+		case fin != nil && fin.Name != tc.expected:
+			t.Fatalf("%s: expected finalizer %q, got %s", tc.name, tc.expected, fin.Name)
+		case fin != nil:
+			// only the vars of the current finalizer are passed to the run
+			parameters := r.makeParameters(u)
+			_, backup := parameters["backup"]
+			_, state := parameters["state"]
+			if backup != (tc.expected == "app.example.com/backup") || state != (tc.expected == "app.example.com/cleanup") {
+				t.Fatalf("%s: unexpected finalizer vars in parameters %v", tc.name, parameters)
+			}
+		}
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
 func TestMakeParameters(t *testing.T) {
 	var (
 		inputSpec string = "testKey"
@@ -27,6 +27,7 @@ import (
 	"strings"
 	"time"
 
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -47,17 +48,28 @@ type Watch struct {
 	Vars                        map[string]interface{}    `yaml:"vars"`
 	MaxRunnerArtifacts          int                       `yaml:"maxRunnerArtifacts"`
 	ReconcilePeriod             time.Duration             `yaml:"reconcilePeriod"`
+	RunTimeout                  time.Duration             `yaml:"runTimeout"`
 	Finalizer                   *Finalizer                `yaml:"finalizer"`
+	Finalizers                  []Finalizer               `yaml:"finalizers"`
 	ManageStatus                bool                      `yaml:"manageStatus"`
 	WatchDependentResources     bool                      `yaml:"watchDependentResources"`
 	WatchClusterScopedResources bool                      `yaml:"watchClusterScopedResources"`
 	SnakeCaseParameters         bool                      `yaml:"snakeCaseParameters"`
+	SnakeCaseFromSchema         bool                      `yaml:"snakeCaseFromSchema"`
+	SnakeCaseOverrides          map[string]string         `yaml:"snakeCaseOverrides"`
 	MarkUnsafe                  bool                      `yaml:"markUnsafe"`
 	Selector                    metav1.LabelSelector      `yaml:"selector"`
+	TaskSummary                 *TaskSummary              `yaml:"taskSummary"`
+	AccessPolicy                *AccessPolicy             `yaml:"accessPolicy"`
+	ConcurrencyGroups           []ConcurrencyGroup        `yaml:"concurrencyGroups"`
+	SkipUnchangedRuns           bool                      `yaml:"skipUnchangedRuns"`
 
 	// Not configurable via watches.yaml
 	MaxConcurrentReconciles int `yaml:"-"`
 	AnsibleVerbosity        int `yaml:"-"`
+	// SpecSchema is the openAPIV3Schema of the spec of the watched kind,
+	// which is read from its CRD when SnakeCaseFromSchema is set.
+	SpecSchema *apiextv1.JSONSchemaProps `yaml:"-"`
 }
 
 // Finalizer - Expose finalizer to be used by a user.
@@ -66,6 +78,133 @@ type Finalizer struct {
 	Playbook string                 `yaml:"playbook"`
 	Role     string                 `yaml:"role"`
 	Vars     map[string]interface{} `yaml:"vars"`
+	// RunTimeout overrides the runTimeout of the watch for runs of the
+	// finalizer. Zero means the runTimeout of the watch applies.
+	RunTimeout metav1.Duration `yaml:"runTimeout"`
+}
+
+// TaskSummary - Configures the per-task summary of the last run that is written
+// to the status of a CR. The summary is only written when the status is managed
+// by the operator.
+type TaskSummary struct {
+	// MaxTasks is the number of most recent tasks kept in the summary.
+	MaxTasks int `yaml:"maxTasks"`
+	// MaxFailedTasks is the number of most recent failed tasks kept in the summary.
+	MaxFailedTasks int `yaml:"maxFailedTasks"`
+}
+
+// AccessPolicy - Restricts the API requests that the playbook or role of a
+// watch may make through the proxy. Requests for the watched kind itself are
+// always allowed.
+type AccessPolicy struct {
+	Rules []AccessRule `yaml:"rules"`
+}
+
+// AccessRule - Allows verbs on a kind. An empty version matches all versions,
+// and "*" matches all groups, kinds or verbs.
+type AccessRule struct {
+	Group   string   `yaml:"group"`
+	Version string   `yaml:"version"`
+	Kind    string   `yaml:"kind"`
+	Verbs   []string `yaml:"verbs"`
+}
+
+// accessVerbs are the verbs that may be used in an AccessRule.
+var accessVerbs = map[string]bool{
+	"*": true, "get": true, "list": true, "watch": true, "create": true,
+	"update": true, "patch": true, "delete": true, "deletecollection": true,
+}
+
+// Allows returns whether the policy allows verb on gvk.
+func (p *AccessPolicy) Allows(verb string, gvk schema.GroupVersionKind) bool {
+	for _, r := range p.Rules {
+		if r.matches(verb, gvk) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r AccessRule) matches(verb string, gvk schema.GroupVersionKind) bool {
+	if r.Group != "*" && r.Group != gvk.Group {
+		return false
+	}
+	if r.Version != "" && r.Version != gvk.Version {
+		return false
+	}
+	if r.Kind != "*" && r.Kind != gvk.Kind {
+		return false
+	}
+	for _, v := range r.Verbs {
+		if v == "*" || v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *AccessPolicy) validate() error {
+	for i, r := range p.Rules {
+		if r.Kind == "" {
+			return fmt.Errorf("rule %d: kind must be set", i)
+		}
+		if len(r.Verbs) == 0 {
+			return fmt.Errorf("rule %d: verbs must be set", i)
+		}
+		for _, v := range r.Verbs {
+			if !accessVerbs[v] {
+				return fmt.Errorf("rule %d: unknown verb %q", i, v)
+			}
+		}
+	}
+	return nil
+}
+
+// Scopes of a ConcurrencyGroup.
+const (
+	ConcurrencyScopeCluster   = "Cluster"
+	ConcurrencyScopeNamespace = "Namespace"
+)
+
+// ConcurrencyGroup - Limits the runs of all watches that are members of the
+// group. Watches that share a group by name must define it identically.
+type ConcurrencyGroup struct {
+	Name string `yaml:"name"`
+	// MaxConcurrentRuns is the number of runs of the group that may be in
+	// progress at once. Zero means unlimited.
+	MaxConcurrentRuns int `yaml:"maxConcurrentRuns"`
+	// Scope is ConcurrencyScopeCluster to apply the limits to all runs of the
+	// group, or ConcurrencyScopeNamespace to apply them to the runs in each
+	// namespace separately.
+	Scope string `yaml:"scope"`
+	// RunsPerSecond is the rate at which runs of the group may start. Zero
+	// means unlimited.
+	RunsPerSecond float64 `yaml:"runsPerSecond"`
+	// Burst is the number of runs that may start at once when RunsPerSecond is set.
+	Burst int `yaml:"burst"`
+}
+
+func (g *ConcurrencyGroup) validate() error {
+	if g.Name == "" {
+		return errors.New("name must be set")
+	}
+	if g.MaxConcurrentRuns < 0 || g.RunsPerSecond < 0 || g.Burst < 0 {
+		return fmt.Errorf("group %q: maxConcurrentRuns, runsPerSecond and burst must not be negative", g.Name)
+	}
+	if g.MaxConcurrentRuns == 0 && g.RunsPerSecond == 0 {
+		return fmt.Errorf("group %q: maxConcurrentRuns or runsPerSecond must be set", g.Name)
+	}
+	switch g.Scope {
+	case "":
+		g.Scope = ConcurrencyScopeCluster
+	case ConcurrencyScopeCluster, ConcurrencyScopeNamespace:
+	default:
+		return fmt.Errorf("group %q: scope must be %q or %q", g.Name, ConcurrencyScopeCluster, ConcurrencyScopeNamespace)
+	}
+	if g.RunsPerSecond > 0 && g.Burst == 0 {
+		g.Burst = concurrencyGroupBurstDefault
+	}
+	return nil
 }
 
 // Default values for optional fields on Watch
@@ -73,12 +212,18 @@ var (
 	blacklistDefault                   = []schema.GroupVersionKind{}
 	maxRunnerArtifactsDefault          = 20
 	reconcilePeriodDefault             = metav1.Duration{Duration: time.Duration(0)}
+	runTimeoutDefault                  = metav1.Duration{Duration: time.Duration(0)}
 	manageStatusDefault                = true
 	watchDependentResourcesDefault     = true
 	watchClusterScopedResourcesDefault = false
 	snakeCaseParametersDefault         = true
+	snakeCaseFromSchemaDefault         = false
 	markUnsafeDefault                  = false
+	skipUnchangedRunsDefault           = false
 	selectorDefault                    = metav1.LabelSelector{}
+	maxTasksDefault                    = 20
+	maxFailedTasksDefault              = 5
+	concurrencyGroupBurstDefault       = 1
 
 	// these are overridden by cmdline flags
 	maxConcurrentReconcilesDefault = runtime.NumCPU()
@@ -125,14 +270,22 @@ type alias struct {
 	Vars                        map[string]interface{}    `yaml:"vars"`
 	MaxRunnerArtifacts          int                       `yaml:"maxRunnerArtifacts"`
 	ReconcilePeriod             *metav1.Duration          `yaml:"reconcilePeriod,omitempty"`
+	RunTimeout                  *metav1.Duration          `yaml:"runTimeout,omitempty"`
 	ManageStatus                *bool                     `yaml:"manageStatus,omitempty"`
 	WatchDependentResources     *bool                     `yaml:"watchDependentResources,omitempty"`
 	WatchClusterScopedResources *bool                     `yaml:"watchClusterScopedResources,omitempty"`
 	SnakeCaseParameters         *bool                     `yaml:"snakeCaseParameters"`
+	SnakeCaseFromSchema         *bool                     `yaml:"snakeCaseFromSchema,omitempty"`
+	SnakeCaseOverrides          map[string]string         `yaml:"snakeCaseOverrides,omitempty"`
 	MarkUnsafe                  *bool                     `yaml:"markUnsafe"`
 	Blacklist                   []schema.GroupVersionKind `yaml:"blacklist,omitempty"`
 	Finalizer                   *Finalizer                `yaml:"finalizer"`
+	Finalizers                  []Finalizer               `yaml:"finalizers,omitempty"`
 	Selector                    tempLabelSelector         `yaml:"selector"`
+	TaskSummary                 *TaskSummary              `yaml:"taskSummary,omitempty"`
+	AccessPolicy                *AccessPolicy             `yaml:"accessPolicy,omitempty"`
+	ConcurrencyGroups           []ConcurrencyGroup        `yaml:"concurrencyGroups,omitempty"`
+	SkipUnchangedRuns           *bool                     `yaml:"skipUnchangedRuns,omitempty"`
 }
 
 // buildWatch will build Watch based on the values parsed from alias
@@ -153,6 +306,14 @@ func (w *Watch) setValuesFromAlias(tmp alias) error {
 		tmp.ReconcilePeriod = &reconcilePeriodDefault
 	}
 
+	// a zero run timeout means ansible-runner is never interrupted
+	if tmp.RunTimeout == nil {
+		tmp.RunTimeout = &runTimeoutDefault
+	}
+	if tmp.RunTimeout.Duration < 0 {
+		return fmt.Errorf("invalid runTimeout %v: must not be negative", tmp.RunTimeout.Duration)
+	}
+
 	if tmp.WatchClusterScopedResources == nil {
 		tmp.WatchClusterScopedResources = &watchClusterScopedResourcesDefault
 	}
@@ -165,10 +326,67 @@ func (w *Watch) setValuesFromAlias(tmp alias) error {
 		tmp.SnakeCaseParameters = &snakeCaseParametersDefault
 	}
 
+	// the schema of the CRD and the overrides only change how the spec is converted
+	if tmp.SnakeCaseFromSchema == nil {
+		tmp.SnakeCaseFromSchema = &snakeCaseFromSchemaDefault
+	}
+	if (*tmp.SnakeCaseFromSchema || len(tmp.SnakeCaseOverrides) > 0) && !*tmp.SnakeCaseParameters {
+		return errors.New("invalid snakeCaseFromSchema or snakeCaseOverrides: requires snakeCaseParameters")
+	}
+
 	if tmp.MarkUnsafe == nil {
 		tmp.MarkUnsafe = &markUnsafeDefault
 	}
 
+	// the inputs of the last successful run are recorded in the status
+	if tmp.SkipUnchangedRuns == nil {
+		tmp.SkipUnchangedRuns = &skipUnchangedRunsDefault
+	}
+	if *tmp.SkipUnchangedRuns && !*tmp.ManageStatus {
+		return errors.New("invalid skipUnchangedRuns: requires manageStatus")
+	}
+
+	if tmp.TaskSummary != nil {
+		if tmp.TaskSummary.MaxTasks < 0 || tmp.TaskSummary.MaxFailedTasks < 0 {
+			return fmt.Errorf("invalid taskSummary: maxTasks and maxFailedTasks must not be negative")
+		}
+		if tmp.TaskSummary.MaxTasks == 0 {
+			tmp.TaskSummary.MaxTasks = maxTasksDefault
+		}
+		if tmp.TaskSummary.MaxFailedTasks == 0 {
+			tmp.TaskSummary.MaxFailedTasks = maxFailedTasksDefault
+		}
+	}
+
+	if tmp.AccessPolicy != nil {
+		if err := tmp.AccessPolicy.validate(); err != nil {
+			return fmt.Errorf("invalid accessPolicy: %w", err)
+		}
+	}
+
+	if tmp.Finalizer != nil && len(tmp.Finalizers) > 0 {
+		return errors.New("invalid finalizers: finalizer and finalizers are mutually exclusive")
+	}
+	finalizerNames := make(map[string]bool, len(tmp.Finalizers))
+	for _, f := range tmp.Finalizers {
+		if finalizerNames[f.Name] {
+			return fmt.Errorf("invalid finalizers: duplicate finalizer %q", f.Name)
+		}
+		finalizerNames[f.Name] = true
+	}
+
+	groupNames := make(map[string]bool, len(tmp.ConcurrencyGroups))
+	for i := range tmp.ConcurrencyGroups {
+		g := &tmp.ConcurrencyGroups[i]
+		if err := g.validate(); err != nil {
+			return fmt.Errorf("invalid concurrencyGroups: %w", err)
+		}
+		if groupNames[g.Name] {
+			return fmt.Errorf("invalid concurrencyGroups: duplicate group %q", g.Name)
+		}
+		groupNames[g.Name] = true
+	}
+
 	gvk := schema.GroupVersionKind{
 		Group:   tmp.Group,
 		Version: tmp.Version,
@@ -187,14 +405,22 @@ func (w *Watch) setValuesFromAlias(tmp alias) error {
 	w.MaxRunnerArtifacts = tmp.MaxRunnerArtifacts
 	w.MaxConcurrentReconciles = getMaxConcurrentReconciles(gvk, maxConcurrentReconcilesDefault)
 	w.ReconcilePeriod = tmp.ReconcilePeriod.Duration
+	w.RunTimeout = tmp.RunTimeout.Duration
 	w.ManageStatus = *tmp.ManageStatus
 	w.WatchDependentResources = *tmp.WatchDependentResources
 	w.SnakeCaseParameters = *tmp.SnakeCaseParameters
+	w.SnakeCaseFromSchema = *tmp.SnakeCaseFromSchema
+	w.SnakeCaseOverrides = tmp.SnakeCaseOverrides
 	w.MarkUnsafe = *tmp.MarkUnsafe
 	w.WatchClusterScopedResources = *tmp.WatchClusterScopedResources
 	w.Finalizer = tmp.Finalizer
+	w.Finalizers = tmp.Finalizers
 	w.AnsibleVerbosity = getAnsibleVerbosity(gvk, ansibleVerbosityDefault)
 	w.Blacklist = tmp.Blacklist
+	w.TaskSummary = tmp.TaskSummary
+	w.AccessPolicy = tmp.AccessPolicy
+	w.ConcurrencyGroups = tmp.ConcurrencyGroups
+	w.SkipUnchangedRuns = *tmp.SkipUnchangedRuns
 
 	wd, err := os.Getwd()
 	if err != nil {
@@ -221,17 +447,27 @@ func (w *Watch) addRolePlaybookPaths(rootDir string) {
 			}
 		}
 	}
-	if w.Finalizer != nil && len(w.Finalizer.Role) > 0 {
-		possibleRolePaths := getPossibleRolePaths(rootDir, w.Finalizer.Role)
+	if w.Finalizer != nil {
+		w.Finalizer.addRolePlaybookPaths(rootDir)
+	}
+	for i := range w.Finalizers {
+		w.Finalizers[i].addRolePlaybookPaths(rootDir)
+	}
+}
+
+// addRolePlaybookPaths will add the full path based on the current dir
+func (f *Finalizer) addRolePlaybookPaths(rootDir string) {
+	if len(f.Role) > 0 {
+		possibleRolePaths := getPossibleRolePaths(rootDir, f.Role)
 		for _, possiblePath := range possibleRolePaths {
 			if _, err := os.Stat(possiblePath); err == nil {
-				w.Finalizer.Role = possiblePath
+				f.Role = possiblePath
 				break
 			}
 		}
 	}
-	if w.Finalizer != nil && len(w.Finalizer.Playbook) > 0 {
-		w.Finalizer.Playbook = getFullPath(rootDir, w.Finalizer.Playbook)
+	if len(f.Playbook) > 0 {
+		f.Playbook = getFullPath(rootDir, f.Playbook)
 	}
 }
 
@@ -284,6 +520,7 @@ func getPossibleRolePaths(rootDir, path string) []string {
 // A Watch is considered valid if it:
 // - Specifies a valid path to a Role||Playbook
 // - If a Finalizer is non-nil, it must have a name + valid path to a Role||Playbook or Vars
+// - Each of Finalizers must be valid the same way, and only one of Finalizer and Finalizers is set
 func (w *Watch) Validate() error {
 	err := verifyAnsiblePath(w.Playbook, w.Role)
 	if err != nil {
@@ -291,17 +528,13 @@ func (w *Watch) Validate() error {
 		return err
 	}
 
-	if w.Finalizer != nil {
-		if w.Finalizer.Name == "" {
-			err = fmt.Errorf("finalizer must have name")
-			log.Error(err, fmt.Sprintf("Invalid finalizer for GVK: %v", w.GroupVersionKind.String()))
-			return err
-		}
-		// only fail if Vars not set
-		err = verifyAnsiblePath(w.Finalizer.Playbook, w.Finalizer.Role)
-		if err != nil && len(w.Finalizer.Vars) == 0 {
-			log.Error(err, fmt.Sprintf("Invalid ansible path on Finalizer for GVK: %v",
-				w.GroupVersionKind.String()))
+	if w.Finalizer != nil && len(w.Finalizers) > 0 {
+		err = fmt.Errorf("finalizer and finalizers are mutually exclusive")
+		log.Error(err, fmt.Sprintf("Invalid finalizer for GVK: %v", w.GroupVersionKind.String()))
+		return err
+	}
+	for _, f := range w.OrderedFinalizers() {
+		if err := w.validateFinalizer(f); err != nil {
 			return err
 		}
 	}
@@ -309,6 +542,36 @@ func (w *Watch) Validate() error {
 	return nil
 }
 
+func (w *Watch) validateFinalizer(f Finalizer) error {
+	if f.Name == "" {
+		err := fmt.Errorf("finalizer must have name")
+		log.Error(err, fmt.Sprintf("Invalid finalizer for GVK: %v", w.GroupVersionKind.String()))
+		return err
+	}
+	if f.RunTimeout.Duration < 0 {
+		err := fmt.Errorf("finalizer %s: runTimeout must not be negative", f.Name)
+		log.Error(err, fmt.Sprintf("Invalid finalizer for GVK: %v", w.GroupVersionKind.String()))
+		return err
+	}
+	// only fail if Vars not set
+	err := verifyAnsiblePath(f.Playbook, f.Role)
+	if err != nil && len(f.Vars) == 0 {
+		log.Error(err, fmt.Sprintf("Invalid ansible path on Finalizer %s for GVK: %v",
+			f.Name, w.GroupVersionKind.String()))
+		return err
+	}
+	return nil
+}
+
+// OrderedFinalizers - returns the finalizers of the watch in the order they
+// run when a CR is deleted: the single Finalizer, or else Finalizers.
+func (w *Watch) OrderedFinalizers() []Finalizer {
+	if w.Finalizer != nil {
+		return []Finalizer{*w.Finalizer}
+	}
+	return w.Finalizers
+}
+
 // New - returns a Watch with sensible defaults.
 func New(gvk schema.GroupVersionKind, role, playbook string, vars map[string]interface{}, finalizer *Finalizer) *Watch {
 	return &Watch{
@@ -320,11 +583,14 @@ func New(gvk schema.GroupVersionKind, role, playbook string, vars map[string]int
 		MaxRunnerArtifacts:          maxRunnerArtifactsDefault,
 		MaxConcurrentReconciles:     maxConcurrentReconcilesDefault,
 		ReconcilePeriod:             reconcilePeriodDefault.Duration,
+		RunTimeout:                  runTimeoutDefault.Duration,
 		ManageStatus:                manageStatusDefault,
 		WatchDependentResources:     watchDependentResourcesDefault,
 		WatchClusterScopedResources: watchClusterScopedResourcesDefault,
 		SnakeCaseParameters:         snakeCaseParametersDefault,
+		SnakeCaseFromSchema:         snakeCaseFromSchemaDefault,
 		MarkUnsafe:                  markUnsafeDefault,
+		SkipUnchangedRuns:           skipUnchangedRunsDefault,
 		Finalizer:                   finalizer,
 		AnsibleVerbosity:            ansibleVerbosityDefault,
 		Selector:                    selectorDefault,
@@ -362,6 +628,7 @@ func Load(path string, maxReconciler, ansibleVerbosity int) ([]Watch, error) {
 	}
 
 	watchesMap := make(map[schema.GroupVersionKind]bool)
+	groups := make(map[string]ConcurrencyGroup)
 	for _, watch := range watches {
 		// prevent dupes
 		if _, ok := watchesMap[watch.GroupVersionKind]; ok {
@@ -370,6 +637,15 @@ func Load(path string, maxReconciler, ansibleVerbosity int) ([]Watch, error) {
 
 		watchesMap[watch.GroupVersionKind] = true
 
+		// a group is shared by all watches that name it, so its limits must agree
+		for _, g := range watch.ConcurrencyGroups {
+			if other, ok := groups[g.Name]; ok && other != g {
+				return nil, fmt.Errorf("concurrency group %q of GVK %v conflicts with its definition in another watch",
+					g.Name, watch.GroupVersionKind.String())
+			}
+			groups[g.Name] = g
+		}
+
 		err = watch.Validate()
 		if err != nil {
 			log.Error(err, fmt.Sprintf("Watch with GVK %v failed validation", watch.GroupVersionKind.String()))
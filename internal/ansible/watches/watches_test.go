@@ -89,6 +89,9 @@ func TestNew(t *testing.T) {
 				t.Fatalf("Unexpected watchClusterScopedResources %v expected %v",
 					watch.WatchClusterScopedResources, watchClusterScopedResourcesDefault)
 			}
+			if watch.RunTimeout != runTimeoutDefault.Duration {
+				t.Fatalf("Unexpected runTimeout %v expected %v", watch.RunTimeout, runTimeoutDefault.Duration)
+			}
 			if watch.AnsibleVerbosity != ansibleVerbosityDefault {
 				t.Fatalf("Unexpected ansibleVerbosity %v expected %v", watch.AnsibleVerbosity,
 					ansibleVerbosityDefault)
@@ -147,10 +150,17 @@ func TestLoad(t *testing.T) { //nolint:gocyclo
 			Playbook:                    validTemplate.ValidPlaybook,
 			ManageStatus:                true,
 			ReconcilePeriod:             twoSeconds,
+			RunTimeout:                  time.Minute,
 			WatchDependentResources:     true,
 			WatchClusterScopedResources: false,
 			SnakeCaseParameters:         true,
+			SnakeCaseFromSchema:         true,
+			SnakeCaseOverrides:          map[string]string{"network.IPv6Address": "ipv6_address"},
 			MarkUnsafe:                  false,
+			ConcurrencyGroups: []ConcurrencyGroup{
+				{Name: "quota-api", MaxConcurrentRuns: 2, Scope: ConcurrencyScopeCluster, RunsPerSecond: 0.5, Burst: 1},
+				{Name: "per-namespace", MaxConcurrentRuns: 1, Scope: ConcurrencyScopeNamespace},
+			},
 		},
 		Watch{
 			GroupVersionKind: schema.GroupVersionKind{
@@ -162,6 +172,11 @@ func TestLoad(t *testing.T) { //nolint:gocyclo
 			ManageStatus:    true,
 			ReconcilePeriod: twoSeconds,
 			MarkUnsafe:      true,
+			TaskSummary:     &TaskSummary{MaxTasks: 10, MaxFailedTasks: 5},
+			AccessPolicy: &AccessPolicy{Rules: []AccessRule{
+				{Group: "apps", Kind: "Deployment", Verbs: []string{"get", "list", "watch", "create", "patch"}},
+				{Group: "", Version: "v1", Kind: "ConfigMap", Verbs: []string{"*"}},
+			}},
 		},
 		Watch{
 			GroupVersionKind: schema.GroupVersionKind{
@@ -191,6 +206,9 @@ func TestLoad(t *testing.T) { //nolint:gocyclo
 			ManageStatus:                true,
 			WatchDependentResources:     true,
 			WatchClusterScopedResources: true,
+			ConcurrencyGroups: []ConcurrencyGroup{
+				{Name: "quota-api", MaxConcurrentRuns: 2, Scope: ConcurrencyScopeCluster, RunsPerSecond: 0.5, Burst: 1},
+			},
 		},
 		Watch{
 			GroupVersionKind: schema.GroupVersionKind{
@@ -226,8 +244,9 @@ func TestLoad(t *testing.T) { //nolint:gocyclo
 				Group:   "app.example.com",
 				Kind:    "EnableStatus",
 			},
-			Playbook:     validTemplate.ValidPlaybook,
-			ManageStatus: true,
+			Playbook:          validTemplate.ValidPlaybook,
+			ManageStatus:      true,
+			SkipUnchangedRuns: true,
 		},
 		Watch{
 			GroupVersionKind: schema.GroupVersionKind{
@@ -256,6 +275,26 @@ func TestLoad(t *testing.T) { //nolint:gocyclo
 				Vars: map[string]interface{}{"sentinel": "finalizer_running"},
 			},
 		},
+		Watch{
+			GroupVersionKind: schema.GroupVersionKind{
+				Version: "v1alpha1",
+				Group:   "app.example.com",
+				Kind:    "FinalizerPhases",
+			},
+			Role:         validTemplate.ValidRole,
+			ManageStatus: true,
+			Finalizers: []Finalizer{
+				{
+					Name:       "app.example.com/backup",
+					Playbook:   validTemplate.ValidPlaybook,
+					RunTimeout: metav1.Duration{Duration: 30 * time.Minute},
+				},
+				{
+					Name: "app.example.com/cleanup",
+					Vars: map[string]interface{}{"sentinel": "finalizer_running"},
+				},
+			},
+		},
 		Watch{
 			GroupVersionKind: schema.GroupVersionKind{
 				Version: "v1alpha1",
@@ -454,6 +493,41 @@ func TestLoad(t *testing.T) { //nolint:gocyclo
 			path:        "testdata/invalid_duration.yaml",
 			shouldError: true,
 		},
+		{
+			name:        "error negative run timeout",
+			path:        "testdata/invalid_run_timeout.yaml",
+			shouldError: true,
+		},
+		{
+			name:        "error invalid access policy",
+			path:        "testdata/invalid_access_policy.yaml",
+			shouldError: true,
+		},
+		{
+			name:        "error conflicting concurrency groups",
+			path:        "testdata/invalid_concurrency_group.yaml",
+			shouldError: true,
+		},
+		{
+			name:        "error skip unchanged runs without managed status",
+			path:        "testdata/invalid_skip_unchanged_runs.yaml",
+			shouldError: true,
+		},
+		{
+			name:        "error finalizer and finalizers",
+			path:        "testdata/invalid_finalizers.yaml",
+			shouldError: true,
+		},
+		{
+			name:        "error duplicate finalizers",
+			path:        "testdata/invalid_duplicate_finalizers.yaml",
+			shouldError: true,
+		},
+		{
+			name:        "error snake case overrides without snake case parameters",
+			path:        "testdata/invalid_snake_case_overrides.yaml",
+			shouldError: true,
+		},
 		{
 			name:        "error invalid status",
 			path:        "testdata/invalid_status.yaml",
@@ -547,10 +621,40 @@ func TestLoad(t *testing.T) { //nolint:gocyclo
 							gotWatch.Finalizer, expectedWatch.Finalizer)
 					}
 				}
+				if !reflect.DeepEqual(gotWatch.Finalizers, expectedWatch.Finalizers) {
+					t.Fatalf("The GVK: %v\nunexpected finalizers: %#v\nexpected finalizers: %#v", gvk,
+						gotWatch.Finalizers, expectedWatch.Finalizers)
+				}
 				if gotWatch.ReconcilePeriod != expectedWatch.ReconcilePeriod {
 					t.Fatalf("The GVK: %v unexpected reconcile period: %v expected reconcile period: %v", gvk,
 						gotWatch.ReconcilePeriod, expectedWatch.ReconcilePeriod)
 				}
+				if !reflect.DeepEqual(gotWatch.TaskSummary, expectedWatch.TaskSummary) {
+					t.Fatalf("The GVK: %v unexpected task summary: %#v expected task summary: %#v", gvk,
+						gotWatch.TaskSummary, expectedWatch.TaskSummary)
+				}
+				if !reflect.DeepEqual(gotWatch.AccessPolicy, expectedWatch.AccessPolicy) {
+					t.Fatalf("The GVK: %v unexpected access policy: %#v expected access policy: %#v", gvk,
+						gotWatch.AccessPolicy, expectedWatch.AccessPolicy)
+				}
+				if !reflect.DeepEqual(gotWatch.ConcurrencyGroups, expectedWatch.ConcurrencyGroups) {
+					t.Fatalf("The GVK: %v unexpected concurrency groups: %#v expected concurrency groups: %#v", gvk,
+						gotWatch.ConcurrencyGroups, expectedWatch.ConcurrencyGroups)
+				}
+				if gotWatch.RunTimeout != expectedWatch.RunTimeout {
+					t.Fatalf("The GVK: %v unexpected run timeout: %v expected run timeout: %v", gvk,
+						gotWatch.RunTimeout, expectedWatch.RunTimeout)
+				}
+				if gotWatch.SkipUnchangedRuns != expectedWatch.SkipUnchangedRuns {
+					t.Fatalf("The GVK: %v unexpected skip unchanged runs: %v expected skip unchanged runs: %v", gvk,
+						gotWatch.SkipUnchangedRuns, expectedWatch.SkipUnchangedRuns)
+				}
+				if gotWatch.SnakeCaseFromSchema != expectedWatch.SnakeCaseFromSchema ||
+					!reflect.DeepEqual(gotWatch.SnakeCaseOverrides, expectedWatch.SnakeCaseOverrides) {
+					t.Fatalf("The GVK: %v unexpected snake case schema %v and overrides %v expected %v and %v", gvk,
+						gotWatch.SnakeCaseFromSchema, gotWatch.SnakeCaseOverrides,
+						expectedWatch.SnakeCaseFromSchema, expectedWatch.SnakeCaseOverrides)
+				}
 				if gotWatch.MarkUnsafe != expectedWatch.MarkUnsafe {
 					t.Fatalf("The GVK: %v unexpected mark unsafe: %v expected mark unsafe: %v", gvk,
 						gotWatch.MarkUnsafe, expectedWatch.MarkUnsafe)
@@ -584,6 +688,35 @@ func TestLoad(t *testing.T) { //nolint:gocyclo
 	}
 }
 
+func TestAccessPolicyAllows(t *testing.T) {
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	policy := &AccessPolicy{Rules: []AccessRule{
+		{Group: "apps", Kind: "Deployment", Verbs: []string{"get", "list"}},
+		{Group: "", Version: "v1beta1", Kind: "*", Verbs: []string{"*"}},
+		{Group: "*", Kind: "Secret", Verbs: []string{"get"}},
+	}}
+
+	testCases := []struct {
+		verb    string
+		gvk     schema.GroupVersionKind
+		allowed bool
+	}{
+		{"get", deployment, true},
+		{"list", deployment, true},
+		{"delete", deployment, false},
+		{"get", configMap, false},
+		{"delete", schema.GroupVersionKind{Version: "v1beta1", Kind: "ConfigMap"}, true},
+		{"get", schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, true},
+		{"update", schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, false},
+	}
+	for _, tc := range testCases {
+		if allowed := policy.Allows(tc.verb, tc.gvk); allowed != tc.allowed {
+			t.Errorf("Allows(%q, %v) = %v, expected %v", tc.verb, tc.gvk, allowed, tc.allowed)
+		}
+	}
+}
+
 func TestMaxConcurrentReconciles(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -15,9 +15,13 @@
 package run
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/url"
 	"os"
 	"runtime"
@@ -25,9 +29,14 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -36,11 +45,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
+	"github.com/operator-framework/operator-sdk/internal/ansible/artifacts"
 	"github.com/operator-framework/operator-sdk/internal/ansible/controller"
+	"github.com/operator-framework/operator-sdk/internal/ansible/events"
 	"github.com/operator-framework/operator-sdk/internal/ansible/flags"
 	"github.com/operator-framework/operator-sdk/internal/ansible/metrics"
 	"github.com/operator-framework/operator-sdk/internal/ansible/proxy"
 	"github.com/operator-framework/operator-sdk/internal/ansible/proxy/controllermap"
+	"github.com/operator-framework/operator-sdk/internal/ansible/proxy/kubeconfig"
 	"github.com/operator-framework/operator-sdk/internal/ansible/runner"
 	"github.com/operator-framework/operator-sdk/internal/ansible/watches"
 	"github.com/operator-framework/operator-sdk/internal/clientbuilder"
@@ -193,14 +205,58 @@ func run(cmd *cobra.Command, f *flags.Flags) {
 		os.Exit(1)
 	}
 
+	artifactsStore, err := newArtifactsStore(f, mgr, options.MetricsBindAddress)
+	if err != nil {
+		log.Error(err, "Failed to configure runner artifacts.")
+		os.Exit(1)
+	}
+	backend, proxyHost, err := newRunnerBackend(f, mgr, artifactsStore)
+	if err != nil {
+		log.Error(err, "Failed to configure runner backend.")
+		os.Exit(1)
+	}
+	proxyOpts, kubeconfigProxy, err := newProxyTransport(f.ProxyTransport, proxyHost)
+	if err != nil {
+		log.Error(err, "Failed to configure proxy transport.")
+		os.Exit(1)
+	}
+
 	cMap := controllermap.NewControllerMap()
 	watches, err := watches.Load(f.WatchesFile, f.MaxConcurrentReconciles, f.AnsibleVerbosity)
 	if err != nil {
 		log.Error(err, "Failed to load watches.")
 		os.Exit(1)
 	}
+	eventHandlers, err := events.NewEventHandlers(f.EventHandlers, events.HandlerOptions{
+		FilePath:             f.EventFile,
+		WebhookURL:           f.EventWebhookURL,
+		WebhookBatchSize:     f.EventWebhookBatchSize,
+		WebhookFlushInterval: f.EventWebhookFlushInterval,
+		WebhookMaxRetries:    f.EventWebhookMaxRetries,
+		Recorder:             mgr.GetEventRecorderFor("ansible-operator"),
+	})
+	if err != nil {
+		log.Error(err, "Failed to create event handlers.")
+		os.Exit(1)
+	}
+	for _, h := range eventHandlers {
+		// Handlers that buffer events must run alongside the manager.
+		if r, ok := h.(manager.Runnable); ok {
+			if err := mgr.Add(r); err != nil {
+				log.Error(err, "Failed to add event handler to the manager.")
+				os.Exit(1)
+			}
+		}
+	}
 	for _, w := range watches {
-		runner, err := runner.New(w, f.AnsibleArgs)
+		if w.SnakeCaseFromSchema {
+			w.SpecSchema, err = loadSpecSchema(mgr.GetAPIReader(), mgr.GetRESTMapper(), w.GroupVersionKind)
+			if err != nil {
+				log.Error(err, "Failed to load the schema of the spec", "GVK", w.GroupVersionKind.String())
+				os.Exit(1)
+			}
+		}
+		runner, err := runner.NewWithBackend(w, f.AnsibleArgs, backend)
 		if err != nil {
 			log.Error(err, "Failed to create runner")
 			os.Exit(1)
@@ -209,11 +265,15 @@ func run(cmd *cobra.Command, f *flags.Flags) {
 		ctr := controller.Add(mgr, controller.Options{
 			GVK:                     w.GroupVersionKind,
 			Runner:                  runner,
+			EventHandlers:           eventHandlers,
 			ManageStatus:            w.ManageStatus,
 			AnsibleDebugLogs:        getAnsibleDebugLog(),
 			MaxConcurrentReconciles: w.MaxConcurrentReconciles,
 			ReconcilePeriod:         w.ReconcilePeriod,
 			Selector:                w.Selector,
+			TaskSummary:             w.TaskSummary,
+			Proxy:                   kubeconfigProxy,
+			SkipUnchangedRuns:       w.SkipUnchangedRuns,
 		})
 		if ctr == nil {
 			log.Error(fmt.Errorf("failed to add controller for GVK %v", w.GroupVersionKind.String()), "")
@@ -225,6 +285,7 @@ func run(cmd *cobra.Command, f *flags.Flags) {
 			WatchClusterScopedResources: w.WatchClusterScopedResources,
 			OwnerWatchMap:               controllermap.NewWatchMap(),
 			AnnotationWatchMap:          controllermap.NewWatchMap(),
+			AccessPolicy:                w.AccessPolicy,
 		}, w.Blacklist)
 	}
 
@@ -237,16 +298,24 @@ func run(cmd *cobra.Command, f *flags.Flags) {
 	done := make(chan error)
 
 	// start the proxy
-	err = proxy.Run(done, proxy.Options{
-		Address:           "localhost",
-		Port:              8888,
-		KubeConfig:        mgr.GetConfig(),
-		Cache:             mgr.GetCache(),
-		RESTMapper:        mgr.GetRESTMapper(),
-		ControllerMap:     cMap,
-		OwnerInjection:    f.InjectOwnerRef,
-		WatchedNamespaces: strings.Split(namespace, ","),
+	proxyOpts.AuditSinks, err = proxy.NewAuditSinks(f.ProxyAuditSinks, proxy.AuditSinkOptions{
+		FilePath:       f.ProxyAuditFile,
+		FileMaxSize:    int64(f.ProxyAuditFileMaxSize) * 1024 * 1024,
+		FileMaxBackups: f.ProxyAuditFileMaxBackups,
+		Recorder:       mgr.GetEventRecorderFor("ansible-operator"),
 	})
+	if err != nil {
+		log.Error(err, "Failed to create proxy audit sinks.")
+		os.Exit(1)
+	}
+	proxyOpts.AuditRequestBodies = f.ProxyAuditRequestBodies
+	proxyOpts.KubeConfig = mgr.GetConfig()
+	proxyOpts.Cache = mgr.GetCache()
+	proxyOpts.RESTMapper = mgr.GetRESTMapper()
+	proxyOpts.ControllerMap = cMap
+	proxyOpts.OwnerInjection = f.InjectOwnerRef
+	proxyOpts.WatchedNamespaces = strings.Split(namespace, ",")
+	err = proxy.Run(done, proxyOpts)
 	if err != nil {
 		log.Error(err, "Error starting proxy.")
 		os.Exit(1)
@@ -266,6 +335,30 @@ func run(cmd *cobra.Command, f *flags.Flags) {
 	log.Info("Exiting.")
 }
 
+// newProxyTransport returns the options the proxy listens with for transport,
+// and how playbooks connect to it. Owner tokens are signed for all transports.
+func newProxyTransport(transport, host string) (proxy.Options, kubeconfig.Proxy, error) {
+	signer, err := kubeconfig.NewTokenSigner()
+	if err != nil {
+		return proxy.Options{}, kubeconfig.Proxy{}, fmt.Errorf("failed to create token signer: %v", err)
+	}
+	o := proxy.Options{Address: host, Port: 8888, Signer: signer}
+	kp := kubeconfig.Proxy{Signer: signer}
+
+	switch transport {
+	case "http":
+		kp.URL = kubeconfig.DefaultProxyURL
+	case "tls":
+		if o.TLSConfig, kp.CAData, err = proxy.NewSelfSignedTLSConfig(host, "localhost", "127.0.0.1"); err != nil {
+			return proxy.Options{}, kubeconfig.Proxy{}, fmt.Errorf("failed to generate proxy certificate: %v", err)
+		}
+		kp.URL = "https://" + net.JoinHostPort(host, strconv.Itoa(o.Port))
+	default:
+		return proxy.Options{}, kubeconfig.Proxy{}, fmt.Errorf("invalid proxy transport %q", transport)
+	}
+	return o, kp, nil
+}
+
 // exitIfUnsupported prints an error containing unsupported field names and exits
 // if any of those fields are not their default values.
 func exitIfUnsupported(options manager.Options) {
@@ -325,3 +418,135 @@ func setAnsibleEnvVars(f *flags.Flags) error {
 	}
 	return nil
 }
+
+// serviceAccountNamespaceFile holds the namespace of the operator's pod.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// artifactsPath is the path of the artifacts API on the metrics endpoint.
+const artifactsPath = "/artifacts/"
+
+// newArtifactsStore returns the store of the runner artifacts, after adding
+// its API to the metrics endpoint and its pruning to mgr if f enables them.
+// The API serves the results of every task, which may include the contents
+// of Secrets, and does not authenticate requests itself. It is therefore only
+// served if the metrics endpoint is bound to a loopback address, such as
+// behind the kube-rbac-proxy sidecar of scaffolded projects.
+func newArtifactsStore(f *flags.Flags, mgr manager.Manager, metricsBindAddress string) (*artifacts.Store, error) {
+	store := &artifacts.Store{
+		Root:        runner.InputDirRoot,
+		ArchiveDir:  f.ArtifactsArchiveDir,
+		MaxArchives: f.ArtifactsMaxArchives,
+		MaxAge:      f.ArtifactsMaxAge,
+	}
+	if f.ArtifactsAPI {
+		if !isLoopbackAddress(metricsBindAddress) {
+			return nil, fmt.Errorf("--artifacts-api requires the metrics endpoint to be bound to a loopback address "+
+				"behind an authenticating proxy, but it is bound to %q", metricsBindAddress)
+		}
+		if err := mgr.AddMetricsExtraHandler(artifactsPath, artifacts.NewHandler(store, artifactsPath)); err != nil {
+			return nil, err
+		}
+	}
+	if store.MaxAge > 0 {
+		if err := mgr.Add(store); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// isLoopbackAddress returns whether addr only accepts connections from the
+// operator's pod.
+func isLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newRunnerBackend returns the backend that runs ansible-runner, and the host
+// that the proxy must listen on for ansible to reach it.
+func newRunnerBackend(f *flags.Flags, mgr manager.Manager, store *artifacts.Store) (runner.Backend, string, error) {
+	switch f.RunnerBackend {
+	case "local":
+		return runner.LocalBackend{Artifacts: store}, "localhost", nil
+	case "job":
+	default:
+		return nil, "", fmt.Errorf("invalid runner backend %q", f.RunnerBackend)
+	}
+
+	// The artifacts of Jobs stay in their pods.
+	if store.ArchiveDir != "" {
+		return nil, "", errors.New("the job runner backend does not support --artifacts-archive-dir")
+	}
+
+	// Jobs reach the proxy over the pod network, so it must be authenticated.
+	if f.ProxyTransport != "tls" {
+		return nil, "", errors.New("the job runner backend requires --proxy-transport=tls")
+	}
+	if f.RunnerJobImage == "" {
+		return nil, "", errors.New("the job runner backend requires --runner-job-image")
+	}
+	b := runner.KubeJobBackend{
+		Image:          f.RunnerJobImage,
+		Namespace:      f.RunnerJobNamespace,
+		ServiceAccount: f.RunnerJobServiceAccount,
+		Host:           f.RunnerJobHost,
+	}
+	if b.Host == "" {
+		if b.Host = os.Getenv("POD_IP"); b.Host == "" {
+			return nil, "", errors.New("the job runner backend requires --runner-job-host or POD_IP to be set")
+		}
+	}
+	if b.Namespace == "" {
+		ns, err := ioutil.ReadFile(serviceAccountNamespaceFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("the job runner backend requires --runner-job-namespace "+
+				"when not running in a pod: %v", err)
+		}
+		b.Namespace = strings.TrimSpace(string(ns))
+	}
+	// Read Jobs from the API server, since they may not be in the watched
+	// namespaces that the manager's cache is limited to.
+	var err error
+	if b.Client, err = client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()}); err != nil {
+		return nil, "", err
+	}
+	return b, b.Host, nil
+}
+
+// loadSpecSchema returns the openAPIV3Schema of the spec of gvk from its CRD.
+func loadSpecSchema(reader client.Reader, mapper meta.RESTMapper, gvk schema.GroupVersionKind) (*apiextv1.JSONSchemaProps, error) {
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	name := mapping.Resource.Resource + "." + gvk.Group
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(apiextv1.SchemeGroupVersion.WithKind("CustomResourceDefinition"))
+	if err := reader.Get(context.TODO(), client.ObjectKey{Name: name}, u); err != nil {
+		return nil, fmt.Errorf("failed to get CRD %s: %w", name, err)
+	}
+	b, err := u.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	crd := &apiextv1.CustomResourceDefinition{}
+	if err := json.Unmarshal(b, crd); err != nil {
+		return nil, err
+	}
+	for _, v := range crd.Spec.Versions {
+		if v.Name != gvk.Version || v.Schema == nil || v.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		if spec, ok := v.Schema.OpenAPIV3Schema.Properties["spec"]; ok {
+			return &spec, nil
+		}
+	}
+	return nil, fmt.Errorf("CRD %s has no schema for the spec of version %s", name, gvk.Version)
+}
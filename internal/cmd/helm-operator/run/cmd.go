@@ -21,6 +21,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,11 +36,13 @@ import (
 	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/operator-framework/operator-sdk/internal/clientbuilder"
+	"github.com/operator-framework/operator-sdk/internal/helm/chartsource"
 	"github.com/operator-framework/operator-sdk/internal/helm/controller"
 	"github.com/operator-framework/operator-sdk/internal/helm/flags"
 	"github.com/operator-framework/operator-sdk/internal/helm/metrics"
 	"github.com/operator-framework/operator-sdk/internal/helm/release"
 	"github.com/operator-framework/operator-sdk/internal/helm/watches"
+	"github.com/operator-framework/operator-sdk/internal/helm/webhook"
 	"github.com/operator-framework/operator-sdk/internal/util/k8sutil"
 	sdkVersion "github.com/operator-framework/operator-sdk/internal/version"
 )
@@ -174,21 +177,51 @@ func run(cmd *cobra.Command, f *flags.Flags) {
 		log.Error(err, "Failed to create new manager factories.")
 		os.Exit(1)
 	}
+	fetcher := chartsource.NewFetcher(f.ChartCacheDir)
 	for _, w := range ws {
+		chartPath, err := fetcher.Fetch(w)
+		if err != nil {
+			log.Error(err, "Failed to fetch chart.", "GVK", w.GroupVersionKind.String())
+			os.Exit(1)
+		}
+
 		// Register the controller with the factory.
-		err := controller.Add(mgr, controller.WatchOptions{
+		var waitTimeout time.Duration
+		if w.WaitTimeout != nil {
+			waitTimeout = w.WaitTimeout.Duration
+		}
+		reconcilePeriod := f.ReconcilePeriod
+		if w.ReconcilePeriod != nil {
+			reconcilePeriod = w.ReconcilePeriod.Duration
+		}
+		maxConcurrentReconciles := f.MaxConcurrentReconciles
+		if w.MaxConcurrentReconciles != nil {
+			maxConcurrentReconciles = *w.MaxConcurrentReconciles
+		}
+		err = controller.Add(mgr, controller.WatchOptions{
 			Namespace:               namespace,
 			GVK:                     w.GroupVersionKind,
-			ManagerFactory:          release.NewManagerFactory(mgr, w.ChartDir),
-			ReconcilePeriod:         f.ReconcilePeriod,
+			ManagerFactory:          release.NewManagerFactory(mgr, chartPath),
+			ReconcilePeriod:         reconcilePeriod,
 			WatchDependentResources: *w.WatchDependentResources,
 			OverrideValues:          w.OverrideValues,
-			MaxConcurrentReconciles: f.MaxConcurrentReconciles,
+			MaxConcurrentReconciles: maxConcurrentReconciles,
+			WaitForReady:            w.WaitForReady,
+			WaitTimeout:             waitTimeout,
+			DriftPolicy:             w.DriftPolicy,
+			Selector:                w.Selector,
 		})
 		if err != nil {
 			log.Error(err, "Failed to add manager factory to controller.")
 			os.Exit(1)
 		}
+
+		if f.EnableWebhooks {
+			if err := webhook.Add(mgr, w.GroupVersionKind, chartPath, w.OverrideValues); err != nil {
+				log.Error(err, "Failed to add validating webhook.", "GVK", w.GroupVersionKind.String())
+				os.Exit(1)
+			}
+		}
 	}
 
 	// Start the Cmd
@@ -19,15 +19,20 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	apierrors "github.com/operator-framework/api/pkg/validation/errors"
 	registrybundle "github.com/operator-framework/operator-registry/pkg/lib/bundle"
 	"github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-sdk/internal/testreport"
 )
 
 const (
 	JSONAlpha1 = "json-alpha1"
 	Text       = "text"
+	JUnit      = testreport.JUnit
+	TAP        = testreport.TAP
 )
 
 // Result represents the final result
@@ -126,6 +131,27 @@ func (o *Result) printJSON() error {
 	return nil
 }
 
+// testSuites converts the result into a single test case in a "bundle-validate"
+// suite. Errors are folded into the failure, and all outputs are kept as the
+// test case's output.
+func (o *Result) testSuites() []testreport.Suite {
+	c := testreport.Case{Name: "validate", State: testreport.Passed}
+	errs, lines := []string{}, []string{}
+	for _, obj := range o.Outputs {
+		if obj.Type == logrus.ErrorLevel.String() {
+			errs = append(errs, obj.Message)
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", obj.Type, obj.Message))
+	}
+	if !o.Passed {
+		c.State = testreport.Failed
+		c.Message = fmt.Sprintf("bundle validation failed with %d error(s)", len(errs))
+		c.Details = strings.Join(errs, "\n")
+	}
+	c.Output = strings.Join(lines, "\n")
+	return []testreport.Suite{{Name: "bundle-validate", Cases: []testreport.Case{c}}}
+}
+
 // prepare should be used when writing an Result to a non-log writer.
 // it will ensure that the passed boolean will properly set in the case of the setters were not properly used
 func (o *Result) prepare() error {
@@ -168,6 +194,10 @@ func (o *Result) getPrintFuncFormat(format string) func(*Result) error {
 		return func(o *Result) error {
 			return o.printJSON()
 		}
+	case JUnit, TAP:
+		return func(o *Result) error {
+			return testreport.Write(os.Stdout, format, o.testSuites())
+		}
 	}
 
 	// Address all to the Stdout when the type is not JSON
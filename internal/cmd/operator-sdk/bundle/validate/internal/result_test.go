@@ -198,6 +198,51 @@ var _ = Describe("Output Result", func() {
 
 			Expect(json.Unmarshal(stdout, &res)).NotTo(Succeed())
 		})
+
+		It("should return a func which prints JUnit XML", func() {
+			By("passing the format `junit`")
+			result.AddError(errors.New("invalid CSV"))
+			printf := result.getPrintFuncFormat(JUnit)
+			Expect(printf).ToNot(BeNil())
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			go func() {
+				err := printf(result)
+				Expect(err).NotTo(HaveOccurred())
+				w.Close()
+			}()
+			stdout, _ := ioutil.ReadAll(r)
+
+			Expect(string(stdout)).To(ContainSubstring(`<testsuite name="bundle-validate" tests="1" failures="1"`))
+			Expect(string(stdout)).To(ContainSubstring("invalid CSV"))
+		})
+
+		It("should return a func which prints TAP", func() {
+			By("passing the format `tap`")
+			result.AddWarn(errors.New("missing icon"))
+			printf := result.getPrintFuncFormat(TAP)
+			Expect(printf).ToNot(BeNil())
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			go func() {
+				err := printf(result)
+				Expect(err).NotTo(HaveOccurred())
+				w.Close()
+			}()
+			stdout, _ := ioutil.ReadAll(r)
+
+			Expect(string(stdout)).To(Equal("TAP version 13\n1..1\nok 1 - bundle-validate validate\n"))
+		})
 	})
 
 	Describe("Test printJSON()", func() {
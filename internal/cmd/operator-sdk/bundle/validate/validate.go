@@ -54,7 +54,9 @@ func (c bundleValidateCmd) validate(args []string) error {
 	if len(args) != 1 {
 		return errors.New("an image tag or directory is a required argument")
 	}
-	if c.outputFormat != internal.JSONAlpha1 && c.outputFormat != internal.Text {
+	switch c.outputFormat {
+	case internal.JSONAlpha1, internal.Text, internal.JUnit, internal.TAP:
+	default:
 		return fmt.Errorf("invalid value for output flag: %v", c.outputFormat)
 	}
 
@@ -86,7 +88,7 @@ func (c *bundleValidateCmd) addToFlagSet(fs *pflag.FlagSet) {
 			"against an Kubernetes version that it is intended to be distributed use `--optional-values=k8s-version=1.22`")
 
 	fs.StringVarP(&c.outputFormat, "output", "o", internal.Text,
-		"Result format for results. One of: [text, json-alpha1]. Note: output format types containing "+
+		"Result format for results. One of: [text, json-alpha1, junit, tap]. Note: output format types containing "+
 			"\"alphaX\" are subject to change and not covered by guarantees of stable APIs.")
 }
 
@@ -33,17 +33,22 @@ import (
 	"github.com/operator-framework/operator-sdk/internal/flags"
 	registryutil "github.com/operator-framework/operator-sdk/internal/registry"
 	"github.com/operator-framework/operator-sdk/internal/scorecard"
+	"github.com/operator-framework/operator-sdk/internal/testreport"
 )
 
 type scorecardCmd struct {
 	bundle         string
+	cacheDir       string
 	config         string
 	kubeconfig     string
+	localRoot      string
 	namespace      string
 	outputFormat   string
 	selector       string
 	serviceAccount string
 	list           bool
+	local          bool
+	rerunFailed    bool
 	skipCleanup    bool
 	waitTime       time.Duration
 }
@@ -73,11 +78,23 @@ If the argument holds an image tag, it must be present remotely.`,
 	scorecardCmd.Flags().StringVarP(&c.config, "config", "c", "", "path to scorecard config file")
 	scorecardCmd.Flags().StringVarP(&c.namespace, "namespace", "n", "", "namespace to run the test images in")
 	scorecardCmd.Flags().StringVarP(&c.outputFormat, "output", "o", "text",
-		"Output format for results. Valid values: text, json")
+		"Output format for results. Valid values: text, json, junit, tap")
 	scorecardCmd.Flags().StringVarP(&c.serviceAccount, "service-account", "s", "default",
 		"Service account to use for tests")
 	scorecardCmd.Flags().BoolVarP(&c.list, "list", "L", false,
 		"Option to enable listing which tests are run")
+	scorecardCmd.Flags().BoolVar(&c.local, "local", false,
+		"Run tests without a cluster. Built-in tests run in-process, and other tests run their "+
+			"entrypoint as a local executable with the bundle linked at --local-bundle-root")
+	scorecardCmd.Flags().StringVar(&c.localRoot, "local-bundle-root", "",
+		"Path to link the bundle at for tests run with --local, "+scorecard.PodBundleRoot+" if unset. "+
+			"It must not exist, and its parent directory must be writable")
+	scorecardCmd.Flags().StringVar(&c.cacheDir, "cache-dir", "",
+		"Directory to cache test results in. Tests whose bundle contents and configuration "+
+			"have not changed since they were cached are not run again")
+	scorecardCmd.Flags().BoolVar(&c.rerunFailed, "rerun-failed", false,
+		"Only run tests whose last cached result did not pass, even if the bundle has changed since, "+
+			"and report the last result of other tests. Requires --cache-dir")
 	scorecardCmd.Flags().BoolVarP(&c.skipCleanup, "skip-cleanup", "x", false,
 		"Disable resource cleanup after tests are run")
 	scorecardCmd.Flags().DurationVarP(&c.waitTime, "wait-time", "w", 30*time.Second,
@@ -86,7 +103,7 @@ If the argument holds an image tag, it must be present remotely.`,
 	return scorecardCmd
 }
 
-func (c *scorecardCmd) printOutput(output v1alpha3.TestList) error {
+func (c *scorecardCmd) printOutput(o scorecard.Scorecard, output v1alpha3.TestList) error {
 	switch c.outputFormat {
 	case "text":
 		if len(output.Items) == 0 {
@@ -102,6 +119,10 @@ func (c *scorecardCmd) printOutput(output v1alpha3.TestList) error {
 			return fmt.Errorf("marshal json error: %v", err)
 		}
 		fmt.Printf("%s\n", string(bytes))
+	case testreport.JUnit, testreport.TAP:
+		if err := testreport.Write(os.Stdout, c.outputFormat, o.TestSuites(output)); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("invalid output format selected")
 	}
@@ -128,6 +149,7 @@ func (c *scorecardCmd) run() (err error) {
 
 	o := scorecard.Scorecard{
 		SkipCleanup: c.skipCleanup,
+		RerunFailed: c.rerunFailed,
 	}
 
 	configPath := c.config
@@ -152,19 +174,33 @@ func (c *scorecardCmd) run() (err error) {
 	if c.list {
 		scorecardTests = o.List()
 	} else {
-		runner := scorecard.PodTestRunner{
-			ServiceAccount: c.serviceAccount,
-			Namespace:      scorecard.GetKubeNamespace(c.kubeconfig, c.namespace),
-			BundlePath:     c.bundle,
-			BundleMetadata: metadata,
+		if c.cacheDir != "" {
+			if o.Cache, err = scorecard.NewResultCache(c.cacheDir, c.bundle); err != nil {
+				return err
+			}
 		}
 
-		// Only get the client if running tests.
-		if runner.Client, err = scorecard.GetKubeClient(c.kubeconfig); err != nil {
-			return fmt.Errorf("error getting kubernetes client: %w", err)
-		}
+		if c.local {
+			o.TestRunner = &scorecard.LocalTestRunner{
+				BundlePath:     c.bundle,
+				BundleMetadata: metadata,
+				BundleRoot:     c.localRoot,
+			}
+		} else {
+			runner := scorecard.PodTestRunner{
+				ServiceAccount: c.serviceAccount,
+				Namespace:      scorecard.GetKubeNamespace(c.kubeconfig, c.namespace),
+				BundlePath:     c.bundle,
+				BundleMetadata: metadata,
+			}
 
-		o.TestRunner = &runner
+			// Only get the client if running tests in a cluster.
+			if runner.Client, err = scorecard.GetKubeClient(c.kubeconfig); err != nil {
+				return fmt.Errorf("error getting kubernetes client: %w", err)
+			}
+
+			o.TestRunner = &runner
+		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), c.waitTime)
 		defer cancel()
@@ -175,7 +211,7 @@ func (c *scorecardCmd) run() (err error) {
 		}
 	}
 
-	if err := c.printOutput(scorecardTests); err != nil {
+	if err := c.printOutput(o, scorecardTests); err != nil {
 		log.Fatal(err)
 	}
 
@@ -200,6 +236,12 @@ func (c *scorecardCmd) validate(args []string) error {
 	if len(args) != 1 {
 		return fmt.Errorf("a bundle image or directory argument is required")
 	}
+	if c.rerunFailed && c.cacheDir == "" {
+		return fmt.Errorf("--rerun-failed requires --cache-dir")
+	}
+	if c.localRoot != "" && !c.local {
+		return fmt.Errorf("--local-bundle-root requires --local")
+	}
 	return nil
 }
 
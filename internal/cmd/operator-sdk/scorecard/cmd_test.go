@@ -56,6 +56,22 @@ var _ = Describe("Running the scorecard command", func() {
 			Expect(flag.Shorthand).To(Equal("L"))
 			Expect(flag.DefValue).To(Equal("false"))
 
+			flag = cmd.Flags().Lookup("local")
+			Expect(flag).NotTo(BeNil())
+			Expect(flag.DefValue).To(Equal("false"))
+
+			flag = cmd.Flags().Lookup("local-bundle-root")
+			Expect(flag).NotTo(BeNil())
+			Expect(flag.DefValue).To(Equal(""))
+
+			flag = cmd.Flags().Lookup("cache-dir")
+			Expect(flag).NotTo(BeNil())
+			Expect(flag.DefValue).To(Equal(""))
+
+			flag = cmd.Flags().Lookup("rerun-failed")
+			Expect(flag).NotTo(BeNil())
+			Expect(flag.DefValue).To(Equal("false"))
+
 			flag = cmd.Flags().Lookup("skip-cleanup")
 			Expect(flag).NotTo(BeNil())
 			Expect(flag.Shorthand).To(Equal("x"))
@@ -86,5 +102,21 @@ var _ = Describe("Running the scorecard command", func() {
 			err := cmd.validate([]string{input})
 			Expect(err).NotTo(HaveOccurred())
 		})
+
+		It("fails if --rerun-failed is set without --cache-dir", func() {
+			cmd.rerunFailed = true
+			Expect(cmd.validate([]string{"cherry"})).NotTo(Succeed())
+
+			cmd.cacheDir = "cache"
+			Expect(cmd.validate([]string{"cherry"})).To(Succeed())
+		})
+
+		It("fails if --local-bundle-root is set without --local", func() {
+			cmd.localRoot = "bundle"
+			Expect(cmd.validate([]string{"cherry"})).NotTo(Succeed())
+
+			cmd.local = true
+			Expect(cmd.validate([]string{"cherry"})).To(Succeed())
+		})
 	})
 })
@@ -0,0 +1,171 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartsource
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/operator-framework/operator-sdk/internal/helm/watches"
+)
+
+var log = logf.Log.WithName("chartsource")
+
+// Fetcher pulls remote charts and caches them on disk.
+type Fetcher struct {
+	// CacheDir is the directory chart archives are cached in.
+	CacheDir string
+	// HTTPClient is used to pull charts from OCI registries.
+	HTTPClient *http.Client
+	// RegistryConfigFiles are the Docker config files that credentials for
+	// OCI registries are read from, in order.
+	RegistryConfigFiles []string
+	// Getters are used to pull charts from chart repositories.
+	Getters getter.Providers
+}
+
+// NewFetcher returns a Fetcher that caches charts in cacheDir.
+func NewFetcher(cacheDir string) *Fetcher {
+	return &Fetcher{
+		CacheDir:            cacheDir,
+		HTTPClient:          &http.Client{Timeout: 2 * time.Minute},
+		RegistryConfigFiles: defaultRegistryConfigFiles(),
+		Getters:             getter.All(cli.New()),
+	}
+}
+
+// Fetch returns the path of a chart that can be loaded with loader.Load. Local
+// charts are returned as is. Remote charts are pulled into the cache, unless
+// they are pinned to a digest that matches an archive already in the cache.
+func (f *Fetcher) Fetch(w watches.Watch) (string, error) {
+	if !w.IsRemoteChart() {
+		return w.ChartDir, nil
+	}
+
+	path := f.cachePath(w)
+	logger := log.WithValues("chart", w.ChartDir, "repo", w.Repo, "version", w.ChartVersion)
+	if w.ChartDigest != "" {
+		if b, err := ioutil.ReadFile(path); err == nil && verifyDigest(b, w.ChartDigest) == nil {
+			logger.Info("Using cached chart", "path", path)
+			return path, nil
+		}
+	}
+
+	var (
+		archive []byte
+		err     error
+	)
+	if strings.HasPrefix(w.ChartDir, watches.OCIScheme) {
+		archive, err = f.pullOCI(strings.TrimPrefix(w.ChartDir, watches.OCIScheme), w.ChartVersion, w.PlainHTTP)
+	} else {
+		archive, err = f.pullRepo(w.Repo, w.ChartDir, w.ChartVersion)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to pull chart %s: %w", w.ChartDir, err)
+	}
+	if w.ChartDigest != "" {
+		if err := verifyDigest(archive, w.ChartDigest); err != nil {
+			return "", fmt.Errorf("failed to verify chart %s: %w", w.ChartDir, err)
+		}
+	}
+	if _, err := loader.LoadArchive(bytes.NewReader(archive)); err != nil {
+		return "", fmt.Errorf("invalid chart archive for %s: %w", w.ChartDir, err)
+	}
+	if err := writeFileAtomic(path, archive); err != nil {
+		return "", fmt.Errorf("failed to cache chart %s: %w", w.ChartDir, err)
+	}
+	logger.Info("Pulled chart", "path", path, "digest", digestOf(archive))
+	return path, nil
+}
+
+// cachePath returns the path of the cached archive for w, which is unique to
+// the chart, repository and version.
+func (f *Fetcher) cachePath(w watches.Watch) string {
+	key := sha256.Sum256([]byte(strings.Join([]string{w.Repo, w.ChartDir, w.ChartVersion}, "\n")))
+	name := filepath.Base(w.ChartDir)
+	return filepath.Join(f.CacheDir, fmt.Sprintf("%s-%s-%s.tgz", name, w.ChartVersion, hex.EncodeToString(key[:8])))
+}
+
+// pullRepo downloads version of chart from the chart repository at repoURL.
+func (f *Fetcher) pullRepo(repoURL, chart, version string) ([]byte, error) {
+	chartURL, err := repo.FindChartInRepoURL(repoURL, chart, version, "", "", "", f.Getters)
+	if err != nil {
+		return nil, err
+	}
+	u, err := repo.ResolveReferenceURL(repoURL, chartURL)
+	if err != nil {
+		return nil, err
+	}
+	scheme := strings.SplitN(u, "://", 2)[0]
+	g, err := f.Getters.ByScheme(scheme)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := g.Get(u, getter.WithURL(repoURL))
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func digestOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func verifyDigest(b []byte, expected string) error {
+	if actual := digestOf(b); actual != expected {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to move chart archive into the cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartsource
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+
+	"github.com/operator-framework/operator-sdk/internal/helm/watches"
+)
+
+// testChartArchive returns a packaged chart named testchart.
+func testChartArchive(t *testing.T) []byte {
+	dir := t.TempDir()
+	chartDir, err := chartutil.Create("testchart", dir)
+	if err != nil {
+		t.Fatalf("Failed to create chart: %v", err)
+	}
+	c, err := loader.LoadDir(chartDir)
+	if err != nil {
+		t.Fatalf("Failed to load chart: %v", err)
+	}
+	path, err := chartutil.Save(c, dir)
+	if err != nil {
+		t.Fatalf("Failed to package chart: %v", err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read chart archive: %v", err)
+	}
+	return b
+}
+
+const ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+// registryOptions configure the registry served by newRegistry.
+type registryOptions struct {
+	// tls serves the registry over https.
+	tls bool
+	// username and password, if set, are required to get a token.
+	username, password string
+}
+
+// newRegistry returns a server that serves archive as charts/testchart:0.1.0
+// and requires a bearer token, along with a count of chart layer requests.
+func newRegistry(t *testing.T, archive []byte, opts registryOptions) (*httptest.Server, *int) {
+	layerRequests := 0
+	mux := http.NewServeMux()
+	srv := httptest.NewUnstartedServer(mux)
+	if opts.tls {
+		srv.StartTLS()
+	} else {
+		srv.Start()
+	}
+
+	config := []byte("{}")
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"config": map[string]interface{}{
+			"mediaType": "application/vnd.cncf.helm.config.v1+json",
+			"digest":    digestOf(config),
+			"size":      len(config),
+		},
+		"layers": []map[string]interface{}{{
+			"mediaType": helmChartContentMediaType,
+			"digest":    digestOf(archive),
+			"size":      len(archive),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest: %v", err)
+	}
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if opts.username != "" {
+			username, password, _ := r.BasicAuth()
+			if r.Method == http.MethodPost {
+				username, password = r.PostFormValue("username"), r.PostFormValue("password")
+			}
+			if username != opts.username || password != opts.password {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		fmt.Fprint(w, `{"token": "secret", "access_token": "secret"}`)
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s/token",service="registry",scope="repository:charts/testchart:pull"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body []byte
+		switch r.URL.Path {
+		case "/v2/charts/testchart/manifests/0.1.0", "/v2/charts/testchart/manifests/" + digestOf(manifest):
+			w.Header().Set("Content-Type", ociManifestMediaType)
+			w.Header().Set("Docker-Content-Digest", digestOf(manifest))
+			body = manifest
+		case "/v2/charts/testchart/blobs/" + digestOf(config):
+			body = config
+		case "/v2/charts/testchart/blobs/" + digestOf(archive):
+			layerRequests++
+			body = archive
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		if r.Method != http.MethodHead {
+			_, _ = w.Write(body)
+		}
+	})
+	return srv, &layerRequests
+}
+
+// newRepo returns a chart repository serving archive as testchart 0.1.0.
+func newRepo(t *testing.T, archive []byte) *httptest.Server {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	c, err := loader.LoadArchive(strings.NewReader(string(archive)))
+	if err != nil {
+		t.Fatalf("Failed to load chart archive: %v", err)
+	}
+	index := repo.NewIndexFile()
+	index.Add(c.Metadata, "testchart-0.1.0.tgz", srv.URL, digestOf(archive))
+	indexYAML, err := yaml.Marshal(index)
+	if err != nil {
+		t.Fatalf("Failed to marshal repo index: %v", err)
+	}
+	mux.HandleFunc("/index.yaml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(indexYAML)
+	})
+	mux.HandleFunc("/testchart-0.1.0.tgz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	})
+	return srv
+}
+
+func TestFetchLocal(t *testing.T) {
+	f := NewFetcher(t.TempDir())
+	path, err := f.Fetch(watches.Watch{ChartDir: "/path/to/chart"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if path != "/path/to/chart" {
+		t.Fatalf("Local charts should not be fetched, got %s", path)
+	}
+}
+
+func TestFetchOCI(t *testing.T) {
+	archive := testChartArchive(t)
+	srv, layerRequests := newRegistry(t, archive, registryOptions{tls: true})
+	defer srv.Close()
+
+	// Registries on localhost are always pulled over http, so the registry is
+	// reached through a name its certificate is valid for.
+	f := NewFetcher(t.TempDir())
+	f.HTTPClient = srv.Client()
+	transport := f.HTTPClient.Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	f.HTTPClient.Transport = transport
+	f.RegistryConfigFiles = []string{filepath.Join(t.TempDir(), "config.json")}
+	w := watches.Watch{
+		ChartDir:     watches.OCIScheme + "example.com/charts/testchart",
+		ChartVersion: "0.1.0",
+		ChartDigest:  digestOf(archive),
+	}
+
+	path, err := f.Fetch(w)
+	if err != nil {
+		t.Fatalf("Failed to fetch chart: %v", err)
+	}
+	c, err := loader.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load cached chart: %v", err)
+	}
+	if c.Name() != "testchart" {
+		t.Fatalf("Unexpected chart %s", c.Name())
+	}
+
+	// A chart pinned to a digest is served from the cache.
+	if _, err := f.Fetch(w); err != nil {
+		t.Fatalf("Failed to fetch cached chart: %v", err)
+	}
+	if *layerRequests != 1 {
+		t.Fatalf("Expected the chart to be pulled once, got %d pulls", *layerRequests)
+	}
+
+	w.ChartDigest = "sha256:" + strings.Repeat("0", 64)
+	if _, err := f.Fetch(w); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("Expected a digest mismatch, got %v", err)
+	}
+
+	w.ChartDigest = ""
+	w.ChartVersion = "0.2.0"
+	if _, err := f.Fetch(w); err == nil {
+		t.Fatal("Expected an error for a missing tag")
+	}
+}
+
+func TestFetchRepo(t *testing.T) {
+	archive := testChartArchive(t)
+	srv := newRepo(t, archive)
+	defer srv.Close()
+
+	f := NewFetcher(t.TempDir())
+	path, err := f.Fetch(watches.Watch{
+		ChartDir:     "testchart",
+		Repo:         srv.URL,
+		ChartVersion: "0.1.0",
+		ChartDigest:  digestOf(archive),
+	})
+	if err != nil {
+		t.Fatalf("Failed to fetch chart: %v", err)
+	}
+	if _, err := loader.Load(path); err != nil {
+		t.Fatalf("Failed to load cached chart: %v", err)
+	}
+
+	if _, err := f.Fetch(watches.Watch{ChartDir: "testchart", Repo: srv.URL, ChartVersion: "9.9.9"}); err == nil {
+		t.Fatal("Expected an error for a missing chart version")
+	}
+}
+
+func TestFetchOCIPlainHTTPWithCredentials(t *testing.T) {
+	archive := testChartArchive(t)
+	srv, _ := newRegistry(t, archive, registryOptions{username: "user", password: "pass"})
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	f := NewFetcher(t.TempDir())
+	f.RegistryConfigFiles = []string{configFile}
+	w := watches.Watch{
+		ChartDir:     watches.OCIScheme + host + "/charts/testchart",
+		ChartVersion: "0.1.0",
+		PlainHTTP:    true,
+	}
+
+	if _, err := f.Fetch(w); err == nil {
+		t.Fatal("Expected an error pulling without credentials")
+	}
+
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	config := fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, host, auth)
+	if err := ioutil.WriteFile(configFile, []byte(config), 0600); err != nil {
+		t.Fatalf("Failed to write registry config: %v", err)
+	}
+	path, err := f.Fetch(w)
+	if err != nil {
+		t.Fatalf("Failed to fetch chart: %v", err)
+	}
+	if _, err := loader.Load(path); err != nil {
+		t.Fatalf("Failed to load cached chart: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartsource
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	auth "github.com/deislabs/oras/pkg/auth/docker"
+	"github.com/deislabs/oras/pkg/content"
+	"github.com/deislabs/oras/pkg/oras"
+	dockerconfig "github.com/docker/cli/cli/config"
+	"helm.sh/helm/v3/pkg/helmpath"
+)
+
+const (
+	// Helm 3.7+ and earlier experimental Helm releases use different media
+	// types for the chart content layer.
+	helmChartContentMediaType       = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	legacyHelmChartContentMediaType = "application/tar+gzip"
+)
+
+// defaultRegistryConfigFiles returns the files registry credentials are read
+// from, in order: the Docker config, which honors $DOCKER_CONFIG and
+// credential helpers, and the file written by `helm registry login`.
+func defaultRegistryConfigFiles() []string {
+	return []string{
+		filepath.Join(dockerconfig.Dir(), dockerconfig.ConfigFileName),
+		helmpath.CachePath("registry", "config.json"),
+	}
+}
+
+// pullOCI downloads the chart content layer of the image ref:tag, where ref is
+// of the form host[:port]/repository. It pulls with the same client Helm's
+// registry support is built on, authenticating with the credentials in
+// f.RegistryConfigFiles, and falls back to anonymous tokens for public charts.
+func (f *Fetcher) pullOCI(ref, tag string, plainHTTP bool) ([]byte, error) {
+	ctx := context.Background()
+	authClient, err := auth.NewClient(f.RegistryConfigFiles...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry credentials: %w", err)
+	}
+	resolver, err := authClient.Resolver(ctx, f.HTTPClient, plainHTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	store := content.NewMemoryStore()
+	_, layers, err := oras.Pull(ctx, resolver, ref+":"+tag, store,
+		oras.WithPullEmptyNameAllowed(),
+		oras.WithAllowedMediaTypes([]string{helmChartContentMediaType, legacyHelmChartContentMediaType}))
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("manifest for %s:%s has no chart content layer", ref, tag)
+	}
+	_, blob, ok := store.Get(layers[0])
+	if !ok {
+		return nil, fmt.Errorf("chart layer of %s:%s was not pulled", ref, tag)
+	}
+	// Blobs are content addressed, so always check that we got what we asked for.
+	if err := verifyDigest(blob, layers[0].Digest.String()); err != nil {
+		return nil, fmt.Errorf("chart layer is corrupt: %w", err)
+	}
+	return blob, nil
+}
@@ -22,14 +22,21 @@ import (
 
 	rpb "helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/releaseutil"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	crthandler "sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlpredicate "sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	"sigs.k8s.io/yaml"
 
@@ -51,12 +58,21 @@ type WatchOptions struct {
 	WatchDependentResources bool
 	OverrideValues          map[string]string
 	MaxConcurrentReconciles int
+	WaitForReady            bool
+	WaitTimeout             time.Duration
+	DriftPolicy             string
+	Selector                metav1.LabelSelector
 }
 
 // Add creates a new helm operator controller and adds it to the manager
 func Add(mgr manager.Manager, options WatchOptions) error {
 	controllerName := fmt.Sprintf("%v-controller", strings.ToLower(options.GVK.Kind))
 
+	selector, err := metav1.LabelSelectorAsSelector(&options.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector: %w", err)
+	}
+
 	r := &HelmOperatorReconciler{
 		Client:          mgr.GetClient(),
 		EventRecorder:   mgr.GetEventRecorderFor(controllerName),
@@ -64,6 +80,10 @@ func Add(mgr manager.Manager, options WatchOptions) error {
 		ManagerFactory:  options.ManagerFactory,
 		ReconcilePeriod: options.ReconcilePeriod,
 		OverrideValues:  options.OverrideValues,
+		WaitForReady:    options.WaitForReady,
+		WaitTimeout:     options.WaitTimeout,
+		DriftPolicy:     options.DriftPolicy,
+		Selector:        selector,
 	}
 
 	// Register the GVK with the schema
@@ -80,19 +100,39 @@ func Add(mgr manager.Manager, options WatchOptions) error {
 
 	o := &unstructured.Unstructured{}
 	o.SetGroupVersionKind(options.GVK)
-	if err := c.Watch(&source.Kind{Type: o}, &libhandler.InstrumentedEnqueueRequestForObject{}); err != nil {
+	if err := c.Watch(&source.Kind{Type: o}, &libhandler.InstrumentedEnqueueRequestForObject{}, selectorPredicate(selector)); err != nil {
 		return err
 	}
 
 	if options.WatchDependentResources {
 		watchDependentResources(mgr, r, c)
 	}
+	watchValuesReferences(r, c)
 
 	log.Info("Watching resource", "apiVersion", options.GVK.GroupVersion(), "kind",
-		options.GVK.Kind, "namespace", options.Namespace, "reconcilePeriod", options.ReconcilePeriod.String())
+		options.GVK.Kind, "namespace", options.Namespace, "reconcilePeriod", options.ReconcilePeriod.String(),
+		"selector", selector.String())
 	return nil
 }
 
+// selectorPredicate passes the events of CRs that match selector. CRs whose
+// release is pending uninstall are also passed if the watch with selector
+// installed the release, so that CRs relabeled out of the selector do not
+// keep their finalizer forever, while the other watches of the GVK leave them
+// alone. Deletions are always passed; they only clean up the state of the
+// watch.
+func selectorPredicate(selector labels.Selector) ctrlpredicate.Predicate {
+	matches := func(o crclient.Object) bool {
+		return selector.Matches(labels.Set(o.GetLabels())) || pendingUninstall(o) && managesRelease(selector, o)
+	}
+	return ctrlpredicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return matches(e.Object) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return matches(e.ObjectNew) },
+		DeleteFunc:  func(event.DeleteEvent) bool { return true },
+		GenericFunc: func(e event.GenericEvent) bool { return matches(e.Object) },
+	}
+}
+
 // watchDependentResources adds a release hook function to the HelmOperatorReconciler
 // that adds watches for resources in released Helm charts.
 func watchDependentResources(mgr manager.Manager, r *HelmOperatorReconciler, c controller.Controller) {
@@ -177,3 +217,85 @@ func watchDependentResources(mgr manager.Manager, r *HelmOperatorReconciler, c c
 	}
 	r.releaseHook = releaseHook
 }
+
+// valuesObject identifies a ConfigMap or Secret that values are referenced
+// from.
+type valuesObject struct {
+	kind string
+	key  types.NamespacedName
+}
+
+// watchValuesReferences adds a values hook function to the
+// HelmOperatorReconciler that records the ConfigMaps and Secrets each CR
+// references values from, and watches them so that a change to one of them
+// triggers a reconcile of the CRs that reference it.
+func watchValuesReferences(r *HelmOperatorReconciler, c controller.Controller) {
+	var m sync.RWMutex
+	referrers := map[valuesObject]map[types.NamespacedName]struct{}{}
+	references := map[types.NamespacedName][]valuesObject{}
+
+	enqueueReferrers := func(kind string) crthandler.EventHandler {
+		return crthandler.EnqueueRequestsFromMapFunc(func(obj crclient.Object) []reconcile.Request {
+			m.RLock()
+			defer m.RUnlock()
+			var requests []reconcile.Request
+			for cr := range referrers[valuesObject{kind: kind, key: crclient.ObjectKeyFromObject(obj)}] {
+				requests = append(requests, reconcile.Request{NamespacedName: cr})
+			}
+			return requests
+		})
+	}
+
+	var watchMu sync.Mutex
+	watches := map[string]struct{}{}
+	watch := func(kind string) error {
+		watchMu.Lock()
+		defer watchMu.Unlock()
+		if _, ok := watches[kind]; ok {
+			return nil
+		}
+		// Only the metadata of the objects is cached, since the values are
+		// read with the API reader, and caching the data of every Secret the
+		// operator can read would needlessly expose it and grow its memory.
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(kind))
+		if err := c.Watch(&source.Kind{Type: obj}, enqueueReferrers(kind)); err != nil {
+			return err
+		}
+		watches[kind] = struct{}{}
+		log.Info("Watching values references", "ownerApiVersion", r.GVK.GroupVersion(),
+			"ownerKind", r.GVK.Kind, "kind", kind)
+		return nil
+	}
+
+	r.valuesHook = func(cr types.NamespacedName, refs []release.ValuesReference) error {
+		objs := make([]valuesObject, 0, len(refs))
+		for _, ref := range refs {
+			if err := watch(ref.Kind); err != nil {
+				return err
+			}
+			objs = append(objs, valuesObject{kind: ref.Kind, key: types.NamespacedName{Namespace: cr.Namespace, Name: ref.Name}})
+		}
+
+		m.Lock()
+		defer m.Unlock()
+		for _, obj := range references[cr] {
+			delete(referrers[obj], cr)
+			if len(referrers[obj]) == 0 {
+				delete(referrers, obj)
+			}
+		}
+		if len(objs) == 0 {
+			delete(references, cr)
+			return nil
+		}
+		references[cr] = objs
+		for _, obj := range objs {
+			if referrers[obj] == nil {
+				referrers[obj] = map[types.NamespacedName]struct{}{}
+			}
+			referrers[obj][cr] = struct{}{}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	crthandler "sigs.k8s.io/controller-runtime/pkg/handler"
+	ctrlpredicate "sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/operator-framework/operator-sdk/internal/helm/release"
+)
+
+// fakeController records the sources and event handlers of its watches.
+type fakeController struct {
+	controller.Controller
+	sources  []source.Source
+	handlers []crthandler.EventHandler
+}
+
+func (c *fakeController) Watch(src source.Source, h crthandler.EventHandler, _ ...ctrlpredicate.Predicate) error {
+	c.sources = append(c.sources, src)
+	c.handlers = append(c.handlers, h)
+	return nil
+}
+
+func TestWatchValuesReferences(t *testing.T) {
+	c := &fakeController{}
+	r := &HelmOperatorReconciler{}
+	watchValuesReferences(r, c)
+
+	a := types.NamespacedName{Namespace: "ns", Name: "a"}
+	b := types.NamespacedName{Namespace: "ns", Name: "b"}
+	db := release.ValuesReference{Kind: release.ValuesKindSecret, Name: "db", Key: release.DefaultValuesKey}
+	assert.NoError(t, r.valuesHook(a, []release.ValuesReference{db}))
+	assert.NoError(t, r.valuesHook(b, []release.ValuesReference{db}))
+	// Secrets are only watched once, and only their metadata is cached.
+	assert.Len(t, c.handlers, 1)
+	watched, ok := c.sources[0].(*source.Kind).Type.(*metav1.PartialObjectMetadata)
+	if assert.True(t, ok) {
+		assert.Equal(t, corev1.SchemeGroupVersion.WithKind("Secret"), watched.GroupVersionKind())
+	}
+
+	secret := func(namespace, name string) *metav1.PartialObjectMetadata {
+		return &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	enqueued := func(obj *metav1.PartialObjectMetadata) []interface{} {
+		q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		c.handlers[0].Update(event.UpdateEvent{ObjectOld: obj, ObjectNew: obj}, q)
+		var items []interface{}
+		for q.Len() > 0 {
+			item, _ := q.Get()
+			items = append(items, item)
+		}
+		return items
+	}
+	assert.Len(t, enqueued(secret("ns", "db")), 2)
+	assert.Len(t, enqueued(secret("other", "db")), 0)
+
+	// CRs that no longer reference the Secret are not reconciled anymore.
+	assert.NoError(t, r.valuesHook(a, nil))
+	assert.NoError(t, r.valuesHook(b, nil))
+	assert.Len(t, enqueued(secret("ns", "db")), 0)
+}
+
+func TestSelectorPredicate(t *testing.T) {
+	p := selectorPredicate(labels.SelectorFromSet(labels.Set{"tenant": "a"}))
+
+	matching := &unstructured.Unstructured{}
+	matching.SetLabels(map[string]string{"tenant": "a"})
+	other := &unstructured.Unstructured{}
+	other.SetLabels(map[string]string{"tenant": "b"})
+	assert.True(t, p.Create(event.CreateEvent{Object: matching}))
+	assert.False(t, p.Create(event.CreateEvent{Object: other}))
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: matching, ObjectNew: other}))
+
+	// A CR relabeled out of the selector and then deleted must still have
+	// its release uninstalled, by the watch that installed it only.
+	deleted := other.DeepCopy()
+	now := metav1.Now()
+	deleted.SetDeletionTimestamp(&now)
+	deleted.SetFinalizers([]string{uninstallFinalizer})
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: other, ObjectNew: deleted}))
+	deleted.SetAnnotations(map[string]string{releaseSelectorAnnotation: "tenant=a"})
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: other, ObjectNew: deleted}))
+	assert.True(t, p.Delete(event.DeleteEvent{Object: other}))
+	deleted.SetAnnotations(map[string]string{releaseSelectorAnnotation: "tenant=c"})
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: other, ObjectNew: deleted}))
+
+	deleted.SetAnnotations(map[string]string{releaseSelectorAnnotation: "tenant=a"})
+	deleted.SetFinalizers(nil)
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: other, ObjectNew: deleted}))
+}
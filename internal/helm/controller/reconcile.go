@@ -25,7 +25,9 @@ import (
 	"helm.sh/helm/v3/pkg/storage/driver"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	apitypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
@@ -35,7 +37,9 @@ import (
 
 	"github.com/operator-framework/operator-sdk/internal/helm/internal/diff"
 	"github.com/operator-framework/operator-sdk/internal/helm/internal/types"
+	"github.com/operator-framework/operator-sdk/internal/helm/metrics"
 	"github.com/operator-framework/operator-sdk/internal/helm/release"
+	"github.com/operator-framework/operator-sdk/internal/helm/watches"
 )
 
 // blank assignment to verify that HelmOperatorReconciler implements reconcile.Reconciler
@@ -44,6 +48,11 @@ var _ reconcile.Reconciler = &HelmOperatorReconciler{}
 // ReleaseHookFunc defines a function signature for release hooks.
 type ReleaseHookFunc func(*rpb.Release) error
 
+// ValuesHookFunc defines a function signature for hooks that are called with
+// the values references of a CR before it is reconciled, and without
+// references once it is gone.
+type ValuesHookFunc func(apitypes.NamespacedName, []release.ValuesReference) error
+
 // HelmOperatorReconciler reconciles custom resources as Helm releases.
 type HelmOperatorReconciler struct {
 	Client          client.Client
@@ -52,7 +61,15 @@ type HelmOperatorReconciler struct {
 	ManagerFactory  release.ManagerFactory
 	ReconcilePeriod time.Duration
 	OverrideValues  map[string]string
+	WaitForReady    bool
+	WaitTimeout     time.Duration
+	DriftPolicy     string
 	releaseHook     ReleaseHookFunc
+	valuesHook      ValuesHookFunc
+
+	// Selector, if set, restricts the reconciled CRs to those with matching
+	// labels. It also applies to CRs enqueued by their dependent resources.
+	Selector labels.Selector
 }
 
 const (
@@ -61,16 +78,49 @@ const (
 	// Deprecated: use uninstallFinalizer. This will be removed in operator-sdk v2.0.0.
 	uninstallFinalizerLegacy = "uninstall-helm-release"
 
-	helmUpgradeForceAnnotation  = "helm.sdk.operatorframework.io/upgrade-force"
-	helmUninstallWaitAnnotation = "helm.sdk.operatorframework.io/uninstall-wait"
+	helmUpgradeForceAnnotation    = "helm.sdk.operatorframework.io/upgrade-force"
+	helmUpgradeRollbackAnnotation = "helm.sdk.operatorframework.io/upgrade-rollback"
+	helmUpgradeTimeoutAnnotation  = "helm.sdk.operatorframework.io/upgrade-timeout"
+	helmUninstallWaitAnnotation   = "helm.sdk.operatorframework.io/uninstall-wait"
+	helmWaitForReadyAnnotation    = "helm.sdk.operatorframework.io/wait-for-ready"
+	helmWaitTimeoutAnnotation     = "helm.sdk.operatorframework.io/wait-timeout"
+
+	// releaseSelectorAnnotation is set along with the finalizer of a CR to
+	// the selector of the watch that manages its release, so that only that
+	// watch uninstalls the release if the CR no longer matches it.
+	releaseSelectorAnnotation = "helm.sdk.operatorframework.io/release-selector"
+
+	// defaultUpgradeTimeout is how long an upgraded release with rollback
+	// enabled may take to become ready before it is rolled back.
+	defaultUpgradeTimeout = 5 * time.Minute
+	// defaultWaitTimeout is how long to wait for a release to become ready
+	// when waiting for readiness is enabled.
+	defaultWaitTimeout = 5 * time.Minute
+	// waitPollInterval is how often the readiness of a release is checked
+	// while it is progressing.
+	waitPollInterval = 5 * time.Second
 )
 
 // Reconcile reconciles the requested resource by installing, updating, or
 // uninstalling a Helm release based on the resource's current state. If no
 // release changes are necessary, Reconcile will create or patch the underlying
 // resources to match the expected release manifest.
+func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	gvk := r.GVK.String()
+	timer := metrics.ReconcileTimer(gvk)
+	defer timer.ObserveDuration()
+
+	result, err := r.reconcile(ctx, request)
+	if err != nil {
+		metrics.ReconcileFailed(gvk)
+	} else {
+		metrics.ReconcileSucceeded(gvk)
+	}
+	return result, err
+}
 
-func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) { //nolint:gocyclo
+func (r HelmOperatorReconciler) reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) { //nolint:gocyclo
+	gvk, key := r.GVK.String(), request.NamespacedName.String()
 	o := &unstructured.Unstructured{}
 	o.SetGroupVersionKind(r.GVK)
 	o.SetNamespace(request.Namespace)
@@ -85,12 +135,29 @@ func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile
 
 	err := r.Client.Get(ctx, request.NamespacedName, o)
 	if apierrors.IsNotFound(err) {
-		return reconcile.Result{}, nil
+		metrics.ReleaseRemoved(gvk, key)
+		return reconcile.Result{}, r.runValuesHook(request.NamespacedName, nil)
 	}
 	if err != nil {
 		log.Error(err, "Failed to lookup resource")
 		return reconcile.Result{}, err
 	}
+	if r.Selector != nil && !r.Selector.Matches(labels.Set(o.GetLabels())) &&
+		!(pendingUninstall(o) && managesRelease(r.Selector, o)) {
+		log.V(1).Info("Ignoring resource that does not match the selector of the watch")
+		metrics.ReleaseRemoved(gvk, key)
+		return reconcile.Result{}, r.runValuesHook(request.NamespacedName, nil)
+	}
+
+	valuesRefs, err := release.ValuesReferences(o)
+	if err != nil {
+		log.Error(err, "Failed to get values references")
+		return reconcile.Result{}, err
+	}
+	if err := r.runValuesHook(request.NamespacedName, valuesRefs); err != nil {
+		log.Error(err, "Failed to watch values references")
+		return reconcile.Result{}, err
+	}
 
 	manager, err := r.ManagerFactory.NewManager(o, r.OverrideValues)
 	if err != nil {
@@ -106,12 +173,17 @@ func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile
 			controllerutil.ContainsFinalizer(o, uninstallFinalizerLegacy)) {
 
 			log.Info("Resource is terminated, skipping reconciliation")
+			metrics.ReleaseRemoved(gvk, key)
 			return reconcile.Result{}, nil
 		}
 
 		uninstalledRelease, err := manager.UninstallRelease(ctx)
+		if !errors.Is(err, driver.ErrReleaseNotFound) {
+			metrics.ReleaseOperation(gvk, metrics.OperationUninstall)
+		}
 		if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
 			log.Error(err, "Failed to uninstall release")
+			metrics.ReleaseOperationFailed(gvk, metrics.OperationUninstall, string(types.ReasonUninstallError))
 			status.SetCondition(types.HelmAppCondition{
 				Type:    types.ConditionReleaseFailed,
 				Status:  types.StatusTrue,
@@ -160,6 +232,7 @@ func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile
 			isAllResourcesDeleted, err := manager.CleanupRelease(ctx, status.DeployedRelease.Manifest)
 			if err != nil {
 				log.Error(err, "Failed to cleanup release")
+				metrics.ReleaseOperationFailed(gvk, metrics.OperationUninstall, string(types.ReasonUninstallError))
 				status.SetCondition(types.HelmAppCondition{
 					Type:    types.ConditionReleaseFailed,
 					Status:  types.StatusTrue,
@@ -183,6 +256,7 @@ func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile
 			log.Info("Failed to remove CR uninstall finalizer")
 			return reconcile.Result{}, err
 		}
+		metrics.ReleaseRemoved(gvk, key)
 
 		// Since the client is hitting a cache, waiting for the
 		// deletion here will guarantee that the next reconciliation
@@ -201,6 +275,21 @@ func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile
 		Status: types.StatusTrue,
 	})
 
+	rollbackTimeout, err := r.upgradeRollback(o)
+	if err != nil {
+		log.Error(err, "Failed to get upgrade rollback configuration")
+		status.SetCondition(types.HelmAppCondition{
+			Type:    types.ConditionIrreconcilable,
+			Status:  types.StatusTrue,
+			Reason:  types.ReasonReconcileError,
+			Message: err.Error(),
+		})
+		if err := r.updateResourceStatus(ctx, o, status); err != nil {
+			log.Error(err, "Failed to update status after upgrade rollback configuration failure")
+		}
+		return reconcile.Result{}, err
+	}
+
 	if err := manager.Sync(ctx); err != nil {
 		log.Error(err, "Failed to sync release")
 		status.SetCondition(types.HelmAppCondition{
@@ -221,9 +310,11 @@ func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile
 			r.EventRecorder.Eventf(o, "Warning", "OverrideValuesInUse",
 				"Chart value %q overridden to %q by operator's watches.yaml", k, v)
 		}
+		metrics.ReleaseOperation(gvk, metrics.OperationInstall)
 		installedRelease, err := manager.InstallRelease(ctx)
 		if err != nil {
 			log.Error(err, "Release failed")
+			metrics.ReleaseOperationFailed(gvk, metrics.OperationInstall, string(types.ReasonInstallError))
 			status.SetCondition(types.HelmAppCondition{
 				Type:    types.ConditionReleaseFailed,
 				Status:  types.StatusTrue,
@@ -236,9 +327,11 @@ func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile
 			return reconcile.Result{}, err
 		}
 		status.RemoveCondition(types.ConditionReleaseFailed)
+		metrics.ReleaseManaged(gvk, key)
 
 		log.V(1).Info("Adding finalizer", "finalizer", uninstallFinalizer)
 		controllerutil.AddFinalizer(o, uninstallFinalizer)
+		r.setReleaseSelector(o)
 		if err := r.updateResource(ctx, o); err != nil {
 			log.Info("Failed to add CR uninstall finalizer")
 			return reconcile.Result{}, err
@@ -255,49 +348,57 @@ func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile
 		if log.V(0).Enabled() {
 			fmt.Println(diff.Generate("", installedRelease.Manifest))
 		}
-		log.V(1).Info("Config values", "values", installedRelease.Config)
-		message := ""
-		if installedRelease.Info != nil {
-			message = installedRelease.Info.Notes
+		if len(valuesRefs) == 0 {
+			log.V(1).Info("Config values", "values", installedRelease.Config)
 		}
-		status.SetCondition(types.HelmAppCondition{
-			Type:    types.ConditionDeployed,
-			Status:  types.StatusTrue,
-			Reason:  types.ReasonInstallSuccessful,
-			Message: message,
-		})
-		status.DeployedRelease = &types.HelmAppRelease{
-			Name:     installedRelease.Name,
-			Manifest: installedRelease.Manifest,
+		result, err := r.setDeployed(ctx, o, manager, status, installedRelease, types.ReasonInstallSuccessful,
+			true, rollbackTimeout)
+		if err := r.updateResourceStatus(ctx, o, status); err != nil {
+			return reconcile.Result{}, err
 		}
-		err = r.updateResourceStatus(ctx, o, status)
-		return reconcile.Result{RequeueAfter: r.ReconcilePeriod}, err
+		return result, err
 	}
+	metrics.ReleaseManaged(gvk, key)
 
-	if !(controllerutil.ContainsFinalizer(o, uninstallFinalizer) ||
-		controllerutil.ContainsFinalizer(o, uninstallFinalizerLegacy)) {
-
+	addFinalizer := !(controllerutil.ContainsFinalizer(o, uninstallFinalizer) ||
+		controllerutil.ContainsFinalizer(o, uninstallFinalizerLegacy))
+	if addFinalizer {
 		log.V(1).Info("Adding finalizer", "finalizer", uninstallFinalizer)
 		controllerutil.AddFinalizer(o, uninstallFinalizer)
+	}
+	// CRs relabeled into this watch, or installed before the annotation
+	// existed, are annotated when they are next reconciled.
+	if r.setReleaseSelector(o) || addFinalizer {
 		if err := r.updateResource(ctx, o); err != nil {
 			log.Info("Failed to add CR uninstall finalizer")
 			return reconcile.Result{}, err
 		}
 	}
 
-	if manager.IsUpgradeRequired() {
+	// An upgrade that was rolled back because it did not become ready is not
+	// retried until the chart or the values of the release change.
+	if manager.IsUpgradeRequired() && !(rollbackTimeout > 0 && manager.IsUpgradeRolledBack()) {
 		for k, v := range r.OverrideValues {
 			r.EventRecorder.Eventf(o, "Warning", "OverrideValuesInUse",
 				"Chart value %q overridden to %q by operator's watches.yaml", k, v)
 		}
 		force := hasAnnotation(helmUpgradeForceAnnotation, o)
+		metrics.ReleaseOperation(gvk, metrics.OperationUpgrade)
 		previousRelease, upgradedRelease, err := manager.UpgradeRelease(ctx, release.ForceUpgrade(force))
 		if err != nil {
 			log.Error(err, "Release failed")
+			reason := types.ReasonUpgradeError
+			var rollbackErr *release.RollbackError
+			if errors.As(err, &rollbackErr) {
+				reason = types.ReasonRolledBack
+				r.EventRecorder.Eventf(o, "Warning", string(types.ReasonRolledBack),
+					"Upgrade failed, rolled back to revision %d: %v", rollbackErr.Revision, rollbackErr.Err)
+			}
+			metrics.ReleaseOperationFailed(gvk, metrics.OperationUpgrade, string(reason))
 			status.SetCondition(types.HelmAppCondition{
 				Type:    types.ConditionReleaseFailed,
 				Status:  types.StatusTrue,
-				Reason:  types.ReasonUpgradeError,
+				Reason:  reason,
 				Message: err.Error(),
 			})
 			if err := r.updateResourceStatus(ctx, o, status); err != nil {
@@ -318,23 +419,15 @@ func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile
 		if log.V(0).Enabled() {
 			fmt.Println(diff.Generate(previousRelease.Manifest, upgradedRelease.Manifest))
 		}
-		log.V(1).Info("Config values", "values", upgradedRelease.Config)
-		message := ""
-		if upgradedRelease.Info != nil {
-			message = upgradedRelease.Info.Notes
+		if len(valuesRefs) == 0 {
+			log.V(1).Info("Config values", "values", upgradedRelease.Config)
 		}
-		status.SetCondition(types.HelmAppCondition{
-			Type:    types.ConditionDeployed,
-			Status:  types.StatusTrue,
-			Reason:  types.ReasonUpgradeSuccessful,
-			Message: message,
-		})
-		status.DeployedRelease = &types.HelmAppRelease{
-			Name:     upgradedRelease.Name,
-			Manifest: upgradedRelease.Manifest,
+		result, err := r.setDeployed(ctx, o, manager, status, upgradedRelease, types.ReasonUpgradeSuccessful,
+			true, rollbackTimeout)
+		if err := r.updateResourceStatus(ctx, o, status); err != nil {
+			return reconcile.Result{}, err
 		}
-		err = r.updateResourceStatus(ctx, o, status)
-		return reconcile.Result{RequeueAfter: r.ReconcilePeriod}, err
+		return result, err
 	}
 
 	// If a change is made to the CR spec that causes a release failure, a
@@ -342,10 +435,13 @@ func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile
 	// is then reverted to its previous state, the operator will stop
 	// attempting the release and will resume reconciling. In this case, we
 	// need to remove the ConditionReleaseFailed because the failing release is
-	// no longer being attempted.
-	status.RemoveCondition(types.ConditionReleaseFailed)
+	// no longer being attempted. An upgrade that was rolled back is still
+	// desired, so its failure is kept.
+	if !manager.IsUpgradeRolledBack() {
+		status.RemoveCondition(types.ConditionReleaseFailed)
+	}
 
-	expectedRelease, err := manager.ReconcileRelease(ctx)
+	expectedRelease, err := r.reconcileRelease(ctx, o, manager, status)
 	if err != nil {
 		log.Error(err, "Failed to reconcile release")
 		status.SetCondition(types.HelmAppCondition{
@@ -373,22 +469,300 @@ func (r HelmOperatorReconciler) Reconcile(ctx context.Context, request reconcile
 	if expectedRelease.Version == 1 {
 		reason = types.ReasonInstallSuccessful
 	}
+	if manager.IsUpgradeRolledBack() {
+		reason = types.ReasonRolledBack
+	}
+	result, err := r.setDeployed(ctx, o, manager, status, expectedRelease, reason, false, rollbackTimeout)
+	if err := r.updateResourceStatus(ctx, o, status); err != nil {
+		return reconcile.Result{}, err
+	}
+	return result, err
+}
+
+// runValuesHook calls the values hook, if any, with the values references of
+// the CR with the given key.
+func (r HelmOperatorReconciler) runValuesHook(key apitypes.NamespacedName, refs []release.ValuesReference) error {
+	if r.valuesHook == nil {
+		return nil
+	}
+	return r.valuesHook(key, refs)
+}
+
+// reconcileRelease handles the resources of the deployed release that no
+// longer match its manifest according to the drift policy of the watch, and
+// returns the deployed release.
+func (r HelmOperatorReconciler) reconcileRelease(ctx context.Context, o *unstructured.Unstructured,
+	manager release.Manager, status *types.HelmAppStatus) (*rpb.Release, error) {
+	switch r.DriftPolicy {
+	case watches.DriftPolicyIgnore:
+		status.RemoveCondition(types.ConditionDrifted)
+		return manager.DeployedRelease(), nil
+	case watches.DriftPolicyReport:
+		rel, drifts, err := manager.DetectReleaseDrift(ctx)
+		if err != nil {
+			return nil, err
+		}
+		r.setDrifted(o, status, drifts)
+		return rel, nil
+	default:
+		status.RemoveCondition(types.ConditionDrifted)
+		return manager.ReconcileRelease(ctx)
+	}
+}
+
+// setDrifted reports drifts in the Drifted condition, and with an event when
+// they change, or removes the condition if there are none.
+func (r HelmOperatorReconciler) setDrifted(o *unstructured.Unstructured, status *types.HelmAppStatus,
+	drifts []release.Drift) {
+	if len(drifts) == 0 {
+		status.RemoveCondition(types.ConditionDrifted)
+		return
+	}
+	message := release.SummarizeDrift(drifts)
+	for _, c := range status.Conditions {
+		if c.Type == types.ConditionDrifted && c.Message == message {
+			return
+		}
+	}
+	log.Info("Release resources drifted", "namespace", o.GetNamespace(), "name", o.GetName(), "count", len(drifts))
+	r.EventRecorder.Event(o, "Warning", string(types.ReasonDriftDetected), message)
+	status.SetCondition(types.HelmAppCondition{
+		Type:    types.ConditionDrifted,
+		Status:  types.StatusTrue,
+		Reason:  types.ReasonDriftDetected,
+		Message: message,
+	})
+}
+
+// setDeployed records rel as the deployed release in status. If waiting for
+// readiness is enabled and the release's workloads are still rolling out, the
+// Deployed condition is False and the Progressing condition describes what is
+// not ready yet; otherwise, Deployed is True with the given reason. Readiness
+// is only awaited after rel was installed or upgraded by this reconcile, as
+// indicated by rollout, until it becomes ready or the wait times out. If
+// rollbackTimeout is positive, an upgraded release is always awaited, and is
+// rolled back if it is not ready within rollbackTimeout.
+func (r HelmOperatorReconciler) setDeployed(ctx context.Context, o *unstructured.Unstructured, manager release.Manager,
+	status *types.HelmAppStatus, rel *rpb.Release, reason types.HelmAppConditionReason, rollout bool,
+	rollbackTimeout time.Duration) (reconcile.Result, error) {
 	message := ""
-	if expectedRelease.Info != nil {
-		message = expectedRelease.Info.Notes
+	if rel.Info != nil {
+		message = rel.Info.Notes
 	}
+	status.DeployedRelease = &types.HelmAppRelease{
+		Name:     rel.Name,
+		Manifest: rel.Manifest,
+	}
+
+	wait, timeout := r.waitForReady(o)
+	rollback := rollbackTimeout > 0 && rel.Version > 1
+	if rollback {
+		wait, timeout = true, rollbackTimeout
+	}
+	if wait && (rollout || findCondition(status, types.ConditionProgressing) != nil) {
+		ready, pending, err := manager.CheckReleaseReady(ctx, rel.Manifest)
+		if err != nil {
+			log.Error(err, "Failed to check release readiness")
+			status.SetCondition(types.HelmAppCondition{
+				Type:    types.ConditionIrreconcilable,
+				Status:  types.StatusTrue,
+				Reason:  types.ReasonReconcileError,
+				Message: err.Error(),
+			})
+			return reconcile.Result{}, err
+		}
+		if !ready {
+			if rollback && progressTimedOut(status, timeout, rollout) {
+				return r.rollBack(ctx, o, manager, status, pending, timeout)
+			}
+			return r.setProgressing(o, status, pending, timeout, rollout), nil
+		}
+	}
+
+	status.RemoveCondition(types.ConditionProgressing)
 	status.SetCondition(types.HelmAppCondition{
 		Type:    types.ConditionDeployed,
 		Status:  types.StatusTrue,
 		Reason:  reason,
 		Message: message,
 	})
+	return reconcile.Result{RequeueAfter: r.ReconcilePeriod}, nil
+}
+
+// setProgressing marks a release that is not ready yet as progressing, or as
+// failed once it has not become ready within timeout. The timeout is measured
+// from the transition of the Progressing condition to True, which is reset
+// when rollout is true.
+func (r HelmOperatorReconciler) setProgressing(o *unstructured.Unstructured, status *types.HelmAppStatus,
+	pending string, timeout time.Duration, rollout bool) reconcile.Result {
+	if rollout {
+		status.RemoveCondition(types.ConditionProgressing)
+	}
+
+	var elapsed time.Duration
+	timedOut := false
+	if c := findCondition(status, types.ConditionProgressing); c != nil {
+		if c.Status == types.StatusTrue {
+			elapsed = time.Since(c.LastTransitionTime.Time)
+		} else {
+			timedOut = c.Reason == types.ReasonWaitTimeout
+		}
+	}
+
+	if timedOut || elapsed >= timeout {
+		message := fmt.Sprintf("Release did not become ready within %s: %s", timeout, pending)
+		if !timedOut {
+			log.Info("Timed out waiting for release to become ready", "timeout", timeout, "pending", pending)
+			r.EventRecorder.Event(o, "Warning", string(types.ReasonWaitTimeout), message)
+		}
+		status.SetCondition(types.HelmAppCondition{
+			Type:    types.ConditionProgressing,
+			Status:  types.StatusFalse,
+			Reason:  types.ReasonWaitTimeout,
+			Message: message,
+		})
+		status.SetCondition(types.HelmAppCondition{
+			Type:    types.ConditionDeployed,
+			Status:  types.StatusFalse,
+			Reason:  types.ReasonWaitTimeout,
+			Message: message,
+		})
+		status.SetCondition(types.HelmAppCondition{
+			Type:    types.ConditionReleaseFailed,
+			Status:  types.StatusTrue,
+			Reason:  types.ReasonWaitTimeout,
+			Message: message,
+		})
+		return reconcile.Result{RequeueAfter: r.ReconcilePeriod}
+	}
+
+	log.Info("Waiting for release to become ready", "pending", pending)
+	status.SetCondition(types.HelmAppCondition{
+		Type:    types.ConditionProgressing,
+		Status:  types.StatusTrue,
+		Reason:  types.ReasonWaitingForResources,
+		Message: pending,
+	})
+	status.SetCondition(types.HelmAppCondition{
+		Type:    types.ConditionDeployed,
+		Status:  types.StatusFalse,
+		Reason:  types.ReasonWaitingForResources,
+		Message: pending,
+	})
+	requeueAfter := waitPollInterval
+	if remaining := timeout - elapsed; remaining < requeueAfter {
+		requeueAfter = remaining
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}
+}
+
+// progressTimedOut returns whether a release that is not ready yet has been
+// progressing for longer than timeout since it was installed or upgraded.
+func progressTimedOut(status *types.HelmAppStatus, timeout time.Duration, rollout bool) bool {
+	c := findCondition(status, types.ConditionProgressing)
+	if rollout || c == nil {
+		return false
+	}
+	if c.Status == types.StatusFalse {
+		return c.Reason == types.ReasonWaitTimeout
+	}
+	return time.Since(c.LastTransitionTime.Time) >= timeout
+}
+
+// rollBack rolls an upgraded release that did not become ready within timeout
+// back to its previous revision, and reports the failed upgrade.
+func (r HelmOperatorReconciler) rollBack(ctx context.Context, o *unstructured.Unstructured, manager release.Manager,
+	status *types.HelmAppStatus, pending string, timeout time.Duration) (reconcile.Result, error) {
+	gvk := r.GVK.String()
+	message := fmt.Sprintf("Release did not become ready within %s: %s", timeout, pending)
+	log.Info("Rolling back release that did not become ready", "timeout", timeout, "pending", pending)
+	metrics.ReleaseOperationFailed(gvk, metrics.OperationUpgrade, string(types.ReasonRolledBack))
+
+	revision, rolledBackRelease, err := manager.RollbackRelease(ctx)
+	if err != nil {
+		log.Error(err, "Failed to roll back release")
+		status.SetCondition(types.HelmAppCondition{
+			Type:    types.ConditionReleaseFailed,
+			Status:  types.StatusTrue,
+			Reason:  types.ReasonUpgradeError,
+			Message: fmt.Sprintf("%s, and failed to roll back: %v", message, err),
+		})
+		return reconcile.Result{}, err
+	}
+
+	message = fmt.Sprintf("Upgrade rolled back to revision %d: %s", revision, message)
+	r.EventRecorder.Event(o, "Warning", string(types.ReasonRolledBack), message)
 	status.DeployedRelease = &types.HelmAppRelease{
-		Name:     expectedRelease.Name,
-		Manifest: expectedRelease.Manifest,
+		Name:     rolledBackRelease.Name,
+		Manifest: rolledBackRelease.Manifest,
 	}
-	err = r.updateResourceStatus(ctx, o, status)
-	return reconcile.Result{RequeueAfter: r.ReconcilePeriod}, err
+	status.RemoveCondition(types.ConditionProgressing)
+	status.SetCondition(types.HelmAppCondition{
+		Type:    types.ConditionReleaseFailed,
+		Status:  types.StatusTrue,
+		Reason:  types.ReasonRolledBack,
+		Message: message,
+	})
+	status.SetCondition(types.HelmAppCondition{
+		Type:    types.ConditionDeployed,
+		Status:  types.StatusTrue,
+		Reason:  types.ReasonRolledBack,
+		Message: message,
+	})
+	return reconcile.Result{RequeueAfter: r.ReconcilePeriod}, nil
+}
+
+// findCondition returns the condition of status with the given type, or nil.
+func findCondition(status *types.HelmAppStatus, conditionType types.HelmAppConditionType) *types.HelmAppCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// waitForReady returns whether the release of o must be ready before it is
+// reported as deployed, and how long to wait for it. The CR's annotations
+// take precedence over the reconciler's settings.
+func (r HelmOperatorReconciler) waitForReady(o *unstructured.Unstructured) (bool, time.Duration) {
+	wait := r.WaitForReady
+	if boolStr, ok := o.GetAnnotations()[helmWaitForReadyAnnotation]; ok {
+		if value, err := strconv.ParseBool(boolStr); err != nil {
+			log.Info("Could not parse annotation as a boolean",
+				"annotation", helmWaitForReadyAnnotation, "value informed", boolStr)
+		} else {
+			wait = value
+		}
+	}
+
+	timeout := r.WaitTimeout
+	if timeout <= 0 {
+		timeout = defaultWaitTimeout
+	}
+	return wait, durationAnnotation(helmWaitTimeoutAnnotation, o, timeout)
+}
+
+// upgradeRollback returns how long an upgraded release of o may take to become
+// ready before it is rolled back, or 0 if upgrades are not rolled back. The
+// upgrade-rollback and upgrade-timeout annotations take precedence over the
+// upgradeRollback field of the spec.
+func (r HelmOperatorReconciler) upgradeRollback(o *unstructured.Unstructured) (time.Duration, error) {
+	spec, err := release.UpgradeRollbackFor(o)
+	if err != nil {
+		return 0, err
+	}
+	enabled, timeout := false, defaultUpgradeTimeout
+	if spec != nil {
+		enabled = spec.Enabled
+		if spec.Timeout != nil {
+			timeout = spec.Timeout.Duration
+		}
+	}
+	if !boolAnnotation(helmUpgradeRollbackAnnotation, o, enabled) {
+		return 0, nil
+	}
+	return durationAnnotation(helmUpgradeTimeoutAnnotation, o, timeout), nil
 }
 
 // returns the boolean representation of the annotation string
@@ -408,6 +782,70 @@ func hasAnnotation(anno string, o *unstructured.Unstructured) bool {
 	return value
 }
 
+// setReleaseSelector records the selector of the watch in the annotations of
+// o, and returns whether they changed. Without a selector, every watch of the
+// GVK manages every release, so nothing is recorded.
+func (r HelmOperatorReconciler) setReleaseSelector(o *unstructured.Unstructured) bool {
+	if r.Selector == nil || r.Selector.Empty() {
+		return false
+	}
+	annotations := o.GetAnnotations()
+	if annotations[releaseSelectorAnnotation] == r.Selector.String() {
+		return false
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[releaseSelectorAnnotation] = r.Selector.String()
+	o.SetAnnotations(annotations)
+	return true
+}
+
+// managesRelease returns whether the release of o was last managed by the
+// watch with selector.
+func managesRelease(selector labels.Selector, o client.Object) bool {
+	return o.GetAnnotations()[releaseSelectorAnnotation] == selector.String()
+}
+
+// pendingUninstall returns whether o is being deleted and its release has not
+// been uninstalled yet.
+func pendingUninstall(o client.Object) bool {
+	return o.GetDeletionTimestamp() != nil && (controllerutil.ContainsFinalizer(o, uninstallFinalizer) ||
+		controllerutil.ContainsFinalizer(o, uninstallFinalizerLegacy))
+}
+
+// returns the boolean representation of the annotation string
+// will return def if annotation is not set or is not a boolean
+func boolAnnotation(anno string, o *unstructured.Unstructured, def bool) bool {
+	boolStr := o.GetAnnotations()[anno]
+	if boolStr == "" {
+		return def
+	}
+	value, err := strconv.ParseBool(boolStr)
+	if err != nil {
+		log.Info("Could not parse annotation as a boolean",
+			"annotation", anno, "value informed", boolStr)
+		return def
+	}
+	return value
+}
+
+// returns the duration representation of the annotation string
+// will return def if annotation is not set or is not a positive duration
+func durationAnnotation(anno string, o *unstructured.Unstructured, def time.Duration) time.Duration {
+	durationStr := o.GetAnnotations()[anno]
+	if durationStr == "" {
+		return def
+	}
+	value, err := time.ParseDuration(durationStr)
+	if err != nil || value <= 0 {
+		log.Info("Could not parse annotation as a positive duration",
+			"annotation", anno, "value informed", durationStr)
+		return def
+	}
+	return value
+}
+
 func (r HelmOperatorReconciler) updateResource(ctx context.Context, o client.Object) error {
 	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		return r.Client.Update(ctx, o)
@@ -15,10 +15,25 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	rpb "helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/operator-framework/operator-sdk/internal/helm/internal/types"
+	"github.com/operator-framework/operator-sdk/internal/helm/release"
 )
 
 func TestHasAnnotation(t *testing.T) {
@@ -131,6 +146,334 @@ func TestHasAnnotation(t *testing.T) {
 	}
 }
 
+func TestBoolAnnotation(t *testing.T) {
+	upgradeRollbackTests := []struct {
+		input       map[string]interface{}
+		expectedVal bool
+		name        string
+	}{
+		{
+			input: map[string]interface{}{
+				"helm.sdk.operatorframework.io/upgrade-rollback": "false",
+			},
+			expectedVal: false,
+			name:        "upgrade rollback disabled",
+		},
+		{
+			input: map[string]interface{}{
+				"helm.sdk.operatorframework.io/upgrade-rollback": "true",
+			},
+			expectedVal: true,
+			name:        "upgrade rollback enabled",
+		},
+		{
+			input: map[string]interface{}{
+				"helm.sdk.operatorframework.io/wrong-annotation": "true",
+			},
+			expectedVal: false,
+			name:        "upgrade rollback annotation not set",
+		},
+		{
+			input: map[string]interface{}{
+				"helm.sdk.operatorframework.io/upgrade-rollback": "invalid",
+			},
+			expectedVal: false,
+			name:        "upgrade rollback invalid value",
+		},
+	}
+
+	for _, test := range upgradeRollbackTests {
+		assert.Equal(t, test.expectedVal, boolAnnotation(helmUpgradeRollbackAnnotation, annotations(test.input), false), test.name)
+	}
+}
+
+func TestUpgradeRollback(t *testing.T) {
+	r := HelmOperatorReconciler{}
+	o := annotations(map[string]interface{}{})
+
+	// Rollback is opt-in.
+	timeout, err := r.upgradeRollback(o)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), timeout)
+
+	o.Object["spec"] = map[string]interface{}{
+		"upgradeRollback": map[string]interface{}{"enabled": true, "timeout": "10m"},
+	}
+	timeout, err = r.upgradeRollback(o)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, timeout)
+
+	// The annotations take precedence over the spec.
+	o.SetAnnotations(map[string]string{"helm.sdk.operatorframework.io/upgrade-timeout": "1m"})
+	timeout, err = r.upgradeRollback(o)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, timeout)
+	o.SetAnnotations(map[string]string{"helm.sdk.operatorframework.io/upgrade-rollback": "false"})
+	timeout, err = r.upgradeRollback(o)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), timeout)
+
+	o = annotations(map[string]interface{}{"helm.sdk.operatorframework.io/upgrade-rollback": "true"})
+	timeout, err = r.upgradeRollback(o)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultUpgradeTimeout, timeout)
+
+	o.Object["spec"] = map[string]interface{}{"upgradeRollback": "invalid"}
+	_, err = r.upgradeRollback(o)
+	assert.Error(t, err)
+}
+
+func TestDurationAnnotation(t *testing.T) {
+	upgradeTimeoutTests := []struct {
+		input       map[string]interface{}
+		expectedVal time.Duration
+		name        string
+	}{
+		{
+			input: map[string]interface{}{
+				"helm.sdk.operatorframework.io/upgrade-timeout": "10m",
+			},
+			expectedVal: 10 * time.Minute,
+			name:        "upgrade timeout base case",
+		},
+		{
+			input: map[string]interface{}{
+				"helm.sdk.operatorframework.io/wrong-annotation": "10m",
+			},
+			expectedVal: defaultUpgradeTimeout,
+			name:        "upgrade timeout annotation not set",
+		},
+		{
+			input: map[string]interface{}{
+				"helm.sdk.operatorframework.io/upgrade-timeout": "-1m",
+			},
+			expectedVal: defaultUpgradeTimeout,
+			name:        "upgrade timeout negative value",
+		},
+		{
+			input: map[string]interface{}{
+				"helm.sdk.operatorframework.io/upgrade-timeout": "invalid",
+			},
+			expectedVal: defaultUpgradeTimeout,
+			name:        "upgrade timeout invalid value",
+		},
+	}
+
+	for _, test := range upgradeTimeoutTests {
+		assert.Equal(t, test.expectedVal, durationAnnotation(helmUpgradeTimeoutAnnotation, annotations(test.input), defaultUpgradeTimeout), test.name)
+	}
+}
+
+func TestWaitForReady(t *testing.T) {
+	r := HelmOperatorReconciler{WaitForReady: true, WaitTimeout: time.Minute}
+
+	wait, timeout := r.waitForReady(annotations(map[string]interface{}{}))
+	assert.True(t, wait)
+	assert.Equal(t, time.Minute, timeout)
+
+	wait, timeout = r.waitForReady(annotations(map[string]interface{}{
+		"helm.sdk.operatorframework.io/wait-for-ready": "false",
+		"helm.sdk.operatorframework.io/wait-timeout":   "10m",
+	}))
+	assert.False(t, wait)
+	assert.Equal(t, 10*time.Minute, timeout)
+
+	r = HelmOperatorReconciler{}
+	wait, timeout = r.waitForReady(annotations(map[string]interface{}{
+		"helm.sdk.operatorframework.io/wait-for-ready": "true",
+	}))
+	assert.True(t, wait)
+	assert.Equal(t, defaultWaitTimeout, timeout)
+
+	wait, _ = r.waitForReady(annotations(map[string]interface{}{
+		"helm.sdk.operatorframework.io/wait-for-ready": "invalid",
+	}))
+	assert.False(t, wait)
+}
+
+func TestSetProgressing(t *testing.T) {
+	recorder := record.NewFakeRecorder(2)
+	r := HelmOperatorReconciler{EventRecorder: recorder, ReconcilePeriod: time.Minute}
+	o := annotations(map[string]interface{}{})
+	pending := "Waiting for Job \"test\" to complete"
+
+	status := &types.HelmAppStatus{}
+	result := r.setProgressing(o, status, pending, time.Hour, true)
+	assert.Equal(t, waitPollInterval, result.RequeueAfter)
+	assert.Equal(t, types.StatusTrue, conditionStatus(status, types.ConditionProgressing))
+	assert.Equal(t, types.StatusFalse, conditionStatus(status, types.ConditionDeployed))
+	assert.Equal(t, types.ConditionStatus(""), conditionStatus(status, types.ConditionReleaseFailed))
+
+	// The timeout is measured from the start of the rollout.
+	findCondition(status, types.ConditionProgressing).LastTransitionTime = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	result = r.setProgressing(o, status, pending, time.Hour, false)
+	assert.Equal(t, r.ReconcilePeriod, result.RequeueAfter)
+	assert.Equal(t, types.StatusFalse, conditionStatus(status, types.ConditionProgressing))
+	assert.Equal(t, types.StatusFalse, conditionStatus(status, types.ConditionDeployed))
+	assert.Equal(t, types.StatusTrue, conditionStatus(status, types.ConditionReleaseFailed))
+	assert.Len(t, recorder.Events, 1)
+
+	// A timeout is not reported again.
+	status.RemoveCondition(types.ConditionReleaseFailed)
+	r.setProgressing(o, status, pending, time.Hour, false)
+	assert.Equal(t, types.StatusTrue, conditionStatus(status, types.ConditionReleaseFailed))
+	assert.Len(t, recorder.Events, 1)
+
+	// A new rollout restarts the timeout.
+	result = r.setProgressing(o, status, pending, time.Hour, true)
+	assert.Equal(t, waitPollInterval, result.RequeueAfter)
+	assert.Equal(t, types.StatusTrue, conditionStatus(status, types.ConditionProgressing))
+}
+
+// rollbackManager is a release manager whose release did not become ready, and
+// that rolls it back to revision 1.
+type rollbackManager struct {
+	release.Manager
+	rollbacks int
+}
+
+func (m *rollbackManager) CheckReleaseReady(context.Context, string) (bool, string, error) {
+	return false, "Waiting for Deployment \"test\" to be ready", nil
+}
+
+func (m *rollbackManager) RollbackRelease(context.Context) (int, *rpb.Release, error) {
+	m.rollbacks++
+	return 1, &rpb.Release{Name: "test", Version: 3, Manifest: "rolled back"}, nil
+}
+
+func TestSetDeployedRollsBack(t *testing.T) {
+	recorder := record.NewFakeRecorder(2)
+	r := HelmOperatorReconciler{EventRecorder: recorder, ReconcilePeriod: time.Minute}
+	o := annotations(map[string]interface{}{})
+	m := &rollbackManager{}
+	upgraded := &rpb.Release{Name: "test", Version: 2}
+
+	// An upgraded release is awaited without waitForReady when it may be
+	// rolled back.
+	status := &types.HelmAppStatus{}
+	result, err := r.setDeployed(context.TODO(), o, m, status, upgraded, types.ReasonUpgradeSuccessful, true, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, waitPollInterval, result.RequeueAfter)
+	assert.Equal(t, types.StatusTrue, conditionStatus(status, types.ConditionProgressing))
+	assert.Equal(t, 0, m.rollbacks)
+
+	findCondition(status, types.ConditionProgressing).LastTransitionTime = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	result, err = r.setDeployed(context.TODO(), o, m, status, upgraded, types.ReasonUpgradeSuccessful, false, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, r.ReconcilePeriod, result.RequeueAfter)
+	assert.Equal(t, 1, m.rollbacks)
+	assert.Equal(t, "rolled back", status.DeployedRelease.Manifest)
+	assert.Nil(t, findCondition(status, types.ConditionProgressing))
+	assert.Equal(t, types.ReasonRolledBack, findCondition(status, types.ConditionReleaseFailed).Reason)
+	assert.Equal(t, types.ReasonRolledBack, findCondition(status, types.ConditionDeployed).Reason)
+	assert.Len(t, recorder.Events, 1)
+
+	// An installed release is not rolled back.
+	status = &types.HelmAppStatus{}
+	installed := &rpb.Release{Name: "test", Version: 1}
+	_, err = r.setDeployed(context.TODO(), o, m, status, installed, types.ReasonInstallSuccessful, true, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, types.StatusTrue, conditionStatus(status, types.ConditionDeployed))
+}
+
+func TestSetDrifted(t *testing.T) {
+	recorder := record.NewFakeRecorder(2)
+	r := HelmOperatorReconciler{EventRecorder: recorder}
+	o := annotations(map[string]interface{}{})
+	drifts := []release.Drift{{Kind: "Deployment", Namespace: "ns", Name: "app", Fields: []string{"spec.replicas"}}}
+
+	status := &types.HelmAppStatus{}
+	r.setDrifted(o, status, drifts)
+	assert.Equal(t, types.StatusTrue, conditionStatus(status, types.ConditionDrifted))
+	assert.Len(t, recorder.Events, 1)
+
+	// The same drift is not reported again.
+	r.setDrifted(o, status, drifts)
+	assert.Len(t, recorder.Events, 1)
+
+	r.setDrifted(o, status, nil)
+	assert.Equal(t, types.ConditionStatus(""), conditionStatus(status, types.ConditionDrifted))
+}
+
+// errManagerFactory counts the CRs it is asked for a release manager for, and
+// fails.
+type errManagerFactory struct {
+	calls int
+}
+
+func (f *errManagerFactory) NewManager(*unstructured.Unstructured, map[string]string) (release.Manager, error) {
+	f.calls++
+	return nil, errors.New("no release manager")
+}
+
+func TestReconcileSelector(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1alpha1", Kind: "Test"}
+	s := runtime.NewScheme()
+	s.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	o := &unstructured.Unstructured{}
+	o.SetGroupVersionKind(gvk)
+	o.SetNamespace("ns")
+	o.SetName("test")
+	o.SetLabels(map[string]string{"tenant": "b"})
+	deleted := o.DeepCopy()
+	deleted.SetName("deleted")
+	now := metav1.Now()
+	deleted.SetDeletionTimestamp(&now)
+	deleted.SetFinalizers([]string{uninstallFinalizer})
+	deleted.SetAnnotations(map[string]string{releaseSelectorAnnotation: "tenant=a"})
+	// Another watch of the GVK installed the release of this CR.
+	otherShard := deleted.DeepCopy()
+	otherShard.SetName("other-shard")
+	otherShard.SetAnnotations(map[string]string{releaseSelectorAnnotation: "tenant=b"})
+
+	factory := &errManagerFactory{}
+	r := HelmOperatorReconciler{
+		Client:         fake.NewClientBuilder().WithScheme(s).WithObjects(o, deleted, otherShard).Build(),
+		GVK:            gvk,
+		ManagerFactory: factory,
+		Selector:       labels.SelectorFromSet(labels.Set{"tenant": "a"}),
+	}
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: apitypes.NamespacedName{Namespace: "ns", Name: "test"}})
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+	assert.Equal(t, 0, factory.calls)
+
+	// The release of a CR that is deleted after it was relabeled out of the
+	// selector is still uninstalled.
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: apitypes.NamespacedName{Namespace: "ns", Name: "deleted"}})
+	assert.Error(t, err)
+	assert.Equal(t, 1, factory.calls)
+
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: apitypes.NamespacedName{Namespace: "ns", Name: "other-shard"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, factory.calls)
+}
+
+func TestSetReleaseSelector(t *testing.T) {
+	o := &unstructured.Unstructured{}
+	o.SetAnnotations(map[string]string{"keep": "true"})
+
+	r := HelmOperatorReconciler{Selector: labels.Everything()}
+	assert.False(t, r.setReleaseSelector(o))
+	assert.Equal(t, map[string]string{"keep": "true"}, o.GetAnnotations())
+
+	r.Selector = labels.SelectorFromSet(labels.Set{"tenant": "a"})
+	assert.True(t, r.setReleaseSelector(o))
+	assert.False(t, r.setReleaseSelector(o))
+	assert.Equal(t, map[string]string{"keep": "true", releaseSelectorAnnotation: "tenant=a"}, o.GetAnnotations())
+	assert.True(t, managesRelease(r.Selector, o))
+	assert.False(t, managesRelease(labels.SelectorFromSet(labels.Set{"tenant": "b"}), o))
+}
+
+func conditionStatus(status *types.HelmAppStatus, conditionType types.HelmAppConditionType) types.ConditionStatus {
+	for _, c := range status.Conditions {
+		if c.Type == conditionType {
+			return c.Status
+		}
+	}
+	return ""
+}
+
 func annotations(m map[string]interface{}) *unstructured.Unstructured {
 	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
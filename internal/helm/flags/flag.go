@@ -33,6 +33,8 @@ type Flags struct {
 	LeaderElectionNamespace string
 	MaxConcurrentReconciles int
 	ProbeAddr               string
+	ChartCacheDir           string
+	EnableWebhooks          bool
 
 	// Path to a controller-runtime componentconfig file.
 	// If this is empty, use default values.
@@ -53,6 +55,17 @@ func (f *Flags) AddTo(flagSet *pflag.FlagSet) {
 		"./watches.yaml",
 		"Path to the watches file to use",
 	)
+	flagSet.StringVar(&f.ChartCacheDir,
+		"chart-cache-dir",
+		"/tmp/helm-operator/charts",
+		"Directory that charts pulled from OCI registries and chart repositories are cached in",
+	)
+	flagSet.BoolVar(&f.EnableWebhooks,
+		"enable-webhooks",
+		false,
+		"Serve a validating admission webhook for each watched GVK that rejects CRs whose release name "+
+			"collides with a release of another chart or whose values do not match the chart's values schema",
+	)
 
 	// Controller flags.
 	flagSet.DurationVar(&f.ReconcilePeriod,
@@ -60,6 +60,8 @@ const (
 	ConditionDeployed       HelmAppConditionType = "Deployed"
 	ConditionReleaseFailed  HelmAppConditionType = "ReleaseFailed"
 	ConditionIrreconcilable HelmAppConditionType = "Irreconcilable"
+	ConditionProgressing    HelmAppConditionType = "Progressing"
+	ConditionDrifted        HelmAppConditionType = "Drifted"
 
 	StatusTrue    ConditionStatus = "True"
 	StatusFalse   ConditionStatus = "False"
@@ -72,6 +74,10 @@ const (
 	ReasonUpgradeError        HelmAppConditionReason = "UpgradeError"
 	ReasonReconcileError      HelmAppConditionReason = "ReconcileError"
 	ReasonUninstallError      HelmAppConditionReason = "UninstallError"
+	ReasonRolledBack          HelmAppConditionReason = "RolledBack"
+	ReasonWaitingForResources HelmAppConditionReason = "WaitingForResources"
+	ReasonWaitTimeout         HelmAppConditionReason = "WaitTimeout"
+	ReasonDriftDetected       HelmAppConditionReason = "DriftDetected"
 )
 
 type HelmAppStatus struct {
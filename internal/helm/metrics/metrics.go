@@ -15,7 +15,12 @@
 package metrics
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	sdkVersion "github.com/operator-framework/operator-sdk/internal/version"
 )
@@ -24,6 +29,13 @@ const (
 	subsystem = "helm_operator"
 )
 
+// Release operations, as counted by ReleaseOperation.
+const (
+	OperationInstall   = "install"
+	OperationUpgrade   = "upgrade"
+	OperationUninstall = "uninstall"
+)
+
 var (
 	buildInfo = prometheus.NewGauge(
 		prometheus.GaugeOpts{
@@ -36,9 +48,159 @@ var (
 			},
 		},
 	)
+
+	reconcileResults = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "reconcile_total",
+			Help:      "Total number of reconciles by result.",
+		},
+		[]string{
+			"GVK",
+			"result",
+		})
+
+	reconciles = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: subsystem,
+			Name:      "reconciles",
+			Help:      "How long in seconds a reconcile takes.",
+		},
+		[]string{
+			"GVK",
+		})
+
+	releaseOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "release_operations_total",
+			Help:      "Total number of release installs, upgrades and uninstalls.",
+		},
+		[]string{
+			"GVK",
+			"operation",
+		})
+
+	releaseOperationFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "release_operation_failures_total",
+			Help:      "Total number of failed release installs, upgrades and uninstalls by reason.",
+		},
+		[]string{
+			"GVK",
+			"operation",
+			"reason",
+		})
+
+	managedReleases = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: subsystem,
+			Name:      "managed_releases",
+			Help:      "Number of releases managed by the operator.",
+		},
+		[]string{
+			"GVK",
+		})
+
+	driftCorrections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "drift_corrections_total",
+			Help:      "Total number of release resources created or patched to match their release manifest.",
+		},
+		[]string{
+			"GVK",
+			"kind",
+		})
+
+	// releases holds the keys of the CRs whose release is managed, by GVK.
+	releasesMu sync.Mutex
+	releases   = map[string]map[string]struct{}{}
 )
 
+func init() {
+	metrics.Registry.MustRegister(reconcileResults)
+	metrics.Registry.MustRegister(reconciles)
+	metrics.Registry.MustRegister(releaseOperations)
+	metrics.Registry.MustRegister(releaseOperationFailures)
+	metrics.Registry.MustRegister(managedReleases)
+	metrics.Registry.MustRegister(driftCorrections)
+}
+
+// We will never want to panic our app because of metric saving.
+// Therefore, we will recover our panics here and error log them
+// for later diagnosis but will never fail the app.
+func recoverMetricPanic() {
+	if r := recover(); r != nil {
+		logf.Log.WithName("metrics").Error(fmt.Errorf("%v", r),
+			"Recovering from metric function")
+	}
+}
+
 func RegisterBuildInfo(r prometheus.Registerer) {
 	buildInfo.Set(1)
 	r.MustRegister(buildInfo)
 }
+
+func ReconcileSucceeded(gvk string) {
+	defer recoverMetricPanic()
+	reconcileResults.WithLabelValues(gvk, "succeeded").Inc()
+}
+
+func ReconcileFailed(gvk string) {
+	defer recoverMetricPanic()
+	reconcileResults.WithLabelValues(gvk, "failed").Inc()
+}
+
+func ReconcileTimer(gvk string) *prometheus.Timer {
+	defer recoverMetricPanic()
+	return prometheus.NewTimer(prometheus.ObserverFunc(func(duration float64) {
+		reconciles.WithLabelValues(gvk).Observe(duration)
+	}))
+}
+
+// ReleaseOperation counts an attempted install, upgrade or uninstall.
+func ReleaseOperation(gvk, operation string) {
+	defer recoverMetricPanic()
+	releaseOperations.WithLabelValues(gvk, operation).Inc()
+}
+
+// ReleaseOperationFailed counts a failed install, upgrade or uninstall, and
+// the reason of the ReleaseFailed condition it set.
+func ReleaseOperationFailed(gvk, operation, reason string) {
+	defer recoverMetricPanic()
+	releaseOperationFailures.WithLabelValues(gvk, operation, reason).Inc()
+}
+
+// ReleaseManaged records that the release of the CR with key is managed.
+func ReleaseManaged(gvk, key string) {
+	defer recoverMetricPanic()
+	releasesMu.Lock()
+	defer releasesMu.Unlock()
+	if releases[gvk] == nil {
+		releases[gvk] = map[string]struct{}{}
+	}
+	releases[gvk][key] = struct{}{}
+	managedReleases.WithLabelValues(gvk).Set(float64(len(releases[gvk])))
+}
+
+// ReleaseRemoved records that the release of the CR with key is no longer
+// managed, since it was uninstalled or the CR is gone.
+func ReleaseRemoved(gvk, key string) {
+	defer recoverMetricPanic()
+	releasesMu.Lock()
+	defer releasesMu.Unlock()
+	if _, ok := releases[gvk][key]; !ok {
+		return
+	}
+	delete(releases[gvk], key)
+	managedReleases.WithLabelValues(gvk).Set(float64(len(releases[gvk])))
+}
+
+// DriftCorrected counts a resource of kind that was created or patched
+// because it no longer matched its release manifest.
+func DriftCorrected(gvk, kind string) {
+	defer recoverMetricPanic()
+	driftCorrections.WithLabelValues(gvk, kind).Inc()
+}
@@ -0,0 +1,39 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestManagedReleases(t *testing.T) {
+	const gvk = "example.com/v1, Kind=Nginx"
+	gauge := managedReleases.WithLabelValues(gvk)
+
+	ReleaseManaged(gvk, "default/a")
+	ReleaseManaged(gvk, "default/a")
+	ReleaseManaged(gvk, "default/b")
+	if got := testutil.ToFloat64(gauge); got != 2 {
+		t.Fatalf("expected 2 managed releases, got %v", got)
+	}
+
+	ReleaseRemoved(gvk, "default/a")
+	ReleaseRemoved(gvk, "default/c")
+	if got := testutil.ToFloat64(gauge); got != 1 {
+		t.Fatalf("expected 1 managed release, got %v", got)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxDriftFields is the number of drifted fields of a resource that are
+	// listed in a drift summary.
+	maxDriftFields = 5
+	// maxDriftResources is the number of drifted resources that are listed in
+	// a drift summary.
+	maxDriftResources = 10
+	// maxDriftSummaryLength is the length past which a drift summary is
+	// truncated, so that it fits in a condition and an event.
+	maxDriftSummaryLength = 1024
+)
+
+// Drift is a resource of a release that does not match the release manifest.
+type Drift struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Missing is true if the resource does not exist.
+	Missing bool
+	// Fields are the paths of the fields that differ from the release
+	// manifest, such as "spec.replicas". They do not hold values, since drifts
+	// are reported to anyone who can read the CR, and the resource may be a
+	// Secret.
+	Fields []string
+}
+
+func (d Drift) String() string {
+	name := d.Name
+	if d.Namespace != "" {
+		name = d.Namespace + "/" + name
+	}
+	if d.Missing {
+		return fmt.Sprintf("%s %s is missing", d.Kind, name)
+	}
+	if len(d.Fields) == 0 {
+		return fmt.Sprintf("%s %s differs", d.Kind, name)
+	}
+	fields, more := d.Fields, ""
+	if len(fields) > maxDriftFields {
+		fields, more = fields[:maxDriftFields], fmt.Sprintf(" and %d more", len(fields)-maxDriftFields)
+	}
+	return fmt.Sprintf("%s %s differs in %s%s", d.Kind, name, strings.Join(fields, ", "), more)
+}
+
+// SummarizeDrift returns a human readable summary of drifts.
+func SummarizeDrift(drifts []Drift) string {
+	lines := make([]string, 0, len(drifts))
+	for i, d := range drifts {
+		if i == maxDriftResources {
+			lines = append(lines, fmt.Sprintf("and %d more", len(drifts)-maxDriftResources))
+			break
+		}
+		lines = append(lines, d.String())
+	}
+	summary := fmt.Sprintf("%d resource(s) drifted from the release manifest: %s", len(drifts), strings.Join(lines, "; "))
+	if len(summary) > maxDriftSummaryLength {
+		n := maxDriftSummaryLength - len("...")
+		for !utf8.ValidString(summary[:n]) {
+			n--
+		}
+		summary = summary[:n] + "..."
+	}
+	return summary
+}
+
+// patchFields returns the sorted paths of the fields set by a JSON merge or
+// strategic merge patch. Lists are not descended into, and the directives of
+// strategic merge patches are left out.
+func patchFields(patch []byte) []string {
+	var m map[string]interface{}
+	if err := json.Unmarshal(patch, &m); err != nil {
+		return nil
+	}
+	var fields []string
+	collectPatchFields(m, "", &fields)
+	sort.Strings(fields)
+	return fields
+}
+
+func collectPatchFields(m map[string]interface{}, path string, fields *[]string) {
+	for k, v := range m {
+		if strings.HasPrefix(k, "$") {
+			continue
+		}
+		fieldPath := k
+		if path != "" {
+			fieldPath = path + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok {
+			n := len(*fields)
+			collectPatchFields(nested, fieldPath, fields)
+			if len(*fields) > n {
+				continue
+			}
+		}
+		*fields = append(*fields, fieldPath)
+	}
+}
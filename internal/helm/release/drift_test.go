@@ -0,0 +1,57 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeDrift(t *testing.T) {
+	drifts := []Drift{
+		{Kind: "ConfigMap", Namespace: "ns", Name: "config", Missing: true},
+		{Kind: "Deployment", Namespace: "ns", Name: "app", Fields: []string{"spec.replicas"}},
+		{Kind: "ClusterRole", Name: "role", Fields: []string{"a", "b", "c", "d", "e", "f", "g"}},
+	}
+	assert.Equal(t, "3 resource(s) drifted from the release manifest: "+
+		"ConfigMap ns/config is missing; "+
+		"Deployment ns/app differs in spec.replicas; "+
+		"ClusterRole role differs in a, b, c, d, e and 2 more",
+		SummarizeDrift(drifts))
+
+	many := make([]Drift, 0, maxDriftResources+2)
+	for i := 0; i < maxDriftResources+2; i++ {
+		many = append(many, Drift{Kind: "Secret", Namespace: "ns", Name: "secret", Fields: []string{"data.password"}})
+	}
+	summary := SummarizeDrift(many)
+	assert.Equal(t, maxDriftResources+1, len(strings.Split(summary, "; ")))
+	assert.True(t, strings.HasSuffix(summary, "; and 2 more"))
+
+	long := []Drift{{Kind: "ConfigMap", Name: strings.Repeat("x", maxDriftSummaryLength)}}
+	summary = SummarizeDrift(long)
+	assert.Equal(t, maxDriftSummaryLength, len(summary))
+	assert.True(t, strings.HasSuffix(summary, "..."))
+}
+
+func TestPatchFields(t *testing.T) {
+	// The values of a drifted Secret are not part of its fields.
+	patch := `{"data":{"password":"c2VjcmV0"},"metadata":{"labels":{"app":"db"}},` +
+		`"spec":{"$setElementOrder/containers":[{"name":"app"}],"containers":[{"image":"app:2","name":"app"}],"template":{}}}`
+	assert.Equal(t, []string{"data.password", "metadata.labels.app", "spec.containers", "spec.template"},
+		patchFields([]byte(patch)))
+	assert.Nil(t, patchFields([]byte("invalid")))
+}
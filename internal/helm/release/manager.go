@@ -41,9 +41,11 @@ import (
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/operator-framework/operator-sdk/internal/helm/internal/types"
 	"github.com/operator-framework/operator-sdk/internal/helm/manifestutil"
+	"github.com/operator-framework/operator-sdk/internal/helm/metrics"
 )
 
 // Manager manages a Helm release. It can install, upgrade, reconcile,
@@ -52,29 +54,37 @@ type Manager interface {
 	ReleaseName() string
 	IsInstalled() bool
 	IsUpgradeRequired() bool
+	IsUpgradeRolledBack() bool
 	Sync(context.Context) error
 	InstallRelease(context.Context, ...InstallOption) (*rpb.Release, error)
 	UpgradeRelease(context.Context, ...UpgradeOption) (*rpb.Release, *rpb.Release, error)
 	ReconcileRelease(context.Context) (*rpb.Release, error)
+	DetectReleaseDrift(context.Context) (*rpb.Release, []Drift, error)
+	DeployedRelease() *rpb.Release
 	UninstallRelease(context.Context, ...UninstallOption) (*rpb.Release, error)
 	CleanupRelease(context.Context, string) (bool, error)
+	CheckReleaseReady(context.Context, string) (bool, string, error)
+	RollbackRelease(context.Context) (int, *rpb.Release, error)
 }
 
 type manager struct {
 	actionConfig   *action.Configuration
 	storageBackend *storage.Storage
 	kubeClient     kube.Interface
+	kubeClientSet  kubernetes.Interface
 
 	releaseName string
 	namespace   string
+	ownerGVK    string
 
 	values map[string]interface{}
 	status *types.HelmAppStatus
 
-	isInstalled       bool
-	isUpgradeRequired bool
-	deployedRelease   *rpb.Release
-	chart             *cpb.Chart
+	isInstalled         bool
+	isUpgradeRequired   bool
+	isUpgradeRolledBack bool
+	deployedRelease     *rpb.Release
+	chart               *cpb.Chart
 }
 
 type InstallOption func(*action.Install) error
@@ -94,6 +104,12 @@ func (m manager) IsUpgradeRequired() bool {
 	return m.isUpgradeRequired
 }
 
+// IsUpgradeRolledBack returns whether the required upgrade is to the revision
+// that the deployed release was rolled back from.
+func (m manager) IsUpgradeRolledBack() bool {
+	return m.isUpgradeRolledBack
+}
+
 // Sync ensures the Helm storage backend is in sync with the status of the
 // custom resource.
 func (m *manager) Sync(ctx context.Context) error {
@@ -103,11 +119,13 @@ func (m *manager) Sync(ctx context.Context) error {
 		return fmt.Errorf("failed to retrieve release history: %w", err)
 	}
 
-	// Cleanup non-deployed release versions. If all release versions are
-	// non-deployed, this will ensure that failed installations are correctly
-	// retried.
+	// Cleanup non-deployed release versions, except for the revision that
+	// preceded the deployed one, which an upgrade may be rolled back to. If
+	// all release versions are non-deployed, this will ensure that failed
+	// installations are correctly retried.
+	previous := previousRelease(releases)
 	for _, rel := range releases {
-		if rel.Info != nil && rel.Info.Status != rpb.StatusDeployed {
+		if rel.Info != nil && rel.Info.Status != rpb.StatusDeployed && rel != previous {
 			_, err := m.storageBackend.Delete(rel.Name, rel.Version)
 			if err != nil && !notFoundErr(err) {
 				return fmt.Errorf("failed to delete stale release version: %w", err)
@@ -127,10 +145,18 @@ func (m *manager) Sync(ctx context.Context) error {
 	m.isInstalled = true
 
 	m.isUpgradeRequired = m.isUpgrade(deployedRelease)
+	m.isUpgradeRolledBack = m.isUpgradeRequired && isRollback(deployedRelease) &&
+		previous != nil && previous.Version == deployedRelease.Version-1 && !m.isUpgrade(previous)
 
 	return nil
 }
 
+// isRollback returns whether rel was deployed by a rollback, based on the
+// description Helm gives the revisions it creates for rollbacks.
+func isRollback(rel *rpb.Release) bool {
+	return rel.Info != nil && strings.HasPrefix(rel.Info.Description, "Rollback to ")
+}
+
 func notFoundErr(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "not found")
 }
@@ -202,6 +228,23 @@ func ForceUpgrade(force bool) UpgradeOption {
 	}
 }
 
+// RollbackError is returned by UpgradeRelease when a failed upgrade has been
+// rolled back to the last deployed revision of the release.
+type RollbackError struct {
+	// Revision is the revision the release was rolled back to.
+	Revision int
+	// Err is the error that caused the upgrade to fail.
+	Err error
+}
+
+func (e *RollbackError) Error() string {
+	return fmt.Sprintf("failed to upgrade release, rolled back to revision %d: %v", e.Revision, e.Err)
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
+
 // UpgradeRelease performs a Helm release upgrade.
 func (m manager) UpgradeRelease(ctx context.Context, opts ...UpgradeOption) (*rpb.Release, *rpb.Release, error) {
 	upgrade := action.NewUpgrade(m.actionConfig)
@@ -219,6 +262,12 @@ func (m manager) UpgradeRelease(ctx context.Context, opts ...UpgradeOption) (*rp
 			rollback := action.NewRollback(m.actionConfig)
 			rollback.Force = true
 
+			// Roll back to the revision that was deployed when the upgrade
+			// started, as recorded in the storage backend.
+			if m.deployedRelease != nil {
+				rollback.Version = m.deployedRelease.Version
+			}
+
 			// As of Helm 2.13, if UpgradeRelease returns a non-nil release, that
 			// means the release was also recorded in the release store.
 			// Therefore, we should perform the rollback when we have a non-nil
@@ -228,35 +277,113 @@ func (m manager) UpgradeRelease(ctx context.Context, opts ...UpgradeOption) (*rp
 			if rollbackErr != nil {
 				return nil, nil, fmt.Errorf("failed upgrade (%s) and failed rollback: %w", err, rollbackErr)
 			}
+			return nil, nil, &RollbackError{Revision: rollback.Version, Err: err}
 		}
 		return nil, nil, fmt.Errorf("failed to upgrade release: %w", err)
 	}
 	return m.deployedRelease, upgradedRelease, err
 }
 
+// RollbackRelease rolls the release back to the revision that was deployed
+// before the current one, and returns that revision along with the release
+// it is now deployed as.
+func (m manager) RollbackRelease(ctx context.Context) (int, *rpb.Release, error) {
+	releases, err := m.storageBackend.History(m.releaseName)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to retrieve release history: %w", err)
+	}
+	previous := previousRelease(releases)
+	if previous == nil {
+		return 0, nil, errors.New("release has no previous revision to roll back to")
+	}
+
+	rollback := action.NewRollback(m.actionConfig)
+	rollback.Force = true
+	rollback.Version = previous.Version
+	if err := rollback.Run(m.releaseName); err != nil {
+		return 0, nil, fmt.Errorf("failed to roll back to revision %d: %w", previous.Version, err)
+	}
+	rolledBackRelease, err := m.getDeployedRelease()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get rolled back release: %w", err)
+	}
+	return previous.Version, rolledBackRelease, nil
+}
+
+// previousRelease returns the most recent superseded revision that precedes
+// the deployed revision in releases, or nil if there is none.
+func previousRelease(releases []*rpb.Release) *rpb.Release {
+	deployedVersion := 0
+	for _, rel := range releases {
+		if rel.Info != nil && rel.Info.Status == rpb.StatusDeployed && rel.Version > deployedVersion {
+			deployedVersion = rel.Version
+		}
+	}
+	var previous *rpb.Release
+	for _, rel := range releases {
+		if rel.Info == nil || rel.Info.Status != rpb.StatusSuperseded || rel.Version >= deployedVersion {
+			continue
+		}
+		if previous == nil || rel.Version > previous.Version {
+			previous = rel
+		}
+	}
+	return previous
+}
+
 // ReconcileRelease creates or patches resources as necessary to match the
 // deployed release's manifest.
 func (m manager) ReconcileRelease(ctx context.Context) (*rpb.Release, error) {
-	err := reconcileRelease(ctx, m.kubeClient, m.deployedRelease.Manifest)
+	_, err := reconcileRelease(ctx, m.kubeClient, m.deployedRelease.Manifest, m.ownerGVK, true)
 	return m.deployedRelease, err
 }
 
-func reconcileRelease(_ context.Context, kubeClient kube.Interface, expectedManifest string) error {
+// DetectReleaseDrift returns the resources that do not match the deployed
+// release's manifest, without changing them.
+func (m manager) DetectReleaseDrift(ctx context.Context) (*rpb.Release, []Drift, error) {
+	drifts, err := reconcileRelease(ctx, m.kubeClient, m.deployedRelease.Manifest, m.ownerGVK, false)
+	return m.deployedRelease, drifts, err
+}
+
+// DeployedRelease returns the deployed release, without checking its
+// resources.
+func (m manager) DeployedRelease() *rpb.Release {
+	return m.deployedRelease
+}
+
+// reconcileRelease returns the missing and drifted resources of
+// expectedManifest. If correct is true, it also creates the missing and
+// patches the drifted resources, counting each as a drift correction of the
+// CR's ownerGVK.
+func reconcileRelease(_ context.Context, kubeClient kube.Interface, expectedManifest, ownerGVK string,
+	correct bool) ([]Drift, error) {
 	expectedInfos, err := kubeClient.Build(bytes.NewBufferString(expectedManifest), false)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return expectedInfos.Visit(func(expected *resource.Info, err error) error {
+	var drifts []Drift
+	err = expectedInfos.Visit(func(expected *resource.Info, err error) error {
 		if err != nil {
 			return fmt.Errorf("visit error: %w", err)
 		}
+		drift := Drift{
+			Kind:      expected.Mapping.GroupVersionKind.Kind,
+			Namespace: expected.Namespace,
+			Name:      expected.Name,
+		}
 
 		helper := resource.NewHelper(expected.Client, expected.Mapping)
 		existing, err := helper.Get(expected.Namespace, expected.Name)
 		if apierrors.IsNotFound(err) {
+			drift.Missing = true
+			drifts = append(drifts, drift)
+			if !correct {
+				return nil
+			}
 			if _, err := helper.Create(expected.Namespace, true, expected.Object); err != nil {
 				return fmt.Errorf("create error: %s", err)
 			}
+			metrics.DriftCorrected(ownerGVK, drift.Kind)
 			return nil
 		} else if err != nil {
 			return fmt.Errorf("could not get object: %w", err)
@@ -276,14 +403,21 @@ func reconcileRelease(_ context.Context, kubeClient kube.Interface, expectedMani
 			// nothing to do
 			return nil
 		}
+		drift.Fields = patchFields(patch)
+		drifts = append(drifts, drift)
+		if !correct {
+			return nil
+		}
 
 		_, err = helper.Patch(expected.Namespace, expected.Name, patchType, patch,
 			&metav1.PatchOptions{})
 		if err != nil {
 			return fmt.Errorf("patch error: %w", err)
 		}
+		metrics.DriftCorrected(ownerGVK, drift.Kind)
 		return nil
 	})
+	return drifts, err
 }
 
 func createPatch(existing runtime.Object, expected *resource.Info) ([]byte, apitypes.PatchType, error) {
@@ -15,6 +15,7 @@
 package release
 
 import (
+	"context"
 	"fmt"
 
 	"helm.sh/helm/v3/pkg/action"
@@ -25,7 +26,8 @@ import (
 	"helm.sh/helm/v3/pkg/storage/driver"
 	"helm.sh/helm/v3/pkg/strvals"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/kubernetes"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 	crmanager "sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"github.com/operator-framework/operator-sdk/internal/helm/client"
@@ -41,22 +43,23 @@ type ManagerFactory interface {
 }
 
 type managerFactory struct {
-	mgr      crmanager.Manager
-	chartDir string
+	mgr       crmanager.Manager
+	chartPath string
 }
 
 // NewManagerFactory returns a new Helm manager factory capable of installing and uninstalling releases.
-func NewManagerFactory(mgr crmanager.Manager, chartDir string) ManagerFactory {
-	return &managerFactory{mgr, chartDir}
+// chartPath is either a chart directory or a chart archive.
+func NewManagerFactory(mgr crmanager.Manager, chartPath string) ManagerFactory {
+	return &managerFactory{mgr, chartPath}
 }
 
 func (f managerFactory) NewManager(cr *unstructured.Unstructured, overrideValues map[string]string) (Manager, error) {
 	// Get both v2 and v3 storage backends
-	clientv1, err := v1.NewForConfig(f.mgr.GetConfig())
+	clientSet, err := kubernetes.NewForConfig(f.mgr.GetConfig())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get core/v1 client: %w", err)
+		return nil, fmt.Errorf("failed to get kubernetes client: %w", err)
 	}
-	storageBackend := storage.Init(driver.NewSecrets(clientv1.Secrets(cr.GetNamespace())))
+	storageBackend := storage.Init(driver.NewSecrets(clientSet.CoreV1().Secrets(cr.GetNamespace())))
 
 	// Get the necessary clients and client getters. Use a client that injects the CR
 	// as an owner reference into all resources templated by the chart.
@@ -72,26 +75,26 @@ func (f managerFactory) NewManager(cr *unstructured.Unstructured, overrideValues
 		return nil, fmt.Errorf("failed to inject owner references: %w", err)
 	}
 
-	crChart, err := loader.LoadDir(f.chartDir)
+	crChart, err := loader.Load(f.chartPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load chart dir: %w", err)
+		return nil, fmt.Errorf("failed to load chart: %w", err)
 	}
 
-	releaseName, err := getReleaseName(storageBackend, crChart.Name(), cr)
+	releaseName, err := ReleaseName(storageBackend, crChart.Name(), cr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get helm release name: %w", err)
 	}
 
-	crValues, ok := cr.Object["spec"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("failed to get spec: expected map[string]interface{}")
+	// The values of a CR that is being deleted are not needed to uninstall
+	// its release, and the objects they are referenced from may be gone.
+	var reader crclient.Reader
+	if cr.GetDeletionTimestamp() == nil {
+		reader = f.mgr.GetAPIReader()
 	}
-
-	expOverrides, err := parseOverrides(overrideValues)
+	values, err := Values(context.TODO(), reader, cr, overrideValues)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse override values: %w", err)
+		return nil, err
 	}
-	values := mergeMaps(crValues, expOverrides)
 
 	actionConfig := &action.Configuration{
 		RESTClientGetter: rcg,
@@ -104,9 +107,11 @@ func (f managerFactory) NewManager(cr *unstructured.Unstructured, overrideValues
 		actionConfig:   actionConfig,
 		storageBackend: storageBackend,
 		kubeClient:     ownerRefClient,
+		kubeClientSet:  clientSet,
 
 		releaseName: releaseName,
 		namespace:   cr.GetNamespace(),
+		ownerGVK:    cr.GroupVersionKind().String(),
 
 		chart:  crChart,
 		values: values,
@@ -114,23 +119,18 @@ func (f managerFactory) NewManager(cr *unstructured.Unstructured, overrideValues
 	}, nil
 }
 
-// getReleaseName returns a release name for the CR.
+// ReleaseName returns a release name for the CR.
 //
-// getReleaseName searches for a release using the CR name. If a release
+// ReleaseName searches for a release using the CR name. If a release
 // cannot be found, or if it is found and was created by the chart managed
 // by this manager, the CR name is returned.
 //
 // If a release is found but it was created by another chart, that means we
 // have a release name collision, so return an error. This case is possible
 // because Kubernetes allows instances of different types to have the same name
-// in the same namespace.
-//
-// TODO(jlanford): As noted above, using the CR name as the release name raises
-//   the possibility of collision. We should move this logic to a validating
-//   admission webhook so that the CR owner receives immediate feedback of the
-//   collision. As is, the only indication of collision will be in the CR status
-//   and operator logs.
-func getReleaseName(storageBackend *storage.Storage, crChartName string,
+// in the same namespace. The helm-operator's validating admission webhook uses
+// ReleaseName to reject such CRs when they are created.
+func ReleaseName(storageBackend *storage.Storage, crChartName string,
 	cr *unstructured.Unstructured) (string, error) {
 	// If a release with the CR name does not exist, return the CR name.
 	releaseName := cr.GetName()
@@ -15,12 +15,19 @@
 package release
 
 import (
+	"context"
+	"io/ioutil"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/action"
 	cpb "helm.sh/helm/v3/pkg/chart"
 	lpb "helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
 	rpb "helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -267,6 +274,61 @@ func TestManagerisUpgrade(t *testing.T) {
 	}
 }
 
+func TestManagerRollbackRelease(t *testing.T) {
+	storageBackend := storage.Init(driver.NewMemory())
+	m := manager{
+		actionConfig: &action.Configuration{
+			Releases:     storageBackend,
+			KubeClient:   &kubefake.PrintingKubeClient{Out: ioutil.Discard},
+			Capabilities: chartutil.DefaultCapabilities,
+			Log:          func(_ string, _ ...interface{}) {},
+		},
+		storageBackend: storageBackend,
+		releaseName:    "test",
+		namespace:      "ns",
+		chart:          newTestChart(t, "./testdata/simple"),
+		values:         map[string]interface{}{"key": "value"},
+	}
+	_, err := m.InstallRelease(context.TODO())
+	assert.NoError(t, err)
+	assert.NoError(t, m.Sync(context.TODO()))
+
+	// An installed release has nothing to roll back to.
+	_, _, err = m.RollbackRelease(context.TODO())
+	assert.Error(t, err)
+
+	m.values = map[string]interface{}{"key": "other"}
+	_, _, err = m.UpgradeRelease(context.TODO())
+	assert.NoError(t, err)
+
+	// Syncing keeps the revision that preceded the upgrade.
+	assert.NoError(t, m.Sync(context.TODO()))
+	revision, rolledBack, err := m.RollbackRelease(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, revision)
+	assert.Equal(t, 3, rolledBack.Version)
+	assert.Equal(t, map[string]interface{}{"key": "value"}, rolledBack.Config)
+
+	// Only the deployed revision and the one before it are kept.
+	assert.NoError(t, m.Sync(context.TODO()))
+	history, err := storageBackend.History("test")
+	assert.NoError(t, err)
+	versions := []int{}
+	for _, rel := range history {
+		versions = append(versions, rel.Version)
+	}
+	assert.ElementsMatch(t, []int{2, 3}, versions)
+
+	// The upgrade that was rolled back is still required, but is known to
+	// have been rolled back until the values change.
+	assert.True(t, m.IsUpgradeRequired())
+	assert.True(t, m.IsUpgradeRolledBack())
+	m.values = map[string]interface{}{"key": "fixed"}
+	assert.NoError(t, m.Sync(context.TODO()))
+	assert.True(t, m.IsUpgradeRequired())
+	assert.False(t, m.IsUpgradeRolledBack())
+}
+
 func newTestChart(t *testing.T, path string) *cpb.Chart {
 	chart, err := lpb.Load(path)
 	assert.Nil(t, err)
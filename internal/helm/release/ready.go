@@ -0,0 +1,92 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/kube"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+)
+
+// errNotReady is returned from a resource visitor to stop at the first resource
+// that is not ready.
+var errNotReady = errors.New("resource not ready")
+
+// CheckReleaseReady checks whether the resources in a release manifest are
+// ready, with the same checks `helm install --wait --wait-for-jobs` makes. If
+// they are not, it returns false and a message describing the first resource
+// that is not ready.
+//
+// A Job with ttlSecondsAfterFinished that no longer exists is ready, since it
+// was deleted by the TTL controller once it finished.
+func (m manager) CheckReleaseReady(ctx context.Context, manifest string) (bool, string, error) {
+	infos, err := m.kubeClient.Build(strings.NewReader(manifest), false)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to build resources from manifest: %w", err)
+	}
+	return resourcesReady(ctx, m.kubeClientSet, infos)
+}
+
+func resourcesReady(ctx context.Context, cl kubernetes.Interface, infos kube.ResourceList) (bool, string, error) {
+	pending := ""
+	checker := newReadyChecker(cl, func(format string, args ...interface{}) {
+		pending = fmt.Sprintf(format, args...)
+	})
+	err := infos.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		pending = ""
+		ready, err := checker.IsReady(ctx, info)
+		if apierrors.IsNotFound(err) {
+			if isFinishedJob(info) {
+				return nil
+			}
+			pending = fmt.Sprintf("%s is not found: %s/%s", info.Mapping.GroupVersionKind.Kind, info.Namespace, info.Name)
+			return errNotReady
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check readiness of %s %q: %w", info.Mapping.GroupVersionKind.Kind, info.Name, err)
+		}
+		if !ready {
+			if pending == "" {
+				pending = fmt.Sprintf("%s is not ready: %s/%s", info.Mapping.GroupVersionKind.Kind, info.Namespace, info.Name)
+			}
+			return errNotReady
+		}
+		return nil
+	})
+	if errors.Is(err, errNotReady) {
+		return false, pending, nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	return true, "", nil
+}
+
+// isFinishedJob returns whether info is a Job that is deleted once it
+// finishes, so that its absence means it has run.
+func isFinishedJob(info *resource.Info) bool {
+	job, ok := kube.AsVersioned(info).(*batchv1.Job)
+	return ok && job.Spec.TTLSecondsAfterFinished != nil
+}
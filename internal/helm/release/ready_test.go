@@ -0,0 +1,207 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/kube"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newInfo(obj runtime.Object, group, version, kind string) *resource.Info {
+	m := obj.(metav1.Object)
+	gvk := schema.GroupVersionKind{Group: group, Version: version, Kind: kind}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	return &resource.Info{
+		Name:      m.GetName(),
+		Namespace: m.GetNamespace(),
+		Object:    obj,
+		Mapping:   &meta.RESTMapping{GroupVersionKind: gvk},
+	}
+}
+
+func testDeployment(replicas int32) *appsv1.Deployment {
+	labels := map[string]string{"app": "test"}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "deployment-uid"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "test", Image: "test"}}},
+			},
+		},
+	}
+}
+
+func testReplicaSet(d *appsv1.Deployment, ready int32) *appsv1.ReplicaSet {
+	controller := true
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-1",
+			Namespace: d.Namespace,
+			Labels:    d.Spec.Template.Labels,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1", Kind: "Deployment", Name: d.Name, UID: d.UID, Controller: &controller,
+			}},
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Replicas: d.Spec.Replicas,
+			Selector: d.Spec.Selector,
+			Template: d.Spec.Template,
+		},
+		Status: appsv1.ReplicaSetStatus{ReadyReplicas: ready},
+	}
+}
+
+func TestResourcesReady(t *testing.T) {
+	replicas, completions, backoffLimit, ttl := int32(2), int32(1), int32(1), int32(60)
+	job := func(status batchv1.JobStatus) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec:       batchv1.JobSpec{Completions: &completions, BackoffLimit: &backoffLimit},
+			Status:     status,
+		}
+	}
+	ttlJob := job(batchv1.JobStatus{})
+	ttlJob.Spec.TTLSecondsAfterFinished = &ttl
+
+	tests := []struct {
+		name     string
+		manifest []runtime.Object
+		cluster  []runtime.Object
+		ready    bool
+		pending  string
+	}{
+		{
+			name:     "deployment ready",
+			manifest: []runtime.Object{testDeployment(replicas)},
+			cluster:  []runtime.Object{testDeployment(replicas), testReplicaSet(testDeployment(replicas), 2)},
+			ready:    true,
+		},
+		{
+			name:     "deployment pods not ready",
+			manifest: []runtime.Object{testDeployment(replicas)},
+			cluster:  []runtime.Object{testDeployment(replicas), testReplicaSet(testDeployment(replicas), 1)},
+			pending:  "Deployment is not ready: default/test. 1 out of 2 expected pods are ready",
+		},
+		{
+			name:     "deployment new replicaset not created",
+			manifest: []runtime.Object{testDeployment(replicas)},
+			cluster:  []runtime.Object{testDeployment(replicas)},
+			pending:  "Deployment is not ready: default/test. Its new ReplicaSet has not been created",
+		},
+		{
+			name:     "deployment not found",
+			manifest: []runtime.Object{testDeployment(replicas)},
+			pending:  "Deployment is not found: default/test",
+		},
+		{
+			name: "statefulset pods not ready",
+			manifest: []runtime.Object{&appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			}},
+			cluster: []runtime.Object{&appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec: appsv1.StatefulSetSpec{
+					Replicas:       &replicas,
+					UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+				},
+				Status: appsv1.StatefulSetStatus{UpdatedReplicas: 2, ReadyReplicas: 1},
+			}},
+			pending: "StatefulSet is not ready: default/test. 1 out of 2 expected pods are ready",
+		},
+		{
+			name:     "job complete",
+			manifest: []runtime.Object{job(batchv1.JobStatus{})},
+			cluster:  []runtime.Object{job(batchv1.JobStatus{Succeeded: 1})},
+			ready:    true,
+		},
+		{
+			name:     "job running",
+			manifest: []runtime.Object{job(batchv1.JobStatus{})},
+			cluster:  []runtime.Object{job(batchv1.JobStatus{Active: 1})},
+			pending:  "Job is not completed: default/test",
+		},
+		{
+			name:     "job failed",
+			manifest: []runtime.Object{job(batchv1.JobStatus{})},
+			cluster:  []runtime.Object{job(batchv1.JobStatus{Failed: 2})},
+			pending:  "Job is failed: default/test",
+		},
+		{
+			name:     "job not found",
+			manifest: []runtime.Object{job(batchv1.JobStatus{})},
+			pending:  "Job is not found: default/test",
+		},
+		{
+			name:     "job deleted after finishing",
+			manifest: []runtime.Object{ttlJob},
+			ready:    true,
+		},
+		{
+			name: "service without cluster IP",
+			manifest: []runtime.Object{&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			}},
+			cluster: []runtime.Object{&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			}},
+			pending: "Service does not have cluster IP address: default/test",
+		},
+		{
+			name: "other resource",
+			manifest: []runtime.Object{&v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			}},
+			ready: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var infos kube.ResourceList
+			for _, obj := range test.manifest {
+				switch obj.(type) {
+				case *batchv1.Job:
+					infos = append(infos, newInfo(obj, "batch", "v1", "Job"))
+				case *v1.Service:
+					infos = append(infos, newInfo(obj, "", "v1", "Service"))
+				case *v1.ConfigMap:
+					infos = append(infos, newInfo(obj, "", "v1", "ConfigMap"))
+				case *appsv1.StatefulSet:
+					infos = append(infos, newInfo(obj, "apps", "v1", "StatefulSet"))
+				default:
+					infos = append(infos, newInfo(obj, "apps", "v1", "Deployment"))
+				}
+			}
+			ready, pending, err := resourcesReady(context.TODO(), fake.NewSimpleClientset(test.cluster...), infos)
+			assert.NoError(t, err)
+			assert.Equal(t, test.ready, ready)
+			assert.Equal(t, test.pending, pending)
+		})
+	}
+}
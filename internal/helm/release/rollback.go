@@ -0,0 +1,57 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// UpgradeRollbackField is the field of the spec of a CR that configures
+// rolling back upgrades of its release that do not become ready.
+const UpgradeRollbackField = "upgradeRollback"
+
+// UpgradeRollback configures rolling back upgrades of the release of a CR.
+type UpgradeRollback struct {
+	// Enabled rolls an upgraded release back to its previous revision if it
+	// does not become ready within Timeout.
+	Enabled bool `json:"enabled,omitempty"`
+	// Timeout is how long an upgraded release may take to become ready.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// UpgradeRollbackFor returns the upgradeRollback field of the spec of cr, or
+// nil if it is not set.
+func UpgradeRollbackFor(cr *unstructured.Unstructured) (*UpgradeRollback, error) {
+	in, found, err := unstructured.NestedFieldNoCopy(cr.Object, "spec", UpgradeRollbackField)
+	if err != nil || !found {
+		return nil, err
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	rollback := &UpgradeRollback{}
+	if err := json.Unmarshal(b, rollback); err != nil {
+		return nil, fmt.Errorf("invalid spec.%s: %w", UpgradeRollbackField, err)
+	}
+	if rollback.Timeout != nil && rollback.Timeout.Duration <= 0 {
+		return nil, fmt.Errorf("invalid spec.%s: timeout must be positive", UpgradeRollbackField)
+	}
+	return rollback, nil
+}
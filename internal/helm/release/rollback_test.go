@@ -0,0 +1,45 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpgradeRollbackFor(t *testing.T) {
+	rollback, err := UpgradeRollbackFor(newValuesCR(map[string]interface{}{
+		"upgradeRollback": map[string]interface{}{"enabled": true, "timeout": "10m"},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, &UpgradeRollback{Enabled: true, Timeout: &metav1.Duration{Duration: 10 * time.Minute}}, rollback)
+
+	rollback, err = UpgradeRollbackFor(newValuesCR(map[string]interface{}{}))
+	assert.NoError(t, err)
+	assert.Nil(t, rollback)
+
+	for _, upgradeRollback := range []interface{}{
+		"invalid",
+		map[string]interface{}{"enabled": "yes"},
+		map[string]interface{}{"enabled": true, "timeout": "soon"},
+		map[string]interface{}{"enabled": true, "timeout": "-1m"},
+	} {
+		_, err = UpgradeRollbackFor(newValuesCR(map[string]interface{}{"upgradeRollback": upgradeRollback}))
+		assert.Error(t, err)
+	}
+}
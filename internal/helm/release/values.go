@@ -0,0 +1,183 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// ValuesFromField is the field of the spec of a CR that lists the ConfigMaps
+// and Secrets that values of its release are read from.
+const ValuesFromField = "valuesFrom"
+
+// DefaultValuesKey is the key of a ConfigMap or Secret that values are read
+// from if its reference does not set one.
+const DefaultValuesKey = "values.yaml"
+
+// Kinds of objects that values can be referenced from.
+const (
+	ValuesKindConfigMap = "ConfigMap"
+	ValuesKindSecret    = "Secret"
+)
+
+// ValuesReference references a key of a ConfigMap or Secret in the namespace
+// of a CR, whose value is a YAML document of values of the CR's release.
+type ValuesReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
+	// Optional references are skipped if their object or key does not exist.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// ValuesReferences returns the references in the valuesFrom field of the spec
+// of cr, with their key defaulted.
+func ValuesReferences(cr *unstructured.Unstructured) ([]ValuesReference, error) {
+	in, found, err := unstructured.NestedFieldNoCopy(cr.Object, "spec", ValuesFromField)
+	if err != nil || !found {
+		return nil, err
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	var refs []ValuesReference
+	if err := json.Unmarshal(b, &refs); err != nil {
+		return nil, fmt.Errorf("invalid spec.%s: %w", ValuesFromField, err)
+	}
+	for i, ref := range refs {
+		if ref.Kind != ValuesKindConfigMap && ref.Kind != ValuesKindSecret {
+			return nil, fmt.Errorf("invalid spec.%s[%d]: kind must be %q or %q", ValuesFromField, i,
+				ValuesKindConfigMap, ValuesKindSecret)
+		}
+		if ref.Name == "" {
+			return nil, fmt.Errorf("invalid spec.%s[%d]: name must not be empty", ValuesFromField, i)
+		}
+		if ref.Key == "" {
+			refs[i].Key = DefaultValuesKey
+		}
+	}
+	return refs, nil
+}
+
+// Values returns the values of the release of a CR. In increasing order of
+// precedence, they are the values read from the references in its valuesFrom
+// field in order, its spec and overrideValues. If reader is nil, the
+// references are not read.
+func Values(ctx context.Context, reader crclient.Reader, cr *unstructured.Unstructured,
+	overrideValues map[string]string) (map[string]interface{}, error) {
+	crValues, ok := cr.Object["spec"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to get spec: expected map[string]interface{}")
+	}
+	refs, err := ValuesReferences(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if reader != nil {
+		for _, ref := range refs {
+			refValues, err := readValuesReference(ctx, reader, cr.GetNamespace(), ref)
+			if err != nil {
+				return nil, err
+			}
+			values = mergeMaps(values, refValues)
+		}
+	}
+
+	// The references and the rollback configuration are read by the operator
+	// and are not values of the chart.
+	spec := make(map[string]interface{}, len(crValues))
+	for k, v := range crValues {
+		if k != ValuesFromField && k != UpgradeRollbackField {
+			spec[k] = v
+		}
+	}
+	values = mergeMaps(values, spec)
+
+	expOverrides, err := parseOverrides(overrideValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse override values: %w", err)
+	}
+	return mergeMaps(values, expOverrides), nil
+}
+
+// readValuesReference reads the values referenced by ref in namespace.
+func readValuesReference(ctx context.Context, reader crclient.Reader, namespace string,
+	ref ValuesReference) (map[string]interface{}, error) {
+	objKey := crclient.ObjectKey{Namespace: namespace, Name: ref.Name}
+	var data []byte
+	var found bool
+	var err error
+	switch ref.Kind {
+	case ValuesKindConfigMap:
+		cm := &corev1.ConfigMap{}
+		if err = reader.Get(ctx, objKey, cm); err == nil {
+			if s, ok := cm.Data[ref.Key]; ok {
+				data, found = []byte(s), true
+			} else {
+				data, found = cm.BinaryData[ref.Key]
+			}
+		}
+	case ValuesKindSecret:
+		secret := &corev1.Secret{}
+		if err = reader.Get(ctx, objKey, secret); err == nil {
+			data, found = secret.Data[ref.Key]
+		}
+	}
+	if apierrors.IsNotFound(err) && ref.Optional {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get %s %q: %w", ref.Kind, ref.Name, err)
+	}
+	if !found {
+		if ref.Optional {
+			return nil, nil
+		}
+		return nil, keyNotFoundError{ref}
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse values in key %q of %s %q: %w", ref.Key, ref.Kind, ref.Name, err)
+	}
+	return values, nil
+}
+
+// keyNotFoundError is returned for a reference whose object does not have
+// its key.
+type keyNotFoundError struct {
+	ref ValuesReference
+}
+
+func (e keyNotFoundError) Error() string {
+	return fmt.Sprintf("key %q not found in %s %q", e.ref.Key, e.ref.Kind, e.ref.Name)
+}
+
+// IsValuesNotFound returns whether err was returned by Values because the
+// object or key of a reference that is not optional does not exist.
+func IsValuesNotFound(err error) bool {
+	return apierrors.IsNotFound(err) || errors.As(err, &keyNotFoundError{})
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValuesReferences(t *testing.T) {
+	refs, err := ValuesReferences(newValuesCR(map[string]interface{}{
+		"valuesFrom": []interface{}{
+			map[string]interface{}{"kind": "ConfigMap", "name": "defaults"},
+			map[string]interface{}{"kind": "Secret", "name": "db", "key": "db.yaml", "optional": true},
+		},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []ValuesReference{
+		{Kind: ValuesKindConfigMap, Name: "defaults", Key: DefaultValuesKey},
+		{Kind: ValuesKindSecret, Name: "db", Key: "db.yaml", Optional: true},
+	}, refs)
+
+	refs, err = ValuesReferences(newValuesCR(map[string]interface{}{}))
+	assert.NoError(t, err)
+	assert.Nil(t, refs)
+
+	for _, valuesFrom := range []interface{}{
+		"invalid",
+		[]interface{}{map[string]interface{}{"kind": "Pod", "name": "test"}},
+		[]interface{}{map[string]interface{}{"kind": "Secret"}},
+	} {
+		_, err = ValuesReferences(newValuesCR(map[string]interface{}{"valuesFrom": valuesFrom}))
+		assert.Error(t, err)
+	}
+}
+
+func TestValues(t *testing.T) {
+	reader := fake.NewClientBuilder().WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "defaults"},
+			Data:       map[string]string{"values.yaml": "replicaCount: 1\nimage:\n  tag: v1\ndb:\n  host: localhost\n"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "db"},
+			Data:       map[string][]byte{"values.yaml": []byte("db:\n  host: db.example.com\n  password: secret\n")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "db"},
+			Data:       map[string][]byte{"values.yaml": []byte("db:\n  password: other\n")},
+		},
+	).Build()
+	valuesFrom := []interface{}{
+		map[string]interface{}{"kind": "ConfigMap", "name": "defaults"},
+		map[string]interface{}{"kind": "Secret", "name": "db"},
+		map[string]interface{}{"kind": "Secret", "name": "missing", "optional": true},
+		map[string]interface{}{"kind": "Secret", "name": "db", "key": "missing", "optional": true},
+	}
+	cr := newValuesCR(map[string]interface{}{
+		"valuesFrom":      valuesFrom,
+		"upgradeRollback": map[string]interface{}{"enabled": true},
+		"replicaCount":    float64(2),
+		"image":           map[string]interface{}{"repository": "nginx"},
+	})
+
+	values, err := Values(context.TODO(), reader, cr, map[string]string{"image.tag": "v2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"replicaCount": float64(2),
+		"image":        map[string]interface{}{"repository": "nginx", "tag": "v2"},
+		"db":           map[string]interface{}{"host": "db.example.com", "password": "secret"},
+	}, values)
+
+	// The references of a CR are not read without a reader.
+	values, err = Values(context.TODO(), nil, cr, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"replicaCount": float64(2),
+		"image":        map[string]interface{}{"repository": "nginx"},
+	}, values)
+
+	for _, ref := range []interface{}{
+		map[string]interface{}{"kind": "Secret", "name": "missing"},
+		map[string]interface{}{"kind": "Secret", "name": "db", "key": "missing"},
+	} {
+		_, err = Values(context.TODO(), reader, newValuesCR(map[string]interface{}{"valuesFrom": []interface{}{ref}}), nil)
+		assert.Error(t, err)
+	}
+}
+
+func newValuesCR(spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":      "test",
+				"namespace": "ns",
+			},
+			"spec": spec,
+		},
+	}
+}
@@ -20,21 +20,78 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"strings"
 
 	"helm.sh/helm/v3/pkg/chartutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/yaml"
 )
 
 const WatchesFile = "watches.yaml"
 
+// OCIScheme is the prefix of a chart stored in an OCI registry.
+const OCIScheme = "oci://"
+
+// Drift policies, which decide what happens to release resources that no longer
+// match the release manifest.
+const (
+	// DriftPolicyCorrect creates or patches drifted resources to match the
+	// release manifest. This is the default.
+	DriftPolicyCorrect = "correct"
+	// DriftPolicyReport reports drifted resources in the Drifted condition of
+	// the CR and with an event, without changing them.
+	DriftPolicyReport = "report"
+	// DriftPolicyIgnore does not check release resources for drift.
+	DriftPolicyIgnore = "ignore"
+)
+
+var digestRegexp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
 // Watch defines options for configuring a watch for a Helm-based
 // custom resource.
 type Watch struct {
 	schema.GroupVersionKind `json:",inline"`
-	ChartDir                string            `json:"chart"`
+	// ChartDir is a local chart directory, an oci:// reference, or the name
+	// of a chart in Repo.
+	ChartDir string `json:"chart"`
+	// Repo is the URL of the chart repository ChartDir is pulled from.
+	Repo string `json:"repo,omitempty"`
+	// ChartVersion is the version of a remote chart.
+	ChartVersion string `json:"chartVersion,omitempty"`
+	// ChartDigest, if set, is the expected "sha256:<hex>" digest of the
+	// remote chart archive.
+	ChartDigest string `json:"chartDigest,omitempty"`
+	// PlainHTTP, if true, pulls an OCI chart over http instead of https, such
+	// as from a local registry.
+	PlainHTTP               bool              `json:"plainHTTP,omitempty"`
 	WatchDependentResources *bool             `json:"watchDependentResources,omitempty"`
 	OverrideValues          map[string]string `json:"overrideValues,omitempty"`
+	// WaitForReady, if true, only reports a release as deployed once its
+	// Deployments, StatefulSets and Jobs are ready.
+	WaitForReady bool `json:"waitForReady,omitempty"`
+	// WaitTimeout is how long to wait for a release to become ready before
+	// reporting it as failed.
+	WaitTimeout *metav1.Duration `json:"waitTimeout,omitempty"`
+	// DriftPolicy is one of DriftPolicyCorrect, DriftPolicyReport or
+	// DriftPolicyIgnore. If empty, drifted resources are corrected.
+	DriftPolicy string `json:"driftPolicy,omitempty"`
+	// Selector restricts the CRs reconciled by the watch to those with
+	// matching labels.
+	Selector metav1.LabelSelector `json:"selector,omitempty"`
+	// ReconcilePeriod, if set, overrides the --reconcile-period flag for the
+	// watch.
+	ReconcilePeriod *metav1.Duration `json:"reconcilePeriod,omitempty"`
+	// MaxConcurrentReconciles, if set, overrides the
+	// --max-concurrent-reconciles flag for the watch.
+	MaxConcurrentReconciles *int `json:"maxConcurrentReconciles,omitempty"`
+}
+
+// IsRemoteChart returns true if the chart must be pulled from an OCI registry
+// or a chart repository instead of being read from a local directory.
+func (w Watch) IsRemoteChart() bool {
+	return strings.HasPrefix(w.ChartDir, OCIScheme) || w.Repo != ""
 }
 
 // UnmarshalYAML unmarshals an individual watch from the Helm watches.yaml file
@@ -98,8 +155,29 @@ func LoadReader(reader io.Reader) ([]Watch, error) {
 			return nil, fmt.Errorf("invalid GVK: %s: %w", gvk, err)
 		}
 
-		if _, err := chartutil.IsChartDir(w.ChartDir); err != nil {
-			return nil, fmt.Errorf("invalid chart directory %s: %w", w.ChartDir, err)
+		if err := verifyChart(w); err != nil {
+			return nil, err
+		}
+
+		if w.WaitTimeout != nil && w.WaitTimeout.Duration <= 0 {
+			return nil, fmt.Errorf("invalid waitTimeout %s for GVK %s: must be positive", w.WaitTimeout.Duration, gvk)
+		}
+
+		switch w.DriftPolicy {
+		case "", DriftPolicyCorrect, DriftPolicyReport, DriftPolicyIgnore:
+		default:
+			return nil, fmt.Errorf("invalid driftPolicy %q for GVK %s: must be one of %q, %q or %q",
+				w.DriftPolicy, gvk, DriftPolicyCorrect, DriftPolicyReport, DriftPolicyIgnore)
+		}
+
+		if _, err := metav1.LabelSelectorAsSelector(&w.Selector); err != nil {
+			return nil, fmt.Errorf("invalid selector for GVK %s: %w", gvk, err)
+		}
+		if w.ReconcilePeriod != nil && w.ReconcilePeriod.Duration < 0 {
+			return nil, fmt.Errorf("invalid reconcilePeriod %s for GVK %s: must not be negative", w.ReconcilePeriod.Duration, gvk)
+		}
+		if w.MaxConcurrentReconciles != nil && *w.MaxConcurrentReconciles <= 0 {
+			return nil, fmt.Errorf("invalid maxConcurrentReconciles %d for GVK %s: must be positive", *w.MaxConcurrentReconciles, gvk)
 		}
 
 		if _, ok := watchesMap[gvk]; ok {
@@ -127,6 +205,34 @@ func expandOverrideEnvs(in map[string]string) map[string]string {
 	return out
 }
 
+func verifyChart(w Watch) error {
+	if !w.IsRemoteChart() {
+		if w.ChartVersion != "" || w.ChartDigest != "" || w.PlainHTTP {
+			return fmt.Errorf("chartVersion, chartDigest and plainHTTP are only valid for remote charts")
+		}
+		if _, err := chartutil.IsChartDir(w.ChartDir); err != nil {
+			return fmt.Errorf("invalid chart directory %s: %w", w.ChartDir, err)
+		}
+		return nil
+	}
+	if w.PlainHTTP && !strings.HasPrefix(w.ChartDir, OCIScheme) {
+		return fmt.Errorf("invalid chart %s: plainHTTP is only valid for OCI charts", w.ChartDir)
+	}
+	if w.Repo != "" && strings.HasPrefix(w.ChartDir, OCIScheme) {
+		return fmt.Errorf("invalid chart %s: repo must not be set for OCI charts", w.ChartDir)
+	}
+	if w.ChartDir == "" || w.ChartDir == OCIScheme {
+		return errors.New("chart must not be empty")
+	}
+	if w.ChartVersion == "" {
+		return fmt.Errorf("invalid chart %s: chartVersion must be set for remote charts", w.ChartDir)
+	}
+	if w.ChartDigest != "" && !digestRegexp.MatchString(w.ChartDigest) {
+		return fmt.Errorf("invalid chartDigest %q: must be of the form sha256:<hex>", w.ChartDigest)
+	}
+	return nil
+}
+
 func verifyGVK(gvk schema.GroupVersionKind) error {
 	// A GVK without a group is valid. Certain scenarios may cause a GVK
 	// without a group to fail in other ways later in the initialization
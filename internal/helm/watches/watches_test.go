@@ -19,13 +19,15 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestLoadReader(t *testing.T) {
-	trueVal, falseVal := true, false
+	trueVal, falseVal, two := true, false, 2
 	testCases := []struct {
 		name          string
 		data          string
@@ -141,6 +143,260 @@ func TestLoadReader(t *testing.T) {
   version: v1alpha1
   kind: MyKind
   chart: nonexistent/path/to/chart
+`,
+			expectErr: true,
+		},
+		{
+			name: "valid oci chart",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: oci://registry.example.com/charts/test-chart
+  chartVersion: 0.1.0
+  chartDigest: sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945
+`,
+			expectWatches: []Watch{
+				{
+					GroupVersionKind:        schema.GroupVersionKind{Group: "mygroup", Version: "v1alpha1", Kind: "MyKind"},
+					ChartDir:                "oci://registry.example.com/charts/test-chart",
+					ChartVersion:            "0.1.0",
+					ChartDigest:             "sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945",
+					WatchDependentResources: &trueVal,
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "valid repo chart",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: test-chart
+  repo: https://charts.example.com
+  chartVersion: 0.1.0
+`,
+			expectWatches: []Watch{
+				{
+					GroupVersionKind:        schema.GroupVersionKind{Group: "mygroup", Version: "v1alpha1", Kind: "MyKind"},
+					ChartDir:                "test-chart",
+					Repo:                    "https://charts.example.com",
+					ChartVersion:            "0.1.0",
+					WatchDependentResources: &trueVal,
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "valid plain http oci chart",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: oci://localhost:5000/charts/test-chart
+  chartVersion: 0.1.0
+  plainHTTP: true
+`,
+			expectWatches: []Watch{
+				{
+					GroupVersionKind:        schema.GroupVersionKind{Group: "mygroup", Version: "v1alpha1", Kind: "MyKind"},
+					ChartDir:                "oci://localhost:5000/charts/test-chart",
+					ChartVersion:            "0.1.0",
+					PlainHTTP:               true,
+					WatchDependentResources: &trueVal,
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "repo chart with plain http",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: test-chart
+  repo: http://charts.example.com
+  chartVersion: 0.1.0
+  plainHTTP: true
+`,
+			expectErr: true,
+		},
+		{
+			name: "remote chart without version",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: oci://registry.example.com/charts/test-chart
+`,
+			expectErr: true,
+		},
+		{
+			name: "oci chart with repo",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: oci://registry.example.com/charts/test-chart
+  repo: https://charts.example.com
+  chartVersion: 0.1.0
+`,
+			expectErr: true,
+		},
+		{
+			name: "invalid chart digest",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: oci://registry.example.com/charts/test-chart
+  chartVersion: 0.1.0
+  chartDigest: md5:1234
+`,
+			expectErr: true,
+		},
+		{
+			name: "local chart with version",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../../internal/plugins/helm/v1/chartutil/testdata/test-chart
+  chartVersion: 0.1.0
+`,
+			expectErr: true,
+		},
+		{
+			name: "valid wait for ready",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../../internal/plugins/helm/v1/chartutil/testdata/test-chart
+  waitForReady: true
+  waitTimeout: 10m
+`,
+			expectWatches: []Watch{
+				{
+					GroupVersionKind:        schema.GroupVersionKind{Group: "mygroup", Version: "v1alpha1", Kind: "MyKind"},
+					ChartDir:                "../../../internal/plugins/helm/v1/chartutil/testdata/test-chart",
+					WatchDependentResources: &trueVal,
+					WaitForReady:            true,
+					WaitTimeout:             &metav1.Duration{Duration: 10 * time.Minute},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "invalid wait timeout",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../../internal/plugins/helm/v1/chartutil/testdata/test-chart
+  waitForReady: true
+  waitTimeout: -1m
+`,
+			expectErr: true,
+		},
+		{
+			name: "valid drift policy",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../../internal/plugins/helm/v1/chartutil/testdata/test-chart
+  driftPolicy: report
+`,
+			expectWatches: []Watch{
+				{
+					GroupVersionKind:        schema.GroupVersionKind{Group: "mygroup", Version: "v1alpha1", Kind: "MyKind"},
+					ChartDir:                "../../../internal/plugins/helm/v1/chartutil/testdata/test-chart",
+					WatchDependentResources: &trueVal,
+					DriftPolicy:             DriftPolicyReport,
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "valid selector, reconcile period and concurrency",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../../internal/plugins/helm/v1/chartutil/testdata/test-chart
+  selector:
+    matchLabels:
+      tenant: a
+    matchExpressions:
+    - key: tier
+      operator: In
+      values: [gold]
+  reconcilePeriod: 30s
+  maxConcurrentReconciles: 2
+`,
+			expectWatches: []Watch{
+				{
+					GroupVersionKind:        schema.GroupVersionKind{Group: "mygroup", Version: "v1alpha1", Kind: "MyKind"},
+					ChartDir:                "../../../internal/plugins/helm/v1/chartutil/testdata/test-chart",
+					WatchDependentResources: &trueVal,
+					Selector: metav1.LabelSelector{
+						MatchLabels: map[string]string{"tenant": "a"},
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"gold"}},
+						},
+					},
+					ReconcilePeriod:         &metav1.Duration{Duration: 30 * time.Second},
+					MaxConcurrentReconciles: &two,
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "invalid selector",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../../internal/plugins/helm/v1/chartutil/testdata/test-chart
+  selector:
+    matchExpressions:
+    - key: tier
+      operator: Between
+`,
+			expectErr: true,
+		},
+		{
+			name: "invalid reconcile period",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../../internal/plugins/helm/v1/chartutil/testdata/test-chart
+  reconcilePeriod: -1m
+`,
+			expectErr: true,
+		},
+		{
+			name: "invalid max concurrent reconciles",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../../internal/plugins/helm/v1/chartutil/testdata/test-chart
+  maxConcurrentReconciles: 0
+`,
+			expectErr: true,
+		},
+		{
+			name: "invalid drift policy",
+			data: `---
+- group: mygroup
+  version: v1alpha1
+  kind: MyKind
+  chart: ../../../internal/plugins/helm/v1/chartutil/testdata/test-chart
+  driftPolicy: revert
 `,
 			expectErr: true,
 		},
@@ -0,0 +1,135 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook provides the validating admission webhook of the Helm
+// operator, which rejects custom resources that could never be released.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	crmanager "sigs.k8s.io/controller-runtime/pkg/manager"
+	crwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/operator-framework/operator-sdk/internal/helm/release"
+)
+
+// Path returns the path the validating webhook of gvk is served at. It
+// matches the path that controller-gen generates for Go operators.
+func Path(gvk schema.GroupVersionKind) string {
+	return fmt.Sprintf("/validate-%s-%s-%s",
+		strings.ReplaceAll(gvk.Group, ".", "-"), gvk.Version, strings.ToLower(gvk.Kind))
+}
+
+// validator rejects CRs whose name collides with a release of another chart
+// in their namespace, and CRs whose values do not match the values schema of
+// the chart.
+type validator struct {
+	chart          *chart.Chart
+	overrideValues map[string]string
+	storageFor     func(namespace string) *storage.Storage
+	reader         crclient.Reader
+}
+
+var _ admission.Handler = &validator{}
+
+// Add registers the validating webhook of the CRs of gvk, which are released
+// with the chart at chartPath, with the webhook server of mgr.
+func Add(mgr crmanager.Manager, gvk schema.GroupVersionKind, chartPath string, overrideValues map[string]string) error {
+	chrt, err := loader.Load(chartPath)
+	if err != nil {
+		return fmt.Errorf("failed to load chart: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("failed to get clientset: %w", err)
+	}
+	v := &validator{
+		chart:          chrt,
+		overrideValues: overrideValues,
+		storageFor: func(namespace string) *storage.Storage {
+			return storage.Init(driver.NewSecrets(clientset.CoreV1().Secrets(namespace)))
+		},
+		reader: mgr.GetAPIReader(),
+	}
+	mgr.GetWebhookServer().Register(Path(gvk), &crwebhook.Admission{Handler: v})
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	cr := &unstructured.Unstructured{}
+	if err := json.Unmarshal(req.Object.Raw, &cr.Object); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	// A CR that is being deleted must remain updatable, so that the finalizer
+	// of its release can be removed.
+	if cr.GetDeletionTimestamp() != nil {
+		return admission.Allowed("")
+	}
+	// Neither the release name nor the values change with updates that leave
+	// the spec alone, such as those of the operator adding its finalizer, so
+	// they are allowed even if the CR would be rejected now.
+	if req.Operation == admissionv1.Update {
+		old := &unstructured.Unstructured{}
+		if err := json.Unmarshal(req.OldObject.Raw, &old.Object); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if equality.Semantic.DeepEqual(old.Object["spec"], cr.Object["spec"]) {
+			return admission.Allowed("")
+		}
+	}
+	// The namespace of a CR being created may only be set in the request.
+	if cr.GetNamespace() == "" {
+		cr.SetNamespace(req.Namespace)
+	}
+
+	if _, err := release.ReleaseName(v.storageFor(cr.GetNamespace()), v.chart.Name(), cr); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	values, err := release.Values(ctx, v.reader, cr, v.overrideValues)
+	if release.IsValuesNotFound(err) {
+		// The referenced values may be created after the CR, so they cannot
+		// be validated yet. The operator reports them until they exist.
+		return admission.Allowed("")
+	} else if err != nil {
+		return admission.Denied(err.Error())
+	}
+	// Like helm, validate the values merged with the defaults of the chart.
+	coalesced, err := chartutil.CoalesceValues(v.chart, values)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	if err := chartutil.ValidateAgainstSchema(v.chart, coalesced); err != nil {
+		return admission.Denied(fmt.Sprintf("values do not match the schema of chart %q: %v", v.chart.Name(), err))
+	}
+	return admission.Allowed("")
+}
@@ -0,0 +1,150 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	rpb "helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const testSchema = `{
+  "type": "object",
+  "properties": {
+    "replicaCount": {"type": "integer", "minimum": 1}
+  }
+}`
+
+func TestPath(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "cache.example.com", Version: "v1alpha1", Kind: "Memcached"}
+	assert.Equal(t, "/validate-cache-example-com-v1alpha1-memcached", Path(gvk))
+}
+
+func TestHandle(t *testing.T) {
+	storageBackend := storage.Init(driver.NewMemory())
+	other := &rpb.Release{
+		Name:      "taken",
+		Namespace: "ns",
+		Version:   1,
+		Info:      &rpb.Info{Status: rpb.StatusDeployed},
+		Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "other"}},
+	}
+	assert.NoError(t, storageBackend.Create(other))
+
+	v := &validator{
+		chart: &chart.Chart{
+			Metadata: &chart.Metadata{Name: "test", Version: "0.1.0"},
+			Values:   map[string]interface{}{"replicaCount": 1},
+			Schema:   []byte(testSchema),
+		},
+		storageFor: func(string) *storage.Storage { return storageBackend },
+		reader: fake.NewClientBuilder().WithObjects(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "values"},
+			Data:       map[string][]byte{"values.yaml": []byte("replicaCount: 0")},
+		}).Build(),
+	}
+
+	tests := []struct {
+		name    string
+		old     string
+		object  string
+		allowed bool
+	}{
+		{
+			name:    "valid",
+			object:  `{"metadata":{"name":"test"},"spec":{"replicaCount":3}}`,
+			allowed: true,
+		},
+		{
+			name:    "valid defaults",
+			object:  `{"metadata":{"name":"test"},"spec":{}}`,
+			allowed: true,
+		},
+		{
+			name:   "release name collision",
+			object: `{"metadata":{"name":"taken"},"spec":{}}`,
+		},
+		{
+			name:   "invalid values",
+			object: `{"metadata":{"name":"test"},"spec":{"replicaCount":0}}`,
+		},
+		{
+			name:   "invalid referenced values",
+			object: `{"metadata":{"name":"test"},"spec":{"valuesFrom":[{"kind":"Secret","name":"values"}]}}`,
+		},
+		{
+			name:    "valid referenced values overridden by spec",
+			object:  `{"metadata":{"name":"test"},"spec":{"valuesFrom":[{"kind":"Secret","name":"values"}],"replicaCount":2}}`,
+			allowed: true,
+		},
+		{
+			name:    "missing referenced values",
+			object:  `{"metadata":{"name":"test"},"spec":{"valuesFrom":[{"kind":"Secret","name":"missing"}]}}`,
+			allowed: true,
+		},
+		{
+			name:    "missing referenced key",
+			object:  `{"metadata":{"name":"test"},"spec":{"valuesFrom":[{"kind":"Secret","name":"values","key":"missing"}]}}`,
+			allowed: true,
+		},
+		{
+			name:   "invalid values updated",
+			old:    `{"metadata":{"name":"test"},"spec":{"replicaCount":1}}`,
+			object: `{"metadata":{"name":"test"},"spec":{"replicaCount":0}}`,
+		},
+		{
+			name:    "invalid values with metadata updated",
+			old:     `{"metadata":{"name":"test"},"spec":{"replicaCount":0}}`,
+			object:  `{"metadata":{"name":"test","finalizers":["uninstall-helm-release"]},"spec":{"replicaCount":0}}`,
+			allowed: true,
+		},
+		{
+			name:    "deleted",
+			object:  `{"metadata":{"name":"taken","deletionTimestamp":"2021-01-01T00:00:00Z"},"spec":{}}`,
+			allowed: true,
+		},
+		{
+			name:   "missing spec",
+			object: `{"metadata":{"name":"test"}}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Namespace: "ns",
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: []byte(tc.object)},
+			}}
+			if tc.old != "" {
+				req.Operation = admissionv1.Update
+				req.OldObject = runtime.RawExtension{Raw: []byte(tc.old)}
+			}
+			resp := v.Handle(context.TODO(), req)
+			assert.Equal(t, tc.allowed, resp.Allowed, resp.Result.Message)
+		})
+	}
+}
@@ -132,6 +132,13 @@ rules:
       - patch
       - update
       - watch
+  # Watches with snakeCaseFromSchema read the schema of their CRD at startup.
+  - apiGroups:
+      - apiextensions.k8s.io
+    resources:
+      - customresourcedefinitions
+    verbs:
+      - get
 %s
 `
 
@@ -0,0 +1,280 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// SpecSchema returns a structural OpenAPI v3 schema for the spec of a custom
+// resource whose spec holds the values of chrt. The schema is converted from
+// the chart's values.schema.json if it has one, and is otherwise inferred from
+// the types of the chart's default values.
+func SpecSchema(chrt *chart.Chart) (*apiextv1.JSONSchemaProps, error) {
+	if len(chrt.Schema) == 0 {
+		schema := inferSchema(chrt.Values)
+		return &schema, nil
+	}
+
+	var root map[string]interface{}
+	if err := json.Unmarshal(chrt.Schema, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse values.schema.json: %w", err)
+	}
+	c := schemaConverter{root: root, refs: map[string]bool{}}
+	out := c.convert(root)
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	schema := apiextv1.JSONSchemaProps{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return nil, fmt.Errorf("failed to convert values.schema.json: %w", err)
+	}
+	if schema.Type != "object" {
+		return nil, fmt.Errorf("values.schema.json must describe an object")
+	}
+	return &schema, nil
+}
+
+// inferSchema returns a schema that accepts values of the same types as v.
+// Empty objects and the elements of arrays of objects preserve unknown fields,
+// since they are usually filled in by users with arbitrary content.
+func inferSchema(v interface{}) apiextv1.JSONSchemaProps {
+	preserve := true
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 {
+			return apiextv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: &preserve}
+		}
+		props := make(map[string]apiextv1.JSONSchemaProps, len(val))
+		for k, elem := range val {
+			props[k] = inferSchema(elem)
+		}
+		return apiextv1.JSONSchemaProps{Type: "object", Properties: props}
+	case []interface{}:
+		items := apiextv1.JSONSchemaProps{XPreserveUnknownFields: &preserve}
+		if len(val) > 0 {
+			if _, isObject := val[0].(map[string]interface{}); isObject {
+				items.Type = "object"
+			} else {
+				items = inferSchema(val[0])
+			}
+		}
+		return apiextv1.JSONSchemaProps{Type: "array", Items: &apiextv1.JSONSchemaPropsOrArray{Schema: &items}}
+	case string:
+		return apiextv1.JSONSchemaProps{Type: "string"}
+	case bool:
+		return apiextv1.JSONSchemaProps{Type: "boolean"}
+	case float64:
+		if val == math.Trunc(val) {
+			return apiextv1.JSONSchemaProps{Type: "integer"}
+		}
+		return apiextv1.JSONSchemaProps{Type: "number"}
+	case int, int64:
+		return apiextv1.JSONSchemaProps{Type: "integer"}
+	}
+	// A null default value may be replaced by anything.
+	return apiextv1.JSONSchemaProps{Nullable: true, XPreserveUnknownFields: &preserve}
+}
+
+// schemaKeywords are the JSON schema keywords that are copied as-is into a
+// structural schema. Other keywords are either converted or dropped.
+var schemaKeywords = map[string]bool{
+	"description":   true,
+	"title":         true,
+	"format":        true,
+	"maximum":       true,
+	"minimum":       true,
+	"maxLength":     true,
+	"minLength":     true,
+	"pattern":       true,
+	"maxItems":      true,
+	"minItems":      true,
+	"uniqueItems":   true,
+	"multipleOf":    true,
+	"enum":          true,
+	"maxProperties": true,
+	"minProperties": true,
+	"nullable":      true,
+}
+
+// schemaConverter converts a JSON schema to a structural schema, resolving
+// local references against root.
+type schemaConverter struct {
+	root map[string]interface{}
+	// refs holds the references being resolved, to detect cycles.
+	refs map[string]bool
+}
+
+func (c schemaConverter) convert(in map[string]interface{}) map[string]interface{} {
+	if ref, ok := in["$ref"].(string); ok {
+		return c.convertRef(ref)
+	}
+
+	out := map[string]interface{}{}
+	for k, v := range in {
+		if schemaKeywords[k] {
+			out[k] = v
+		}
+	}
+	if v, ok := in["const"]; ok {
+		out["enum"] = []interface{}{v}
+	}
+	// Draft 6 and later use numbers for exclusive bounds, OpenAPI v3 uses
+	// booleans that modify minimum and maximum.
+	for _, bound := range []string{"Maximum", "Minimum"} {
+		switch v := in["exclusive"+bound].(type) {
+		case bool:
+			out["exclusive"+bound] = v
+		case float64:
+			out[strings.ToLower(bound)] = v
+			out["exclusive"+bound] = true
+		}
+	}
+
+	switch t := in["type"].(type) {
+	case string:
+		out["type"] = t
+	case []interface{}:
+		types := []string{}
+		for _, elem := range t {
+			if s, ok := elem.(string); ok && s != "null" {
+				types = append(types, s)
+			} else if ok {
+				out["nullable"] = true
+			}
+		}
+		sort.Strings(types)
+		switch {
+		case len(types) == 1:
+			out["type"] = types[0]
+		case len(types) == 2 && types[0] == "integer" && types[1] == "string":
+			out["x-kubernetes-int-or-string"] = true
+		}
+	}
+	if _, ok := in["type"]; !ok {
+		if _, hasProps := in["properties"]; hasProps {
+			out["type"] = "object"
+		} else if _, hasItems := in["items"]; hasItems {
+			out["type"] = "array"
+		} else if enum, ok := out["enum"].([]interface{}); ok {
+			out["type"] = enumType(enum)
+		}
+	}
+
+	switch out["type"] {
+	case "", nil:
+		delete(out, "type")
+		if out["x-kubernetes-int-or-string"] == nil {
+			out["x-kubernetes-preserve-unknown-fields"] = true
+		}
+	case "object":
+		c.convertObject(in, out)
+	case "array":
+		items := map[string]interface{}{"x-kubernetes-preserve-unknown-fields": true}
+		if schema, ok := in["items"].(map[string]interface{}); ok {
+			items = c.convert(schema)
+		}
+		out["items"] = items
+	}
+	return out
+}
+
+// enumType returns the type of the values of an enum, or "" if they do not
+// all have the same type.
+func enumType(enum []interface{}) string {
+	t := ""
+	for _, v := range enum {
+		var vt string
+		switch n := v.(type) {
+		case string:
+			vt = "string"
+		case bool:
+			vt = "boolean"
+		case float64:
+			vt = "number"
+			if n == math.Trunc(n) {
+				vt = "integer"
+			}
+		default:
+			return ""
+		}
+		if t == "integer" && vt == "number" || t == "number" && vt == "integer" {
+			vt = "number"
+		} else if t != "" && t != vt {
+			return ""
+		}
+		t = vt
+	}
+	return t
+}
+
+func (c schemaConverter) convertObject(in, out map[string]interface{}) {
+	props, _ := in["properties"].(map[string]interface{})
+	if len(props) > 0 {
+		outProps := make(map[string]interface{}, len(props))
+		for k, v := range props {
+			if schema, ok := v.(map[string]interface{}); ok {
+				outProps[k] = c.convert(schema)
+			} else {
+				outProps[k] = map[string]interface{}{"x-kubernetes-preserve-unknown-fields": true}
+			}
+		}
+		out["properties"] = outProps
+		if required, ok := in["required"]; ok {
+			out["required"] = required
+		}
+		return
+	}
+	// Structural schemas do not allow properties and additionalProperties to
+	// be used together, so they are only kept for map-like objects.
+	if schema, ok := in["additionalProperties"].(map[string]interface{}); ok {
+		out["additionalProperties"] = c.convert(schema)
+		return
+	}
+	out["x-kubernetes-preserve-unknown-fields"] = true
+}
+
+// convertRef resolves a reference of the form "#/definitions/name" or
+// "#/$defs/name". Unresolvable and recursive references accept any value.
+func (c schemaConverter) convertRef(ref string) map[string]interface{} {
+	anyValue := map[string]interface{}{"x-kubernetes-preserve-unknown-fields": true}
+	if c.refs[ref] || !strings.HasPrefix(ref, "#/") {
+		return anyValue
+	}
+	var node interface{} = c.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return anyValue
+		}
+		node = m[strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")]
+	}
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return anyValue
+	}
+	c.refs[ref] = true
+	defer delete(c.refs, ref)
+	return c.convert(schema)
+}
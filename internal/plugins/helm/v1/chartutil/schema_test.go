@@ -0,0 +1,198 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartutil_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/operator-framework/operator-sdk/internal/plugins/helm/v1/chartutil"
+)
+
+func TestSpecSchema(t *testing.T) {
+	testCases := []struct {
+		name      string
+		values    string
+		schema    string
+		expected  string
+		expectErr bool
+	}{
+		{
+			name: "inferred from values",
+			values: `
+replicaCount: 1
+ratio: 0.5
+enabled: true
+image:
+  repository: nginx
+resources: {}
+tolerations: []
+ports: [80]
+hosts:
+- host: example.com
+nameOverride:
+`,
+			expected: `
+properties:
+  enabled:
+    type: boolean
+  hosts:
+    items:
+      type: object
+      x-kubernetes-preserve-unknown-fields: true
+    type: array
+  image:
+    properties:
+      repository:
+        type: string
+    type: object
+  nameOverride:
+    nullable: true
+    x-kubernetes-preserve-unknown-fields: true
+  ports:
+    items:
+      type: integer
+    type: array
+  ratio:
+    type: number
+  replicaCount:
+    type: integer
+  resources:
+    type: object
+    x-kubernetes-preserve-unknown-fields: true
+  tolerations:
+    items:
+      x-kubernetes-preserve-unknown-fields: true
+    type: array
+type: object
+`,
+		},
+		{
+			name: "converted from values.schema.json",
+			values: `
+replicaCount: 1
+`,
+			schema: `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["image"],
+  "additionalProperties": false,
+  "definitions": {
+    "port": {"type": "integer", "exclusiveMinimum": 0, "default": 80}
+  },
+  "properties": {
+    "replicaCount": {"type": "integer", "minimum": 1, "examples": [3]},
+    "image": {
+      "type": "object",
+      "properties": {
+        "repository": {"type": "string", "description": "The image repository."},
+        "pullPolicy": {"enum": ["Always", "IfNotPresent"]}
+      }
+    },
+    "port": {"$ref": "#/definitions/port"},
+    "maxUnavailable": {"type": ["integer", "string"]},
+    "nameOverride": {"type": ["string", "null"]},
+    "mode": {"const": "fast"},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "extra": {"type": "object"},
+    "args": {"type": "array"},
+    "any": {"oneOf": [{"type": "string"}, {"type": "integer"}]}
+  }
+}`,
+			expected: `
+properties:
+  any:
+    x-kubernetes-preserve-unknown-fields: true
+  args:
+    items:
+      x-kubernetes-preserve-unknown-fields: true
+    type: array
+  extra:
+    type: object
+    x-kubernetes-preserve-unknown-fields: true
+  image:
+    properties:
+      pullPolicy:
+        enum:
+        - Always
+        - IfNotPresent
+        type: string
+      repository:
+        description: The image repository.
+        type: string
+    type: object
+  labels:
+    additionalProperties:
+      type: string
+    type: object
+  maxUnavailable:
+    x-kubernetes-int-or-string: true
+  mode:
+    enum:
+    - fast
+    type: string
+  nameOverride:
+    nullable: true
+    type: string
+  port:
+    exclusiveMinimum: true
+    minimum: 0
+    type: integer
+  replicaCount:
+    minimum: 1
+    type: integer
+required:
+- image
+type: object
+`,
+		},
+		{
+			name:      "values.schema.json not an object",
+			schema:    `{"type": "string"}`,
+			expectErr: true,
+		},
+		{
+			name:      "invalid values.schema.json",
+			schema:    `{`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chrt := &chart.Chart{Metadata: &chart.Metadata{Name: "test"}, Schema: []byte(tc.schema)}
+			if err := yaml.Unmarshal([]byte(tc.values), &chrt.Values); err != nil {
+				t.Fatalf("Failed to parse values: %v", err)
+			}
+
+			schema, err := chartutil.SpecSchema(chrt)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			expected := &apiextv1.JSONSchemaProps{}
+			if err := yaml.Unmarshal([]byte(tc.expected), expected); err != nil {
+				t.Fatalf("Failed to parse expected schema: %v", err)
+			}
+			assert.Equal(t, expected, schema)
+		})
+	}
+}
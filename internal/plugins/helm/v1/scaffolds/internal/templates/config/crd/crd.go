@@ -17,9 +17,16 @@ package crd
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/kr/text"
+	"helm.sh/helm/v3/pkg/chart"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/yaml"
+
+	"github.com/operator-framework/operator-sdk/internal/helm/release"
+	"github.com/operator-framework/operator-sdk/internal/plugins/helm/v1/chartutil"
 )
 
 var _ machinery.Template = &CRD{}
@@ -28,6 +35,11 @@ var _ machinery.Template = &CRD{}
 type CRD struct {
 	machinery.TemplateMixin
 	machinery.ResourceMixin
+
+	// Chart, if set, is used to generate the schema of the CRD's spec.
+	Chart *chart.Chart
+	// SpecSchema is the indented YAML schema of the CRD's spec.
+	SpecSchema string
 }
 
 // SetTemplateDefaults implements machinery.Template
@@ -39,6 +51,37 @@ func (f *CRD) SetTemplateDefaults() error {
 
 	f.IfExistsAction = machinery.Error
 
+	if f.SpecSchema == "" {
+		schema := fmt.Sprintf(defaultSpecSchema, f.Resource.Kind)
+		if f.Chart != nil {
+			specSchema, err := chartutil.SpecSchema(f.Chart)
+			if err != nil {
+				return fmt.Errorf("failed to generate spec schema from chart %s: %w", f.Chart.Name(), err)
+			}
+			specSchema.Description = fmt.Sprintf("Spec defines the desired state of %s", f.Resource.Kind)
+			// valuesFrom and upgradeRollback are read by the operator rather
+			// than the chart, but must be in the schema so that they are not
+			// pruned from the spec.
+			if specSchema.Properties == nil {
+				specSchema.Properties = map[string]apiextv1.JSONSchemaProps{}
+			}
+			specSchema.Properties[release.ValuesFromField] = valuesFromSchema
+			specSchema.Properties[release.UpgradeRollbackField] = upgradeRollbackSchema
+			b, err := yaml.Marshal(specSchema)
+			if err != nil {
+				return err
+			}
+			schema = string(b)
+		}
+		// The spec schema is nested under spec in versions[].schema for v1
+		// CRDs, and under validation for v1beta1 CRDs.
+		indent := "            "
+		if f.Resource.API.CRDVersion == "v1beta1" {
+			indent = "          "
+		}
+		f.SpecSchema = "\n" + strings.TrimSuffix(text.Indent(schema, indent), "\n")
+	}
+
 	f.TemplateBody = fmt.Sprintf(crdTemplate,
 		text.Indent(openAPIV3SchemaTemplate, "    "),
 		text.Indent(openAPIV3SchemaTemplate, "      "),
@@ -80,6 +123,57 @@ spec:
 {{- end }}
 `
 
+const defaultSpecSchema = `description: Spec defines the desired state of %s
+type: object
+x-kubernetes-preserve-unknown-fields: true
+`
+
+// valuesFromSchema is the schema of the list of ConfigMaps and Secrets that
+// the operator reads chart values from.
+var valuesFromSchema = apiextv1.JSONSchemaProps{
+	Description: "ValuesFrom lists ConfigMaps and Secrets in the namespace of this resource to read chart values from. Values set in the spec take precedence over them.",
+	Type:        "array",
+	Items: &apiextv1.JSONSchemaPropsOrArray{Schema: &apiextv1.JSONSchemaProps{
+		Type:     "object",
+		Required: []string{"kind", "name"},
+		Properties: map[string]apiextv1.JSONSchemaProps{
+			"kind": {
+				Type: "string",
+				Enum: []apiextv1.JSON{
+					{Raw: []byte(`"` + release.ValuesKindConfigMap + `"`)},
+					{Raw: []byte(`"` + release.ValuesKindSecret + `"`)},
+				},
+			},
+			"name": {Type: "string"},
+			"key": {
+				Description: "Key of the values in the object's data. Defaults to " + release.DefaultValuesKey + ".",
+				Type:        "string",
+			},
+			"optional": {
+				Description: "Optional references are skipped if the object or key does not exist.",
+				Type:        "boolean",
+			},
+		},
+	}},
+}
+
+// upgradeRollbackSchema is the schema of the configuration of rolling back
+// upgrades that do not become ready.
+var upgradeRollbackSchema = apiextv1.JSONSchemaProps{
+	Description: "UpgradeRollback rolls an upgraded release back to its previous revision if it does not become ready within the timeout.",
+	Type:        "object",
+	Properties: map[string]apiextv1.JSONSchemaProps{
+		"enabled": {
+			Description: "Enabled turns on rolling back upgrades. Defaults to false.",
+			Type:        "boolean",
+		},
+		"timeout": {
+			Description: "Timeout is how long an upgraded release may take to become ready, such as 10m. Defaults to 5m.",
+			Type:        "string",
+		},
+	},
+}
+
 const openAPIV3SchemaTemplate = `openAPIV3Schema:
   description: {{ .Resource.Kind }} is the Schema for the {{ .Resource.Plural }} API
   properties:
@@ -96,9 +190,7 @@ const openAPIV3SchemaTemplate = `openAPIV3Schema:
     metadata:
       type: object
     spec:
-      description: Spec defines the desired state of {{ .Resource.Kind }}
-      type: object
-      x-kubernetes-preserve-unknown-fields: true
+      {{- .SpecSchema }}
     status:
       description: Status defines the observed state of {{ .Resource.Kind }}
       type: object
@@ -0,0 +1,131 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crd
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCRDSpecSchema(t *testing.T) {
+	valuesChart := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "test"},
+		Values: map[string]interface{}{
+			"replicaCount":   float64(1),
+			"image":          map[string]interface{}{"repository": "nginx"},
+			"podAnnotations": map[string]interface{}{},
+		},
+	}
+	schemaChart := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "test"},
+		Schema: []byte(`{
+  "type": "object",
+  "properties": {
+    "replicaCount": {"type": "integer", "description": "Use {{ .Values.replicaCount }} pods."},
+    "image": {"type": "object", "properties": {"repository": {"type": "string"}}}
+  }
+}`),
+	}
+
+	for _, crdVersion := range []string{"v1", "v1beta1"} {
+		for name, chrt := range map[string]*chart.Chart{"no chart": nil, "values": valuesChart, "schema": schemaChart} {
+			t.Run(crdVersion+" "+name, func(t *testing.T) {
+				spec := renderCRDSpec(t, crdVersion, chrt)
+				if chrt == nil {
+					assert.Nil(t, spec.Properties)
+					assert.Equal(t, true, *spec.XPreserveUnknownFields)
+					return
+				}
+				assert.Equal(t, "Spec defines the desired state of Memcached", spec.Description)
+				assert.Equal(t, "integer", spec.Properties["replicaCount"].Type)
+				assert.Equal(t, "string", spec.Properties["image"].Properties["repository"].Type)
+				valuesFrom := spec.Properties["valuesFrom"]
+				assert.Equal(t, "array", valuesFrom.Type)
+				assert.Equal(t, []string{"kind", "name"}, valuesFrom.Items.Schema.Required)
+				upgradeRollback := spec.Properties["upgradeRollback"]
+				assert.Equal(t, "object", upgradeRollback.Type)
+				assert.Contains(t, upgradeRollback.Properties, "enabled")
+				assert.Contains(t, upgradeRollback.Properties, "timeout")
+			})
+		}
+	}
+}
+
+// renderCRDSpec renders a CRD and returns the schema of its spec, after
+// checking that the whole CRD schema is structural.
+func renderCRDSpec(t *testing.T, crdVersion string, chrt *chart.Chart) apiextv1.JSONSchemaProps {
+	f := &CRD{Chart: chrt}
+	f.Resource = &resource.Resource{
+		GVK:    resource.GVK{Group: "cache", Domain: "example.com", Version: "v1alpha1", Kind: "Memcached"},
+		Plural: "memcacheds",
+		API:    &resource.API{CRDVersion: crdVersion},
+	}
+	if err := f.SetTemplateDefaults(); err != nil {
+		t.Fatalf("Failed to set template defaults: %v", err)
+	}
+	tmpl, err := template.New("crd").Funcs(machinery.DefaultFuncMap()).Parse(f.TemplateBody)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	out := &bytes.Buffer{}
+	if err := tmpl.Execute(out, f); err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+
+	var schema *apiextv1.JSONSchemaProps
+	if crdVersion == "v1" {
+		crd := apiextv1.CustomResourceDefinition{}
+		if err := yaml.UnmarshalStrict(out.Bytes(), &crd); err != nil {
+			t.Fatalf("Failed to parse CRD: %v\n%s", err, out)
+		}
+		schema = crd.Spec.Versions[0].Schema.OpenAPIV3Schema
+	} else {
+		crd := apiextv1beta1.CustomResourceDefinition{}
+		if err := yaml.UnmarshalStrict(out.Bytes(), &crd); err != nil {
+			t.Fatalf("Failed to parse CRD: %v\n%s", err, out)
+		}
+		v1Schema := &apiextv1.JSONSchemaProps{}
+		b, _ := yaml.Marshal(crd.Spec.Validation.OpenAPIV3Schema)
+		if err := yaml.Unmarshal(b, v1Schema); err != nil {
+			t.Fatalf("Failed to convert schema: %v", err)
+		}
+		schema = v1Schema
+	}
+
+	internalSchema := &apiextensions.JSONSchemaProps{}
+	if err := apiextv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(schema, internalSchema, nil); err != nil {
+		t.Fatalf("Failed to convert schema: %v", err)
+	}
+	structural, err := structuralschema.NewStructural(internalSchema)
+	if err != nil {
+		t.Fatalf("Schema is not structural: %v", err)
+	}
+	if errs := structuralschema.ValidateStructural(field.NewPath("openAPIV3Schema"), structural); len(errs) > 0 {
+		t.Fatalf("Schema is not structural: %v", errs.ToAggregate())
+	}
+	return schema.Properties["spec"]
+}
@@ -131,13 +131,22 @@ rules:
   - namespaces
   verbs:
   - get
-# We need to manage Helm release secrets
+# We need to manage Helm release secrets and read the Secrets that CRs reference values from
 - apiGroups:
   - ""
   resources:
   - secrets
   verbs:
   - "*"
+# We need to read and watch the ConfigMaps that CRs reference values from
+- apiGroups:
+  - ""
+  resources:
+  - configmaps
+  verbs:
+  - get
+  - list
+  - watch
 # We need to create events on CRs about things happening during reconciliation
 - apiGroups:
   - ""
@@ -0,0 +1,109 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+
+	"github.com/operator-framework/operator-sdk/internal/helm/webhook"
+)
+
+var (
+	_ machinery.Template = &Manifests{}
+	_ machinery.Inserter = &Manifests{}
+)
+
+// Manifests scaffolds the ValidatingWebhookConfiguration of the helm-operator,
+// with a webhook for each resource.
+type Manifests struct {
+	machinery.TemplateMixin
+	machinery.ResourceMixin
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *Manifests) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "webhook", "manifests.yaml")
+	}
+
+	f.TemplateBody = fmt.Sprintf(manifestsTemplate, machinery.NewMarkerFor(f.Path, webhookMarker))
+
+	return nil
+}
+
+const (
+	webhookMarker = "validatingwebhooks"
+)
+
+// GetMarkers implements machinery.Inserter
+func (f *Manifests) GetMarkers() []machinery.Marker {
+	return []machinery.Marker{
+		machinery.NewMarkerFor(f.Path, webhookMarker),
+	}
+}
+
+const (
+	webhookCodeFragment = `- admissionReviewVersions:
+  - v1
+  - v1beta1
+  clientConfig:
+    service:
+      name: webhook-service
+      namespace: system
+      path: %s
+  failurePolicy: Fail
+  name: v%s.%s
+  rules:
+  - apiGroups:
+    - %s
+    apiVersions:
+    - %s
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - %s
+  sideEffects: None
+`
+)
+
+// GetCodeFragments implements machinery.Inserter
+func (f *Manifests) GetCodeFragments() machinery.CodeFragmentsMap {
+	gvk := schema.GroupVersionKind{
+		Group:   f.Resource.QualifiedGroup(),
+		Version: f.Resource.Version,
+		Kind:    f.Resource.Kind,
+	}
+	return machinery.CodeFragmentsMap{
+		machinery.NewMarkerFor(f.Path, webhookMarker): []string{
+			fmt.Sprintf(webhookCodeFragment, webhook.Path(gvk), strings.ToLower(gvk.Kind), gvk.Group,
+				gvk.Group, gvk.Version, f.Resource.Plural),
+		},
+	}
+}
+
+const manifestsTemplate = `---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: validating-webhook-configuration
+webhooks:
+%s
+`
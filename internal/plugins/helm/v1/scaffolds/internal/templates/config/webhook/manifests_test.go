@@ -0,0 +1,44 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+	"sigs.k8s.io/yaml"
+)
+
+func TestManifestsCodeFragments(t *testing.T) {
+	f := &Manifests{}
+	f.Resource = &resource.Resource{
+		GVK:    resource.GVK{Group: "cache", Domain: "example.com", Version: "v1alpha1", Kind: "Memcached"},
+		Plural: "memcacheds",
+	}
+	assert.NoError(t, f.SetTemplateDefaults())
+
+	fragments := f.GetCodeFragments()[f.GetMarkers()[0]]
+	assert.Len(t, fragments, 1)
+
+	var webhooks []admissionregistrationv1.ValidatingWebhook
+	assert.NoError(t, yaml.UnmarshalStrict([]byte(fragments[0]), &webhooks))
+	assert.Len(t, webhooks, 1)
+	assert.Equal(t, "vmemcached.cache.example.com", webhooks[0].Name)
+	assert.Equal(t, "/validate-cache-example-com-v1alpha1-memcached", *webhooks[0].ClientConfig.Service.Path)
+	assert.Equal(t, []string{"cache.example.com"}, webhooks[0].Rules[0].APIGroups)
+	assert.Equal(t, []string{"memcacheds"}, webhooks[0].Rules[0].Resources)
+}
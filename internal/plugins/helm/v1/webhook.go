@@ -0,0 +1,147 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin"
+
+	"github.com/operator-framework/operator-sdk/internal/plugins/helm/v1/scaffolds"
+	sdkutil "github.com/operator-framework/operator-sdk/internal/util"
+)
+
+const (
+	webhookVersion = "v1"
+
+	enableWebhooksArg = "--enable-webhooks"
+)
+
+// mutatingCAInjectionPatch is the part of the CA injection patch scaffolded by
+// the kustomize plugin that targets a MutatingWebhookConfiguration, which the
+// helm-operator does not have.
+const mutatingCAInjectionPatch = `apiVersion: admissionregistration.k8s.io/v1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: mutating-webhook-configuration
+  annotations:
+    cert-manager.io/inject-ca-from: $(CERTIFICATE_NAMESPACE)/$(CERTIFICATE_NAME)
+---
+`
+
+var _ plugin.CreateWebhookSubcommand = &createWebhookSubcommand{}
+
+type createWebhookSubcommand struct {
+	config   config.Config
+	resource *resource.Resource
+}
+
+func (p *createWebhookSubcommand) UpdateMetadata(cliMeta plugin.CLIMetadata, subcmdMeta *plugin.SubcommandMetadata) {
+	subcmdMeta.Description = `Scaffold a validating admission webhook for a Helm-based API.
+
+The webhook rejects custom resources whose name collides with a release of
+another chart in their namespace, and custom resources whose spec does not match
+the values.schema.json of the chart. The API must have been created first.
+`
+	subcmdMeta.Examples = fmt.Sprintf(`  $ %s create webhook \
+      --group=apps --version=v1alpha1 \
+      --kind=AppService
+`, cliMeta.CommandName)
+}
+
+func (p *createWebhookSubcommand) InjectConfig(c config.Config) error {
+	p.config = c
+
+	return nil
+}
+
+func (p *createWebhookSubcommand) InjectResource(res *resource.Resource) error {
+	p.resource = res
+
+	existing, err := p.config.GetResource(p.resource.GVK)
+	if err != nil || !existing.HasAPI() {
+		return fmt.Errorf("the API of %s must be created before its webhook", p.resource.GVK.Kind)
+	}
+	if existing.HasValidationWebhook() {
+		return errors.New("the validating webhook already exists")
+	}
+
+	p.resource.Plural = existing.Plural
+	p.resource.Webhooks = &resource.Webhooks{
+		WebhookVersion: webhookVersion,
+		Validation:     true,
+	}
+
+	return p.resource.Validate()
+}
+
+func (p *createWebhookSubcommand) Scaffold(fs machinery.Filesystem) error {
+	scaffolder := scaffolds.NewWebhookScaffolder(p.config, *p.resource)
+	scaffolder.InjectFS(fs)
+	return scaffolder.Scaffold()
+}
+
+func (p *createWebhookSubcommand) PostScaffold() error {
+	return addWebhookCustomizations()
+}
+
+// addWebhookCustomizations enables the webhooks of the helm-operator in the
+// manager, and removes the parts of the webhook configuration scaffolded by
+// the kustomize plugin that the helm-operator does not need.
+func addWebhookCustomizations() error {
+	managerFile := filepath.Join("config", "manager", "manager.yaml")
+	authProxyPatchFile := filepath.Join("config", "default", "manager_auth_proxy_patch.yaml")
+	caInjectionPatchFile := filepath.Join("config", "default", "webhookcainjection_patch.yaml")
+
+	// Enable the webhooks in config/manager/manager.yaml and in config/default/manager_auth_proxy_patch.yaml,
+	// unless a previous webhook already did.
+	if err := insertIfMissing(managerFile, "--leader-elect",
+		fmt.Sprintf("\n        - %s", enableWebhooksArg)); err != nil {
+		return err
+	}
+	if err := insertIfMissing(authProxyPatchFile, "- \"--leader-elect\"",
+		fmt.Sprintf("\n        - \"%s\"", enableWebhooksArg)); err != nil {
+		return err
+	}
+
+	contents, err := ioutil.ReadFile(caInjectionPatchFile)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(contents), mutatingCAInjectionPatch) {
+		return sdkutil.ReplaceInFile(caInjectionPatchFile, mutatingCAInjectionPatch, "")
+	}
+	return nil
+}
+
+// insertIfMissing inserts code after target in filename if filename does not
+// contain enableWebhooksArg yet.
+func insertIfMissing(filename, target, code string) error {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(contents), enableWebhooksArg) {
+		return nil
+	}
+	return sdkutil.InsertCode(filename, target, code)
+}
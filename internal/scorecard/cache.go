@@ -0,0 +1,152 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scorecard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/apis/scorecard/v1alpha3"
+)
+
+// ResultCache stores the last result of each test on disk. A result is keyed
+// by a digest of the test's image, entrypoint and configuration and of the
+// bundle contents, so tests whose inputs have not changed since they were last
+// run are not run again, and is looked up by the test's name, so the last
+// result of a test is found after its inputs change.
+type ResultCache struct {
+	dir          string
+	bundleDigest string
+}
+
+// cacheEntry is the last result of a test.
+type cacheEntry struct {
+	// Key is the digest of the inputs the test ran with.
+	Key    string               `json:"key"`
+	Status *v1alpha3.TestStatus `json:"status"`
+}
+
+// NewResultCache returns a cache of results for the bundle at bundlePath,
+// stored in dir.
+func NewResultCache(dir, bundlePath string) (*ResultCache, error) {
+	digest, err := bundleDigest(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("error computing bundle digest %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating cache directory %w", err)
+	}
+	return &ResultCache{dir: dir, bundleDigest: digest}, nil
+}
+
+// Get returns the last cached result of test, or nil if there is none, and
+// whether that result is current, i.e. was cached for the same bundle and
+// test configuration.
+func (c *ResultCache) Get(test v1alpha3.TestConfiguration) (status *v1alpha3.TestStatus, current bool) {
+	b, err := ioutil.ReadFile(c.path(test))
+	if err != nil {
+		return nil, false
+	}
+	entry := cacheEntry{}
+	if err := json.Unmarshal(b, &entry); err != nil || entry.Status == nil {
+		return nil, false
+	}
+	return entry.Status, entry.Key == c.key(test)
+}
+
+// Put caches status as the last result of test.
+func (c *ResultCache) Put(test v1alpha3.TestConfiguration, status *v1alpha3.TestStatus) error {
+	b, err := json.Marshal(cacheEntry{Key: c.key(test), Status: status})
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so a partially written result is never read.
+	f, err := ioutil.TempFile(c.dir, ".result-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.path(test))
+}
+
+// path returns the file holding the last result of test, which is named
+// after a digest of the test's name.
+func (c *ResultCache) path(test v1alpha3.TestConfiguration) string {
+	h := sha256.Sum256([]byte(cacheName(test)))
+	return filepath.Join(c.dir, hex.EncodeToString(h[:])+".json")
+}
+
+// key returns a digest of the inputs of test: its image, entrypoint and
+// configuration, and the bundle contents.
+func (c *ResultCache) key(test v1alpha3.TestConfiguration) string {
+	// Marshaling a TestConfiguration cannot fail, and sorts its labels.
+	b, _ := json.Marshal(test)
+	h := sha256.New()
+	h.Write([]byte(c.bundleDigest))
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheName returns the name test is cached under, which is its "test" label,
+// as set for the built-in tests, or its image and entrypoint if it has no
+// such label.
+func cacheName(test v1alpha3.TestConfiguration) string {
+	if name := test.Labels["test"]; name != "" {
+		return name
+	}
+	return strings.Join(append([]string{test.Image}, test.Entrypoint...), "\x00")
+}
+
+// bundleDigest returns a digest of the names and contents of the files in the
+// bundle directory at root.
+func bundleDigest(root string) (string, error) {
+	h := sha256.New()
+	// Walk visits files in lexical order, so the digest is deterministic.
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), info.Size())
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
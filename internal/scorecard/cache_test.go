@@ -0,0 +1,189 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scorecard
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/apis/scorecard/v1alpha3"
+)
+
+// countingTestRunner passes tests whose image is "pass", fails all others,
+// and counts the tests it runs.
+type countingTestRunner struct {
+	mu          sync.Mutex
+	initialized int
+	ran         map[string]int
+}
+
+func (r *countingTestRunner) Initialize(ctx context.Context) error {
+	r.initialized++
+	return nil
+}
+
+func (r *countingTestRunner) Cleanup(ctx context.Context) error {
+	return nil
+}
+
+func (r *countingTestRunner) RunTest(ctx context.Context, test v1alpha3.TestConfiguration) (*v1alpha3.TestStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ran[test.Image]++
+	state := v1alpha3.FailState
+	if test.Image == "pass" {
+		state = v1alpha3.PassState
+	}
+	return &v1alpha3.TestStatus{Results: []v1alpha3.TestResult{{Name: test.Image, State: state}}}, nil
+}
+
+func writeBundle(t *testing.T, dir, csv string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, "csv.yaml"), []byte(csv), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResultCache(t *testing.T) {
+	bundleDir, cacheDir := t.TempDir(), t.TempDir()
+	writeBundle(t, bundleDir, "v1")
+
+	runner := &countingTestRunner{ran: map[string]int{}}
+	o := Scorecard{
+		Config: v1alpha3.Configuration{Stages: []v1alpha3.StageConfiguration{
+			{Parallel: true, Tests: []v1alpha3.TestConfiguration{{Image: "pass"}, {Image: "fail"}}},
+		}},
+		TestRunner: runner,
+	}
+	run := func() v1alpha3.TestList {
+		var err error
+		if o.Cache, err = NewResultCache(cacheDir, bundleDir); err != nil {
+			t.Fatal(err)
+		}
+		tests, err := o.Run(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tests.Items) != 2 {
+			t.Fatalf("Expected 2 tests, got %d", len(tests.Items))
+		}
+		return tests
+	}
+	expectRuns := func(initialized, pass, fail int) {
+		t.Helper()
+		if runner.initialized != initialized || runner.ran["pass"] != pass || runner.ran["fail"] != fail {
+			t.Fatalf("Expected %d initializations and %d/%d runs, got %d and %v",
+				initialized, pass, fail, runner.initialized, runner.ran)
+		}
+	}
+
+	run()
+	expectRuns(1, 1, 1)
+
+	// Unchanged inputs are reported from the cache without initializing the runner.
+	tests := run()
+	expectRuns(1, 1, 1)
+	if statusPassed(&tests.Items[0].Status) && statusPassed(&tests.Items[1].Status) {
+		t.Error("Expected the cached failing result to be reported")
+	}
+
+	// Only failing tests are re-run.
+	o.RerunFailed = true
+	run()
+	expectRuns(2, 1, 2)
+	o.RerunFailed = false
+
+	// Changing the configuration of a test re-runs it.
+	o.Config.Stages[0].Tests[0].Entrypoint = []string{"test"}
+	run()
+	expectRuns(3, 2, 2)
+
+	// Changing the bundle re-runs all tests.
+	writeBundle(t, bundleDir, "v2")
+	run()
+	expectRuns(4, 3, 3)
+
+	// After the bundle changes, only tests whose last result failed are re-run,
+	// and the last result of the others is reported.
+	writeBundle(t, bundleDir, "v3")
+	o.RerunFailed = true
+	tests = run()
+	expectRuns(5, 3, 4)
+	for _, test := range tests.Items {
+		if test.Spec.Image == "pass" && !statusPassed(&test.Status) {
+			t.Errorf("Expected the last passing result to be reported, got %+v", test.Status)
+		}
+	}
+	o.RerunFailed = false
+
+	// The passing test's result is not current for the changed bundle.
+	run()
+	expectRuns(6, 4, 4)
+}
+
+func TestResultCacheName(t *testing.T) {
+	bundleDir := t.TempDir()
+	writeBundle(t, bundleDir, "v1")
+	cache, err := NewResultCache(t.TempDir(), bundleDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := v1alpha3.TestConfiguration{
+		Image:      "scorecard-test",
+		Entrypoint: []string{"scorecard-test", "basic-check-spec"},
+		Labels:     map[string]string{"test": "basic-check-spec-test"},
+	}
+	failed := &v1alpha3.TestStatus{Results: []v1alpha3.TestResult{{State: v1alpha3.FailState}}}
+	if err := cache.Put(test, failed); err != nil {
+		t.Fatal(err)
+	}
+	if status, current := cache.Get(test); status == nil || !current {
+		t.Fatalf("Expected a current cached result, got %v, %v", status, current)
+	}
+
+	// A test with the same name but a different image finds the last result,
+	// which is not current.
+	test.Image = "scorecard-test:v2"
+	if status, current := cache.Get(test); status == nil || current {
+		t.Fatalf("Expected a cached result that is not current, got %v, %v", status, current)
+	}
+
+	test.Labels["test"] = "other-test"
+	if status, _ := cache.Get(test); status != nil {
+		t.Fatalf("Expected no cached result for another test, got %v", status)
+	}
+}
+
+func TestResultCacheSkipsErrors(t *testing.T) {
+	bundleDir := t.TempDir()
+	writeBundle(t, bundleDir, "v1")
+	cache, err := NewResultCache(t.TempDir(), bundleDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := getFakeScorecard(false)
+	o.Cache = cache
+	o.TestRunner = FakeTestRunner{Error: context.DeadlineExceeded}
+	if _, err := o.Run(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := cache.Get(v1alpha3.TestConfiguration{}); status != nil {
+		t.Error("Expected a test that errored not to be cached")
+	}
+}
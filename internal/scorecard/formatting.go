@@ -17,9 +17,14 @@ package scorecard
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/operator-framework/api/pkg/apis/scorecard/v1alpha3"
 	v1 "k8s.io/api/core/v1"
+
+	"github.com/operator-framework/operator-sdk/internal/testreport"
 )
 
 // getTestResult fetches the test pod log and converts it into
@@ -51,3 +56,111 @@ func (o Scorecard) List() v1alpha3.TestList {
 	}
 	return output
 }
+
+// TestSuites converts tests into a test report with one suite per configured
+// stage, named "stage-<n>". Test labels are kept as properties, and the errors,
+// suggestions and logs of tests that did not pass are folded into the failure.
+// Tests without results, as returned by List, are reported as skipped.
+func (o Scorecard) TestSuites(tests v1alpha3.TestList) []testreport.Suite {
+	suites := make([]testreport.Suite, len(o.Config.Stages))
+	for i := range o.Config.Stages {
+		suites[i].Name = fmt.Sprintf("stage-%d", i+1)
+	}
+	var unknown []testreport.Case
+	for _, test := range tests.Items {
+		c := testCase(test)
+		if i := o.stageIndex(test.Spec); i >= 0 {
+			suites[i].Cases = append(suites[i].Cases, c)
+		} else {
+			unknown = append(unknown, c)
+		}
+	}
+
+	out := []testreport.Suite{}
+	for _, s := range suites {
+		if len(s.Cases) > 0 {
+			out = append(out, s)
+		}
+	}
+	if len(unknown) > 0 {
+		out = append(out, testreport.Suite{Name: "unknown", Cases: unknown})
+	}
+	return out
+}
+
+// stageIndex returns the index of the first stage containing test, or -1.
+func (o Scorecard) stageIndex(test v1alpha3.TestConfiguration) int {
+	for i, stage := range o.Config.Stages {
+		for _, t := range stage.Tests {
+			if reflect.DeepEqual(t, test) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func testCase(test v1alpha3.Test) testreport.Case {
+	c := testreport.Case{
+		Name:       testName(test),
+		State:      testreport.Passed,
+		Properties: test.Spec.Labels,
+	}
+	if len(test.Status.Results) == 0 {
+		c.State = testreport.Skipped
+		c.Message = "no results"
+		return c
+	}
+
+	details := &strings.Builder{}
+	logs := []string{}
+	for _, r := range test.Status.Results {
+		if r.Log != "" {
+			logs = append(logs, r.Log)
+		}
+		switch r.State {
+		case v1alpha3.PassState:
+			continue
+		case v1alpha3.ErrorState:
+			c.State = testreport.Errored
+		default:
+			if c.State != testreport.Errored {
+				c.State = testreport.Failed
+			}
+		}
+		if c.Message == "" {
+			c.Message = fmt.Sprintf("%s: %s", r.Name, r.State)
+			if len(r.Errors) > 0 {
+				c.Message = fmt.Sprintf("%s: %s", r.Name, r.Errors[0])
+			}
+		}
+		fmt.Fprintf(details, "%s: %s\n", r.Name, r.State)
+		for _, e := range r.Errors {
+			fmt.Fprintf(details, "Error: %s\n", e)
+		}
+		for _, s := range r.Suggestions {
+			fmt.Fprintf(details, "Suggestion: %s\n", s)
+		}
+		if r.Log != "" {
+			fmt.Fprintf(details, "Log:\n%s\n", r.Log)
+		}
+	}
+	c.Details = details.String()
+	// Logs of tests that did not pass are already part of the details.
+	if c.State == testreport.Passed {
+		c.Output = strings.Join(logs, "\n")
+	}
+	return c
+}
+
+// testName returns the "test" label of a test, falling back to the name of its
+// first result and then its image.
+func testName(test v1alpha3.Test) string {
+	if name := test.Spec.Labels["test"]; name != "" {
+		return name
+	}
+	if len(test.Status.Results) > 0 && test.Status.Results[0].Name != "" {
+		return test.Status.Results[0].Name
+	}
+	return test.Spec.Image
+}
@@ -18,7 +18,10 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/operator-framework/api/pkg/apis/scorecard/v1alpha3"
 	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/operator-framework/operator-sdk/internal/testreport"
 )
 
 func TestList(t *testing.T) {
@@ -58,3 +61,48 @@ func TestList(t *testing.T) {
 
 	}
 }
+
+func TestTestSuites(t *testing.T) {
+	basic := v1alpha3.TestConfiguration{Image: "basic", Labels: map[string]string{"test": "basic-test"}}
+	olm := v1alpha3.TestConfiguration{Image: "olm"}
+	o := Scorecard{Config: v1alpha3.Configuration{Stages: []v1alpha3.StageConfiguration{
+		{Tests: []v1alpha3.TestConfiguration{basic}},
+		{Tests: []v1alpha3.TestConfiguration{olm}},
+	}}}
+
+	tests := v1alpha3.NewTestList()
+	pass := v1alpha3.NewTest()
+	pass.Spec = basic
+	pass.Status.Results = []v1alpha3.TestResult{{Name: "basic-check", State: v1alpha3.PassState, Log: "ok"}}
+	fail := v1alpha3.NewTest()
+	fail.Spec = olm
+	fail.Status.Results = []v1alpha3.TestResult{{
+		Name:        "olm-check",
+		State:       v1alpha3.FailState,
+		Errors:      []string{"no CRDs"},
+		Suggestions: []string{"add CRDs"},
+		Log:         "checked",
+	}}
+	tests.Items = append(tests.Items, pass, fail)
+
+	suites := o.TestSuites(tests)
+	if len(suites) != 2 || suites[0].Name != "stage-1" || suites[1].Name != "stage-2" {
+		t.Fatalf("unexpected suites: %+v", suites)
+	}
+
+	p := suites[0].Cases[0]
+	if p.Name != "basic-test" || p.State != testreport.Passed || p.Output != "ok" ||
+		p.Properties["test"] != "basic-test" {
+		t.Errorf("unexpected passing case: %+v", p)
+	}
+	f := suites[1].Cases[0]
+	expected := "olm-check: fail\nError: no CRDs\nSuggestion: add CRDs\nLog:\nchecked\n"
+	if f.Name != "olm-check" || f.State != testreport.Failed || f.Message != "olm-check: no CRDs" || f.Details != expected {
+		t.Errorf("unexpected failing case: %+v", f)
+	}
+
+	listed := o.TestSuites(o.List())
+	if len(listed) != 2 || listed[0].Cases[0].State != testreport.Skipped {
+		t.Errorf("unexpected listed suites: %+v", listed)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scorecard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"github.com/operator-framework/api/pkg/apis/scorecard/v1alpha3"
+	apimanifests "github.com/operator-framework/api/pkg/manifests"
+
+	registryutil "github.com/operator-framework/operator-sdk/internal/registry"
+	"github.com/operator-framework/operator-sdk/internal/scorecard/tests"
+)
+
+// BuiltinEntrypoint is the command of the scorecard-test image, which runs the
+// built-in basic and olm tests.
+const BuiltinEntrypoint = "scorecard-test"
+
+// LocalTestRunner runs tests on the local machine instead of in pods. Built-in
+// tests run in-process against the unpacked bundle. Other tests run their
+// entrypoint as a local executable, with the bundle available at BundleRoot
+// as it would be in a test pod.
+type LocalTestRunner struct {
+	BundlePath     string
+	BundleMetadata registryutil.Labels
+	// BundleRoot is where custom tests expect the bundle to be, PodBundleRoot
+	// if empty. A symlink to BundlePath is created there if it does not exist.
+	BundleRoot string
+
+	bundle *apimanifests.Bundle
+	// mu guards the creation of the bundle root link, and serializes the
+	// bundle validation test, which redirects the global logger.
+	mu sync.Mutex
+	// link is the bundle root symlink created by this runner.
+	link string
+}
+
+// Initialize reads the bundle that tests run against.
+func (r *LocalTestRunner) Initialize(ctx context.Context) (err error) {
+	if r.bundle, err = apimanifests.GetBundleFromDir(r.BundlePath); err != nil {
+		return fmt.Errorf("error reading bundle %w", err)
+	}
+	return ctx.Err()
+}
+
+// Cleanup removes the bundle root symlink, if one was created.
+func (r *LocalTestRunner) Cleanup(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.link == "" {
+		return nil
+	}
+	if err := os.Remove(r.link); err != nil {
+		return fmt.Errorf("error removing bundle link %w", err)
+	}
+	r.link = ""
+	return nil
+}
+
+// RunTest executes a single test, returning ctx's error if it does not
+// complete before ctx is done.
+func (r *LocalTestRunner) RunTest(ctx context.Context, test v1alpha3.TestConfiguration) (*v1alpha3.TestStatus, error) {
+	if len(test.Entrypoint) == 0 {
+		return nil, errors.New("test has no entrypoint")
+	}
+	if test.Entrypoint[0] == BuiltinEntrypoint {
+		return r.runBuiltinTest(ctx, test)
+	}
+	return r.runCommand(ctx, test)
+}
+
+func (r *LocalTestRunner) runBuiltinTest(ctx context.Context, test v1alpha3.TestConfiguration) (*v1alpha3.TestStatus, error) {
+	if len(test.Entrypoint) < 2 {
+		return nil, fmt.Errorf("test name argument is required, valid tests are %v", tests.Names)
+	}
+	name := test.Entrypoint[1]
+
+	done := make(chan *v1alpha3.TestStatus, 1)
+	go func() {
+		if name == tests.OLMBundleValidationTest {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+		}
+		status, ok := tests.Run(name, r.BundlePath, r.BundleMetadata, r.bundle)
+		if !ok {
+			done <- convertErrorToStatus(fmt.Errorf("unknown test %q, valid tests are %v", name, tests.Names), "")
+			return
+		}
+		done <- &status
+	}()
+
+	select {
+	case status := <-done:
+		return status, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *LocalTestRunner) runCommand(ctx context.Context, test v1alpha3.TestConfiguration) (*v1alpha3.TestStatus, error) {
+	if err := r.linkBundleRoot(); err != nil {
+		return nil, err
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, test.Entrypoint[0], test.Entrypoint[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return convertErrorToStatus(err, ""), nil
+	}
+	// Children of the command may keep its output open after it is killed,
+	// so do not wait for them once ctx is done.
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- cmd.Wait()
+	}()
+	var runErr error
+	select {
+	case runErr = <-waitErr:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// Like a test pod's log, stdout is expected to hold the test status even
+	// if the command fails.
+	output := &v1alpha3.TestStatus{}
+	if err := json.Unmarshal(stdout.Bytes(), output); err != nil {
+		if runErr != nil {
+			err = runErr
+		}
+		return convertErrorToStatus(err, stdout.String()+stderr.String()), nil
+	}
+	return output, nil
+}
+
+// linkBundleRoot makes the bundle available at the bundle root.
+func (r *LocalTestRunner) linkBundleRoot() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.link != "" {
+		return nil
+	}
+
+	root := r.BundleRoot
+	if root == "" {
+		root = PodBundleRoot
+	}
+	bundlePath, err := filepath.Abs(r.BundlePath)
+	if err != nil {
+		return err
+	}
+	if target, err := filepath.EvalSymlinks(root); err == nil {
+		if resolved, _ := filepath.EvalSymlinks(bundlePath); target == resolved {
+			return nil
+		}
+		return fmt.Errorf("bundle root %s already exists and is not the bundle under test", root)
+	}
+	if err := os.Symlink(bundlePath, root); err != nil {
+		return fmt.Errorf("error linking bundle to bundle root %s, which must be creatable by the current user "+
+			"(use --local-bundle-root to link the bundle elsewhere): %w", root, err)
+	}
+	r.link = root
+	return nil
+}
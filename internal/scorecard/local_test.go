@@ -0,0 +1,154 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scorecard
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/operator-framework/api/pkg/apis/scorecard/v1alpha3"
+	"k8s.io/apimachinery/pkg/labels"
+
+	registryutil "github.com/operator-framework/operator-sdk/internal/registry"
+)
+
+func newLocalTestRunner(t *testing.T) *LocalTestRunner {
+	metadata, _, err := registryutil.FindBundleMetadata("testdata/bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &LocalTestRunner{
+		BundlePath:     "testdata/bundle",
+		BundleMetadata: metadata,
+		BundleRoot:     filepath.Join(t.TempDir(), "bundle"),
+	}
+	if err := r.Initialize(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// writeScript writes an executable shell script to a temporary directory.
+func writeScript(t *testing.T, script string) string {
+	path := filepath.Join(t.TempDir(), "test.sh")
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLocalTestRunnerBuiltin(t *testing.T) {
+	o := Scorecard{TestRunner: newLocalTestRunner(t)}
+	var err error
+	if o.Config, err = LoadConfig("testdata/bundle/tests/scorecard/config.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if o.Selector, err = labels.Parse("test=olm-bundle-validation-test"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests, err := o.Run(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tests.Items) != 1 {
+		t.Fatalf("Expected 1 test, got %d", len(tests.Items))
+	}
+	expectPass(t, tests.Items[0])
+	if name := tests.Items[0].Status.Results[0].Name; name != "olm-bundle-validation" {
+		t.Errorf("Expected olm-bundle-validation result, got %q", name)
+	}
+
+	status, err := o.TestRunner.RunTest(context.TODO(), v1alpha3.TestConfiguration{
+		Entrypoint: []string{BuiltinEntrypoint, "no-such-test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Results[0].State != v1alpha3.FailState {
+		t.Errorf("Expected an unknown test to fail, got %q", status.Results[0].State)
+	}
+}
+
+func TestLocalTestRunnerCommand(t *testing.T) {
+	r := newLocalTestRunner(t)
+	script := writeScript(t, `test -f "$1/metadata/annotations.yaml" || exit 1
+echo '{"results": [{"name": "custom", "state": "pass"}]}'
+`)
+
+	status, err := r.RunTest(context.TODO(), v1alpha3.TestConfiguration{Entrypoint: []string{script, r.BundleRoot}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectPass(t, v1alpha3.Test{Status: *status})
+	if status.Results[0].Name != "custom" {
+		t.Errorf("Expected custom result, got %q", status.Results[0].Name)
+	}
+
+	if err := r.Cleanup(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(r.BundleRoot); !os.IsNotExist(err) {
+		t.Errorf("Expected bundle root link to be removed, got %v", err)
+	}
+}
+
+func TestLocalTestRunnerCommandFailure(t *testing.T) {
+	r := newLocalTestRunner(t)
+	defer func() {
+		_ = r.Cleanup(context.TODO())
+	}()
+	script := writeScript(t, "echo 'not json'\nexit 2\n")
+
+	status, err := r.RunTest(context.TODO(), v1alpha3.TestConfiguration{Entrypoint: []string{script}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Results[0].State != v1alpha3.FailState || status.Results[0].Log != "not json\n" {
+		t.Errorf("Expected a failed result with the command output, got %+v", status.Results[0])
+	}
+}
+
+func TestLocalTestRunnerBundleRootNotCreatable(t *testing.T) {
+	r := newLocalTestRunner(t)
+	r.BundleRoot = filepath.Join(t.TempDir(), "missing", "bundle")
+	script := writeScript(t, "exit 0\n")
+
+	_, err := r.RunTest(context.TODO(), v1alpha3.TestConfiguration{Entrypoint: []string{script}})
+	if err == nil || !strings.Contains(err.Error(), "--local-bundle-root") {
+		t.Fatalf("Expected an error suggesting --local-bundle-root, got: %v", err)
+	}
+}
+
+func TestLocalTestRunnerTimeout(t *testing.T) {
+	r := newLocalTestRunner(t)
+	defer func() {
+		_ = r.Cleanup(context.TODO())
+	}()
+	script := writeScript(t, "sleep 5\n")
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	_, err := r.RunTest(ctx, v1alpha3.TestConfiguration{Entrypoint: []string{script}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded error, got: %v", err)
+	}
+}
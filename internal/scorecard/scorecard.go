@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/operator-framework/api/pkg/apis/scorecard/v1alpha3"
+	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -41,6 +42,12 @@ type Scorecard struct {
 	Selector    labels.Selector
 	TestRunner  TestRunner
 	SkipCleanup bool
+	// Cache, if set, holds the results of previous runs. Tests with a current
+	// cached result are not run again.
+	Cache *ResultCache
+	// RerunFailed only runs tests whose last cached result did not pass, or
+	// that have no cached result, and reports the last result of the others.
+	RerunFailed bool
 }
 
 type PodTestRunner struct {
@@ -66,10 +73,8 @@ var cleanupTimeout = time.Second * 30
 func (o Scorecard) Run(ctx context.Context) (testOutput v1alpha3.TestList, err error) {
 	testOutput = v1alpha3.NewTestList()
 
-	if err := o.TestRunner.Initialize(ctx); err != nil {
-		return testOutput, err
-	}
-
+	// The runner is only initialized once a test that is not cached must run.
+	initialized := false
 	for _, stage := range o.Config.Stages {
 		tests := o.selectTests(stage)
 		if len(tests) == 0 {
@@ -77,6 +82,13 @@ func (o Scorecard) Run(ctx context.Context) (testOutput v1alpha3.TestList, err e
 		}
 
 		output := make(chan v1alpha3.Test, len(tests))
+		tests = o.cachedTests(tests, output)
+		if len(tests) > 0 && !initialized {
+			if err := o.TestRunner.Initialize(ctx); err != nil {
+				return testOutput, err
+			}
+			initialized = true
+		}
 		if stage.Parallel {
 			o.runStageParallel(ctx, tests, output)
 		} else {
@@ -95,7 +107,7 @@ func (o Scorecard) Run(ctx context.Context) (testOutput v1alpha3.TestList, err e
 	default:
 	}
 
-	if !o.SkipCleanup {
+	if initialized && !o.SkipCleanup {
 		// Use a separate context for cleanup, which needs to run regardless of a prior timeout.
 		clctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
 		defer cancel()
@@ -129,6 +141,11 @@ func (o Scorecard) runTest(ctx context.Context, test v1alpha3.TestConfiguration)
 	result, err := o.TestRunner.RunTest(ctx, test)
 	if err != nil {
 		result = convertErrorToStatus(err, "")
+	} else if o.Cache != nil {
+		// Only results of tests that ran to completion are cached.
+		if err := o.Cache.Put(test, result); err != nil {
+			log.Warnf("Error caching test result: %v", err)
+		}
 	}
 
 	out := v1alpha3.NewTest()
@@ -137,6 +154,38 @@ func (o Scorecard) runTest(ctx context.Context, test v1alpha3.TestConfiguration)
 	return out
 }
 
+// cachedTests sends the cached results of tests to output, and returns the
+// tests that must be run.
+func (o Scorecard) cachedTests(tests []v1alpha3.TestConfiguration, output chan<- v1alpha3.Test) []v1alpha3.TestConfiguration {
+	if o.Cache == nil {
+		return tests
+	}
+	toRun := make([]v1alpha3.TestConfiguration, 0, len(tests))
+	for _, test := range tests {
+		// With RerunFailed, only tests whose last result did not pass are run,
+		// even if the inputs of others have changed since.
+		status, current := o.Cache.Get(test)
+		if status == nil || o.RerunFailed && !statusPassed(status) || !o.RerunFailed && !current {
+			toRun = append(toRun, test)
+			continue
+		}
+		out := v1alpha3.NewTest()
+		out.Spec = test
+		out.Status = *status
+		output <- out
+	}
+	return toRun
+}
+
+func statusPassed(status *v1alpha3.TestStatus) bool {
+	for _, r := range status.Results {
+		if r.State != v1alpha3.PassState {
+			return false
+		}
+	}
+	return true
+}
+
 // selectTests applies an optionally passed selector expression
 // against the configured set of tests, returning the selected tests
 func (o *Scorecard) selectTests(stage v1alpha3.StageConfiguration) []v1alpha3.TestConfiguration {
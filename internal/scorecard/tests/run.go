@@ -0,0 +1,52 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	scapiv1alpha3 "github.com/operator-framework/api/pkg/apis/scorecard/v1alpha3"
+	apimanifests "github.com/operator-framework/api/pkg/manifests"
+
+	registryutil "github.com/operator-framework/operator-sdk/internal/registry"
+)
+
+// Names are the names of all built-in tests.
+var Names = []string{
+	OLMBundleValidationTest,
+	OLMCRDsHaveValidationTest,
+	OLMCRDsHaveResourcesTest,
+	OLMSpecDescriptorsTest,
+	OLMStatusDescriptorsTest,
+	BasicCheckSpecTest,
+}
+
+// Run runs the built-in test name against bundle, which is unpacked at
+// bundleRoot. It returns false if name is not a built-in test.
+func Run(name, bundleRoot string, metadata registryutil.Labels, bundle *apimanifests.Bundle) (scapiv1alpha3.TestStatus, bool) {
+	switch name {
+	case OLMBundleValidationTest:
+		return BundleValidationTest(bundleRoot, metadata), true
+	case OLMCRDsHaveValidationTest:
+		return CRDsHaveValidationTest(bundle), true
+	case OLMCRDsHaveResourcesTest:
+		return CRDsHaveResourcesTest(bundle), true
+	case OLMSpecDescriptorsTest:
+		return SpecDescriptorsTest(bundle), true
+	case OLMStatusDescriptorsTest:
+		return StatusDescriptorsTest(bundle), true
+	case BasicCheckSpecTest:
+		return CheckSpecTest(bundle), true
+	}
+	return scapiv1alpha3.TestStatus{}, false
+}
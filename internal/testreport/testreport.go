@@ -0,0 +1,230 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testreport writes test results in formats understood by CI systems.
+package testreport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// JUnit is the name of the JUnit XML output format.
+	JUnit = "junit"
+	// TAP is the name of the Test Anything Protocol output format.
+	TAP = "tap"
+)
+
+// State is the outcome of a test case.
+type State string
+
+const (
+	Passed  State = "passed"
+	Failed  State = "failed"
+	Errored State = "errored"
+	Skipped State = "skipped"
+)
+
+// Suite is a named group of test cases.
+type Suite struct {
+	Name  string
+	Cases []Case
+}
+
+// Case is the result of a single test.
+type Case struct {
+	Name  string
+	State State
+	// Properties are arbitrary key/value pairs describing the test.
+	Properties map[string]string
+	// Message is a one-line summary of why a test did not pass.
+	Message string
+	// Details is the full explanation of why a test did not pass.
+	Details string
+	// Output is any output produced by the test.
+	Output string
+}
+
+// Write writes suites to w in format, which must be JUnit or TAP.
+func Write(w io.Writer, format string, suites []Suite) error {
+	switch format {
+	case JUnit:
+		return WriteJUnit(w, suites)
+	case TAP:
+		return WriteTAP(w, suites)
+	}
+	return fmt.Errorf("unknown test report format %q", format)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	Classname  string           `xml:"classname,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitResult     `xml:"failure,omitempty"`
+	Error      *junitResult     `xml:"error,omitempty"`
+	Skipped    *junitResult     `xml:"skipped,omitempty"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes suites to w as a JUnit XML report.
+func WriteJUnit(w io.Writer, suites []Suite) error {
+	report := junitTestSuites{}
+	for _, s := range suites {
+		suite := junitTestSuite{Name: s.Name}
+		for _, c := range s.Cases {
+			tc := junitTestCase{Name: c.Name, Classname: s.Name, SystemOut: c.Output}
+			if len(c.Properties) > 0 {
+				tc.Properties = &junitProperties{}
+				for _, k := range sortedKeys(c.Properties) {
+					tc.Properties.Properties = append(tc.Properties.Properties, junitProperty{Name: k, Value: c.Properties[k]})
+				}
+			}
+			result := &junitResult{Message: c.Message, Body: c.Details}
+			switch c.State {
+			case Failed:
+				tc.Failure = result
+				suite.Failures++
+			case Errored:
+				tc.Error = result
+				suite.Errors++
+			case Skipped:
+				tc.Skipped = result
+				suite.Skipped++
+			}
+			suite.Tests++
+			suite.Cases = append(suite.Cases, tc)
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("error marshaling JUnit output: %v", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteTAP writes suites to w as a TAP version 13 report. Cases that did not
+// pass are followed by a YAML diagnostic block.
+func WriteTAP(w io.Writer, suites []Suite) error {
+	total := 0
+	for _, s := range suites {
+		total += len(s.Cases)
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "TAP version 13\n1..%d\n", total)
+
+	n := 0
+	for _, s := range suites {
+		for _, c := range s.Cases {
+			n++
+			name := strings.ReplaceAll(c.Name, "#", `\#`)
+			if s.Name != "" {
+				name = s.Name + " " + name
+			}
+			switch c.State {
+			case Passed:
+				fmt.Fprintf(b, "ok %d - %s\n", n, name)
+			case Skipped:
+				fmt.Fprintf(b, "ok %d - %s # SKIP %s\n", n, name, c.Message)
+			default:
+				fmt.Fprintf(b, "not ok %d - %s\n", n, name)
+			}
+			if c.State == Passed || c.State == Skipped {
+				continue
+			}
+
+			diag := map[string]interface{}{"severity": string(c.State)}
+			if c.Message != "" {
+				diag["message"] = c.Message
+			}
+			if c.Details != "" {
+				diag["data"] = c.Details
+			}
+			if c.Output != "" {
+				diag["output"] = c.Output
+			}
+			if len(c.Properties) > 0 {
+				diag["properties"] = c.Properties
+			}
+			y, err := yaml.Marshal(diag)
+			if err != nil {
+				return fmt.Errorf("error marshaling TAP diagnostics: %v", err)
+			}
+			b.WriteString("  ---\n")
+			for _, line := range strings.Split(strings.TrimSuffix(string(y), "\n"), "\n") {
+				b.WriteString("  " + line + "\n")
+			}
+			b.WriteString("  ...\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
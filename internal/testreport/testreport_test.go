@@ -0,0 +1,119 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testreport
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+var suites = []Suite{
+	{
+		Name: "stage-1",
+		Cases: []Case{
+			{Name: "passing", State: Passed, Output: "all good"},
+			{
+				Name:       "failing",
+				State:      Failed,
+				Properties: map[string]string{"suite": "basic", "test": "failing"},
+				Message:    "spec is missing",
+				Details:    "Error: spec is missing\nSuggestion: add a spec",
+			},
+		},
+	},
+	{
+		Name: "stage-2",
+		Cases: []Case{
+			{Name: "erroring", State: Errored, Message: "pod timed out"},
+			{Name: "listed", State: Skipped, Message: "no results"},
+		},
+	},
+}
+
+func TestWriteJUnit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteJUnit(buf, suites); err != nil {
+		t.Fatal(err)
+	}
+
+	report := junitTestSuites{}
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf)
+	}
+	if report.Tests != 4 || report.Failures != 1 || report.Errors != 1 || report.Skipped != 1 {
+		t.Errorf("unexpected totals: %+v", report)
+	}
+	if len(report.Suites) != 2 || report.Suites[0].Name != "stage-1" || report.Suites[0].Tests != 2 {
+		t.Fatalf("unexpected suites: %+v", report.Suites)
+	}
+
+	failing := report.Suites[0].Cases[1]
+	if failing.Classname != "stage-1" {
+		t.Errorf("expected classname stage-1, got %q", failing.Classname)
+	}
+	if failing.Failure == nil || failing.Failure.Message != "spec is missing" ||
+		failing.Failure.Body != "Error: spec is missing\nSuggestion: add a spec" {
+		t.Errorf("unexpected failure: %+v", failing.Failure)
+	}
+	if failing.Properties == nil || len(failing.Properties.Properties) != 2 ||
+		failing.Properties.Properties[0] != (junitProperty{Name: "suite", Value: "basic"}) {
+		t.Errorf("unexpected properties: %+v", failing.Properties)
+	}
+	if report.Suites[0].Cases[0].SystemOut != "all good" {
+		t.Errorf("unexpected system-out: %q", report.Suites[0].Cases[0].SystemOut)
+	}
+	if report.Suites[1].Cases[0].Error == nil || report.Suites[1].Cases[1].Skipped == nil {
+		t.Errorf("unexpected stage-2 cases: %+v", report.Suites[1].Cases)
+	}
+}
+
+func TestWriteTAP(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteTAP(buf, suites); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `TAP version 13
+1..4
+ok 1 - stage-1 passing
+not ok 2 - stage-1 failing
+  ---
+  data: |-
+    Error: spec is missing
+    Suggestion: add a spec
+  message: spec is missing
+  properties:
+    suite: basic
+    test: failing
+  severity: failed
+  ...
+not ok 3 - stage-2 erroring
+  ---
+  message: pod timed out
+  severity: errored
+  ...
+ok 4 - stage-2 listed # SKIP no results
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "yaml", suites); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}